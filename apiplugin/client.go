@@ -0,0 +1,183 @@
+package apiplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiVersion is the path of the API version below the zone's API address.
+const apiVersion = "v1.0/"
+
+// client calls the AutoTask REST API of a zone.
+type client struct {
+	http            *http.Client
+	baseURL         string // API address of the zone, ending in a slash.
+	integrationCode string
+	username        string
+	secret          string
+}
+
+// zoneInformation is the response of the zone discovery.
+type zoneInformation struct {
+	ZoneName string `json:"zoneName"`
+	URL      string `json:"url"`
+	WebURL   string `json:"webUrl"`
+	CI       int    `json:"ci"`
+}
+
+// filter is a condition of a query.
+type filter struct {
+	Op    string `json:"op"`
+	Field string `json:"field"`
+	Value any    `json:"value"`
+}
+
+type queryRequest struct {
+	Filter []filter `json:"filter"`
+}
+
+// errorResponse is the body returned by the API when a request fails.
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// discoverZone looks up the zone of the API user and returns a client for it.
+func discoverZone(ctx context.Context, httpClient *http.Client, zoneURL, integrationCode, username, secret string) (*client, error) {
+	u, err := url.Parse(zoneURL)
+	if err != nil {
+		return nil, fmt.Errorf("discoverZone: invalid zone url: %v", err)
+	}
+
+	q := u.Query()
+	q.Set("user", username)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("discoverZone: %v", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("discoverZone: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discoverZone: %v", responseError(resp))
+	}
+
+	var zone zoneInformation
+	err = json.NewDecoder(resp.Body).Decode(&zone)
+	if err != nil {
+		return nil, fmt.Errorf("discoverZone: could not decode zone information: %v", err)
+	}
+
+	if zone.URL == "" {
+		return nil, fmt.Errorf("discoverZone: no zone found for user: %v", username)
+	}
+
+	baseURL := zone.URL
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	return &client{
+		http:            httpClient,
+		baseURL:         baseURL,
+		integrationCode: integrationCode,
+		username:        username,
+		secret:          secret,
+	}, nil
+}
+
+// query returns the items of an entity matching all the filters.
+func (c *client) query(ctx context.Context, entity string, filters []filter, items any) error {
+	var resp struct {
+		Items json.RawMessage `json:"items"`
+	}
+
+	err := c.do(ctx, http.MethodPost, entity+"/query", queryRequest{Filter: filters}, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Items == nil {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Items, items)
+}
+
+// create creates an item of an entity and returns its ID.
+func (c *client) create(ctx context.Context, entity string, item any) (int, error) {
+	var resp struct {
+		ItemId int `json:"itemId"`
+	}
+
+	err := c.do(ctx, http.MethodPost, entity, item, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.ItemId, nil
+}
+
+// do sends an authenticated request and decodes the response into out.
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("could not encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+apiVersion+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ApiIntegrationCode", c.integrationCode)
+	req.Header.Set("UserName", c.username)
+	req.Header.Set("Secret", c.secret)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v %v: %v", method, path, responseError(resp))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("%v %v: could not decode response: %v", method, path, err)
+	}
+
+	return nil
+}
+
+// responseError describes a failed response, including the errors reported by the API.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var e errorResponse
+	if json.Unmarshal(body, &e) == nil && len(e.Errors) > 0 {
+		return fmt.Errorf("%v: %v", resp.Status, strings.Join(e.Errors, ", "))
+	}
+
+	return fmt.Errorf("%v", resp.Status)
+}
+
+// newHTTPClient returns the HTTP client used to call the API.
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 60 * time.Second}
+}
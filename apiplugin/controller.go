@@ -0,0 +1,333 @@
+// Package apiplugin captures time entries with the AutoTask REST API, as an alternative to the
+// browser automation in pwplugin for accounts that have an API user.
+package apiplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+const (
+	entityResources   = "Resources"
+	entityTimeEntries = "TimeEntries"
+
+	// Layouts of the dates and times sent to the API, times are in UTC.
+	apiDateLayout     = "2006-01-02T00:00:00"
+	apiDateTimeLayout = "2006-01-02T15:04:05Z"
+)
+
+// BackendName is the name the API backend is registered with.
+const BackendName = "api"
+
+// EnvSecret is the environment variable holding the secret of the API user, it takes precedence
+// over the secret in the configuration and keeps it out of the file.
+const EnvSecret = "GT_AT_API_SECRET"
+
+func init() {
+	at.RegisterBackend(BackendName, func(config at.BackendConfig) (at.AutoTasker, error) {
+		var opts Options
+		err := config.Decode(&opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if secret := os.Getenv(EnvSecret); secret != "" {
+			opts.Secret = secret
+		}
+
+		return NewAutoTaskAPI(opts), nil
+	})
+}
+
+// Options defines the settings of the API backend, read from the api section of the configuration.
+type Options struct {
+	ZoneURL         string `json:"zone-url"`         // Zone discovery address, defaults to URI_API_ZONE.
+	IntegrationCode string `json:"integration-code"` // ApiIntegrationCode of the API user's tracking identifier.
+	Username        string `json:"username"`         // Username of the API user.
+	Secret          string `json:"secret"`           // Secret of the API user.
+	ResourceId      int    `json:"resource-id"`      // Resource the entries are logged for, by default the resource with the email address of the credentials.
+}
+
+// NewAutoTaskAPI initializes and returns an instance of the autoTaskAPI.
+func NewAutoTaskAPI(opts Options) at.AutoTasker {
+	return &autoTaskAPI{http: newHTTPClient(), opts: opts}
+}
+
+type autoTaskAPI struct {
+	http *http.Client
+	opts Options
+}
+
+// timeEntry is a TimeEntries item of the API.
+type timeEntry struct {
+	Id            int     `json:"id,omitempty"`
+	ResourceId    int     `json:"resourceID"`
+	TicketId      int     `json:"ticketID,omitempty"`
+	TaskId        int     `json:"taskID,omitempty"`
+	DateWorked    string  `json:"dateWorked"`
+	StartDateTime string  `json:"startDateTime,omitempty"`
+	EndDateTime   string  `json:"endDateTime,omitempty"`
+	HoursWorked   float64 `json:"hoursWorked"`
+	SummaryNotes  string  `json:"summaryNotes"`
+	InternalNotes string  `json:"internalNotes,omitempty"`
+	ShowOnInvoice *bool   `json:"showOnInvoice,omitempty"`
+	IsNonBillable *bool   `json:"isNonBillable,omitempty"`
+}
+
+// resource is a Resources item of the API.
+type resource struct {
+	Id    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+// CaptureTimes captures time entries in AutoTask using the REST API.
+func (a *autoTaskAPI) CaptureTimes(entries at.TimeEntries, opts at.CaptureOptions) error {
+	return a.CaptureTimesContext(context.Background(), entries, opts)
+}
+
+// CaptureTimesContext captures time entries in AutoTask using the REST API until the context is done.
+func (a *autoTaskAPI) CaptureTimesContext(ctx context.Context, entries at.TimeEntries, opts at.CaptureOptions) error {
+	slog.Info("Capture entries", "backend", BackendName, "entries", len(entries))
+
+	err := validateOptions(a.opts)
+	if err != nil {
+		return err
+	}
+
+	// Entries are only created, existing entries are skipped instead of overwritten
+	policy := opts.DuplicatePolicy
+	if policy == at.DuplicateUpdate {
+		slog.Warn("the api backend can't update entries, existing entries are skipped", "backend", BackendName)
+		policy = at.DuplicateSkip
+	}
+
+	// The role, work type, billing code and ticket status are IDs in the API, names aren't looked up
+	for _, te := range entries {
+		if te.Role != "" || te.WorkType != "" || te.BillingCode != "" || te.TicketStatus != "" {
+			slog.Warn("the api backend can't set the role, work type, billing code or ticket status, they are ignored", "backend", BackendName)
+			break
+		}
+	}
+
+	zoneURL := a.opts.ZoneURL
+	if zoneURL == "" {
+		zoneURL = at.URI_API_ZONE
+	}
+
+	at.Notify(opts.Observer, at.Event{Type: at.EventLoginStarted})
+
+	c, err := discoverZone(ctx, a.http, zoneURL, a.opts.IntegrationCode, a.opts.Username, a.opts.Secret)
+	if err != nil {
+		return entries.Cancel(ctx, opts.Observer, fmt.Errorf("could not discover zone: %v", err))
+	}
+
+	resourceId, err := c.findResourceId(ctx, a.opts.ResourceId, opts.Credentials.Username)
+	if err != nil {
+		return entries.Cancel(ctx, opts.Observer, fmt.Errorf("could not find resource: %v", err))
+	}
+
+	at.Notify(opts.Observer, at.Event{Type: at.EventLoggedIn})
+
+	if opts.EmbedMarkers {
+		entries.EmbedMarkers()
+	}
+
+	if opts.DryRun {
+		slog.Info("Dry run, skipping")
+		return nil
+	}
+
+	// The entries created by this run aren't existing entries of the ones after them
+	created := map[int]bool{}
+
+	for _, te := range entries {
+		if ctx.Err() != nil {
+			return entries.Cancel(ctx, opts.Observer, ctx.Err())
+		}
+
+		err := c.captureEntry(ctx, resourceId, te, policy, created)
+		if ctx.Err() != nil {
+			return entries.Cancel(ctx, opts.Observer, ctx.Err())
+		}
+
+		if err != nil {
+			slog.Error("could not log time entry", "id", te.Id, "date", te.DateStr, "error", err)
+			te.SetError(err)
+		}
+		at.NotifyEntry(opts.Observer, te)
+	}
+
+	slog.Debug("End of CaptureTimes")
+
+	return nil
+}
+
+// validateOptions checks that the API user is configured.
+func validateOptions(opts Options) error {
+	if opts.IntegrationCode == "" || opts.Username == "" || opts.Secret == "" {
+		return errors.New("the api backend needs an integration code, username and secret")
+	}
+
+	return nil
+}
+
+// findResourceId returns the configured resource, or looks up the resource with the email
+// address of the user's credentials.
+func (c *client) findResourceId(ctx context.Context, resourceId int, email string) (int, error) {
+	if resourceId != 0 {
+		return resourceId, nil
+	}
+
+	if email == "" {
+		return 0, errors.New("either a resource ID or the username of the credentials is required")
+	}
+
+	var resources []resource
+	err := c.query(ctx, entityResources, []filter{{Op: "eq", Field: "email", Value: email}}, &resources)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resources) != 1 {
+		return 0, fmt.Errorf("expected one resource with email %v, found %d", email, len(resources))
+	}
+
+	slog.Info("Logging time for resource", "resource", resources[0].Id)
+
+	return resources[0].Id, nil
+}
+
+// captureEntry creates the time entry, unless it already exists. The ID of the created item is
+// added to created.
+func (c *client) captureEntry(ctx context.Context, resourceId int, te *at.TimeEntry, policy at.DuplicatePolicy, created map[int]bool) error {
+	te.SetStarted()
+	defer te.SetFinished()
+
+	slog.Debug("Capture time entry", "id", te.Id, "ticket", te.IsTicket, "date", te.DateStr, "start", te.StartTimeStr, "duration", te.Duration)
+
+	item, err := newTimeEntry(resourceId, te)
+	if err != nil {
+		return err
+	}
+
+	err = c.markExisting(ctx, item, te, policy, created)
+	if err != nil {
+		return fmt.Errorf("could not check for existing entries: %v", err)
+	}
+
+	if te.Exists {
+		slog.Info("Skipping entry as it already exists", "id", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
+		return nil
+	}
+
+	id, err := c.create(ctx, entityTimeEntries, item)
+	if err != nil {
+		return fmt.Errorf("could not create time entry: %v", err)
+	}
+
+	slog.Info("Saved entry", "id", te.Id, "date", te.DateStr, "timeEntry", id)
+	te.Submitted = true
+	created[id] = true
+
+	return nil
+}
+
+// markExisting marks the entry as existing when the resource already has a matching time entry
+// on the same ticket or task and date, and for tickets the same start time if it has one. The items in created
+// were created by this run and are left out.
+func (c *client) markExisting(ctx context.Context, item timeEntry, te *at.TimeEntry, policy at.DuplicatePolicy, created map[int]bool) error {
+	if policy == at.DuplicateForce {
+		return nil
+	}
+
+	filters := []filter{
+		{Op: "eq", Field: "resourceID", Value: item.ResourceId},
+		{Op: "eq", Field: "dateWorked", Value: item.DateWorked},
+	}
+
+	if te.IsTicket {
+		filters = append(filters, filter{Op: "eq", Field: "ticketID", Value: item.TicketId})
+	} else {
+		filters = append(filters, filter{Op: "eq", Field: "taskID", Value: item.TaskId})
+	}
+
+	var existing []timeEntry
+	err := c.query(ctx, entityTimeEntries, filters, &existing)
+	if err != nil {
+		return err
+	}
+
+	// The query already matches the date, the notes decide how the entries are matched
+	for _, e := range existing {
+		if created[e.Id] || (te.IsTicket && e.StartDateTime != "" && !sameTime(e.StartDateTime, item.StartDateTime)) {
+			continue
+		}
+
+		te.MatchConversation(te.DateStr, e.SummaryNotes, te.DateStr, policy)
+		if te.Exists {
+			break
+		}
+	}
+
+	return nil
+}
+
+// sameTime compares two date times of the API, which may be formatted differently.
+func sameTime(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+
+	return ta.Equal(tb)
+}
+
+// newTimeEntry converts the entry to a TimeEntries item, the start and end time are only set
+// when the entry has a start time.
+func newTimeEntry(resourceId int, te *at.TimeEntry) (timeEntry, error) {
+	item := timeEntry{
+		ResourceId:   resourceId,
+		DateWorked:   te.Date.Format(apiDateLayout),
+		HoursWorked:  math.Round(float64(te.DurationHours)*100+float64(te.DurationMinutes)*100/60) / 100,
+		SummaryNotes: te.Notes(),
+
+		InternalNotes: te.InternalNotes,
+		ShowOnInvoice: te.ShowOnInvoice,
+		IsNonBillable: te.NonBillable,
+	}
+
+	if te.IsTicket {
+		item.TicketId = te.Id
+	} else {
+		item.TaskId = te.Id
+	}
+
+	if te.StartTimeStr != "" {
+		start, err := time.ParseInLocation("2006-01-02 15:04", te.Date.Format("2006-01-02")+" "+te.StartTimeStr, time.Local)
+		if err != nil {
+			return item, fmt.Errorf("invalid start time: %v", err)
+		}
+
+		end := start.Add(time.Duration(te.DurationHours)*time.Hour + time.Duration(te.DurationMinutes)*time.Minute)
+		if te.EndTimeStr != "" {
+			end, err = time.ParseInLocation("2006-01-02 15:04", te.Date.Format("2006-01-02")+" "+te.EndTimeStr, time.Local)
+			if err != nil {
+				return item, fmt.Errorf("invalid end time: %v", err)
+			}
+		}
+
+		item.StartDateTime = start.UTC().Format(apiDateTimeLayout)
+		item.EndDateTime = end.UTC().Format(apiDateTimeLayout)
+	}
+
+	return item, nil
+}
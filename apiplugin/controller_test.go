@@ -0,0 +1,282 @@
+package apiplugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/apiplugin/fakeapi"
+	"github.com/philipf/gt-at/at"
+)
+
+const dateFormat = "2006/01/02"
+
+func newStub(t *testing.T) (*fakeapi.Server, Options, at.CaptureOptions) {
+	s := fakeapi.NewServer()
+	t.Cleanup(s.Close)
+
+	s.AddResource(fakeapi.Resource{Id: 29682885, Email: "jo@example.com"})
+
+	apiOpts := Options{
+		ZoneURL:         s.ZoneURL(),
+		IntegrationCode: s.IntegrationCode,
+		Username:        s.Username,
+		Secret:          s.Secret,
+	}
+
+	opts := at.CaptureOptions{
+		Credentials: at.Credentials{Username: "jo@example.com"},
+		DateFormat:  dateFormat,
+	}
+
+	return s, apiOpts, opts
+}
+
+func date(day int) time.Time {
+	return time.Date(2023, 9, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCaptureTimes(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	entries := at.TimeEntries{
+		at.NewEntry(266016, true, date(12), "08:00", 1.5, "Ticket work", "", dateFormat),
+		at.NewEntry(17010, false, date(13), "", 0.25, "Task work", "", dateFormat),
+	}
+
+	err := NewAutoTaskAPI(apiOpts).CaptureTimes(entries, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, te := range entries {
+		if !te.Submitted || te.Error != nil {
+			t.Errorf("expected entry %v to be submitted, error: %v", te.Id, te.Error)
+		}
+	}
+
+	saved := s.Entries()
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(saved))
+	}
+
+	ticket := saved[0]
+	if ticket.ResourceId != 29682885 || ticket.TicketId != 266016 || ticket.DateWorked != "2023-09-12T00:00:00" ||
+		ticket.HoursWorked != 1.5 || ticket.SummaryNotes != "Ticket work" {
+		t.Errorf("unexpected ticket entry: %+v", ticket)
+	}
+
+	start := time.Date(2023, 9, 12, 8, 0, 0, 0, time.Local).UTC().Format(apiDateTimeLayout)
+	end := time.Date(2023, 9, 12, 9, 30, 0, 0, time.Local).UTC().Format(apiDateTimeLayout)
+	if ticket.StartDateTime != start || ticket.EndDateTime != end {
+		t.Errorf("expected %v - %v, got %v - %v", start, end, ticket.StartDateTime, ticket.EndDateTime)
+	}
+
+	task := saved[1]
+	if task.TaskId != 17010 || task.TicketId != 0 || task.HoursWorked != 0.25 || task.StartDateTime != "" {
+		t.Errorf("unexpected task entry: %+v", task)
+	}
+}
+
+func TestCaptureTimesOptionalFields(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+	billable := false
+
+	te := at.NewEntry(266016, true, date(12), "08:00", 1.5, "Ticket work", "", dateFormat)
+	te.EndTimeStr = "10:00"
+	te.InternalNotes = "Reboot needed"
+	te.NonBillable = &billable
+
+	err := NewAutoTaskAPI(apiOpts).CaptureTimes(at.TimeEntries{te}, opts)
+	if err != nil || te.Error != nil {
+		t.Fatalf("unexpected error: %v, %v", err, te.Error)
+	}
+
+	ticket := s.Entries()[0]
+	end := time.Date(2023, 9, 12, 10, 0, 0, 0, time.Local).UTC().Format(apiDateTimeLayout)
+	if ticket.EndDateTime != end || ticket.HoursWorked != 1.5 {
+		t.Errorf("expected the end time %v with 1.5 hours worked, got %+v", end, ticket)
+	}
+	if ticket.InternalNotes != "Reboot needed" || ticket.IsNonBillable == nil || *ticket.IsNonBillable || ticket.ShowOnInvoice != nil {
+		t.Errorf("unexpected optional fields: %+v", ticket)
+	}
+}
+
+func TestCaptureTimesExisting(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	existing := at.NewEntry(266016, true, date(12), "08:00", 1, "Marked", "", dateFormat)
+	existing.EmbedMarker = true
+
+	s.AddEntry(fakeapi.TimeEntry{ResourceId: 29682885, TicketId: 266016, DateWorked: "2023-09-12T00:00:00", HoursWorked: 1, SummaryNotes: existing.Notes()})
+	s.AddEntry(fakeapi.TimeEntry{ResourceId: 29682885, TicketId: 266017, DateWorked: "2023-09-12T00:00:00", HoursWorked: 1, SummaryNotes: "No marker"})
+
+	entries := at.TimeEntries{
+		at.NewEntry(266016, true, date(12), "08:00", 1, "Marked", "", dateFormat),
+		at.NewEntry(266016, true, date(12), "10:00", 1, "Other", "", dateFormat),
+		at.NewEntry(266017, true, date(12), "11:00", 1, "Same day", "", dateFormat),
+	}
+
+	opts.EmbedMarkers = true
+	err := NewAutoTaskAPI(apiOpts).CaptureTimes(entries, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first matches its marker, the second has another marker on the same day and the third
+	// matches a conversation without a marker on the same day
+	expected := []bool{true, false, true}
+	for i, te := range entries {
+		if te.Exists != expected[i] || te.Submitted == expected[i] {
+			t.Errorf("entry %d: expected exists %v, got exists %v submitted %v", i, expected[i], te.Exists, te.Submitted)
+		}
+	}
+
+	if len(s.Entries()) != 3 {
+		t.Errorf("expected only one entry to be created, got %d entries", len(s.Entries()))
+	}
+}
+
+func TestCaptureTimesErrors(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	bad := apiOpts
+	bad.Secret = "wrong"
+	err := NewAutoTaskAPI(bad).CaptureTimes(at.TimeEntries{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("expected invalid credentials error, got: %v", err)
+	}
+
+	bad = apiOpts
+	bad.Secret = ""
+	err = NewAutoTaskAPI(bad).CaptureTimes(at.TimeEntries{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "needs an integration code") {
+		t.Errorf("expected missing secret error, got: %v", err)
+	}
+
+	badCreds := opts
+	badCreds.Credentials.Username = "nobody@example.com"
+	err = NewAutoTaskAPI(apiOpts).CaptureTimes(at.TimeEntries{}, badCreds)
+	if err == nil || !strings.Contains(err.Error(), "found 0") {
+		t.Errorf("expected resource not found error, got: %v", err)
+	}
+
+	// Failing entries are marked and don't stop the others
+	entries := at.TimeEntries{
+		at.NewEntry(266016, true, date(12), "8am", 1, "Bad start", "", dateFormat),
+		at.NewEntry(266016, true, date(13), "08:00", 1, "Good", "", dateFormat),
+	}
+
+	err = NewAutoTaskAPI(apiOpts).CaptureTimes(entries, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries[0].Error == nil || !entries[1].Submitted {
+		t.Errorf("expected the first entry to fail and the second to be submitted: %v, %v", entries[0].Error, entries[1].Submitted)
+	}
+
+	if len(s.Entries()) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(s.Entries()))
+	}
+}
+
+// cancelAfterCreate cancels the context once the first time entry is created.
+type cancelAfterCreate struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelAfterCreate) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if strings.HasSuffix(req.URL.Path, "/TimeEntries") {
+		c.cancel()
+	}
+	return resp, err
+}
+
+func TestCaptureTimesContextCancelled(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	entries := at.TimeEntries{
+		at.NewEntry(266016, true, date(12), "08:00", 1, "First", "", dateFormat),
+		at.NewEntry(266016, true, date(13), "08:00", 1, "Second", "", dateFormat),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := &autoTaskAPI{http: &http.Client{Transport: cancelAfterCreate{cancel}}, opts: apiOpts}
+	err := a.CaptureTimesContext(ctx, entries, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+
+	if !entries[0].Submitted || entries[1].Submitted || !errors.Is(entries[1].Error, context.Canceled) {
+		t.Errorf("expected only the first entry to be saved, got: %v, %v", entries[0].Error, entries[1].Error)
+	}
+
+	if len(s.Entries()) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(s.Entries()))
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	autoTasker, err := at.NewBackend(BackendName, at.BackendConfig{
+		"zone-url":         apiOpts.ZoneURL,
+		"integration-code": apiOpts.IntegrationCode,
+		"username":         apiOpts.Username,
+		"secret":           apiOpts.Secret,
+		"resource-id":      29682885,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := at.TimeEntries{at.NewEntry(266016, true, date(12), "08:00", 1, "Ticket work", "", dateFormat)}
+	opts.Credentials.Username = ""
+	err = autoTasker.CaptureTimes(entries, opts)
+	if err != nil || !entries[0].Submitted {
+		t.Fatalf("expected the entry to be submitted with the decoded settings: %v, %v", err, entries[0].Error)
+	}
+
+	if len(s.Entries()) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(s.Entries()))
+	}
+}
+
+func TestCaptureTimesSameDay(t *testing.T) {
+	s, apiOpts, opts := newStub(t)
+
+	start := time.Date(2023, 9, 12, 10, 30, 0, 0, time.Local).UTC().Format(apiDateTimeLayout)
+	s.AddEntry(fakeapi.TimeEntry{ResourceId: 29682885, TicketId: 266016, DateWorked: "2023-09-12T00:00:00", StartDateTime: start, HoursWorked: 1, SummaryNotes: "Entered by hand"})
+
+	// Neither is a duplicate of the entry the other creates, nor of the entry at another time
+	entries := at.TimeEntries{
+		at.NewEntry(266016, true, date(12), "08:00", 1, "Stand-up", "", dateFormat),
+		at.NewEntry(266016, true, date(12), "14:00", 1, "Fixing", "", dateFormat),
+		at.NewEntry(266016, true, date(12), "10:30", 1, "Entered by hand", "", dateFormat),
+		at.NewEntry(17010, false, date(12), "", 1, "Design", "", dateFormat),
+		at.NewEntry(17010, false, date(12), "", 1, "Review", "", dateFormat),
+	}
+
+	err := NewAutoTaskAPI(apiOpts).CaptureTimes(entries, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []bool{true, true, false, true, true}
+	for i, te := range entries {
+		if te.Submitted != expected[i] || te.Exists == expected[i] {
+			t.Errorf("entry %d: expected submitted %v, got submitted %v exists %v", i, expected[i], te.Submitted, te.Exists)
+		}
+	}
+
+	if len(s.Entries()) != 5 {
+		t.Errorf("expected 4 entries to be created, got %d entries", len(s.Entries()))
+	}
+}
@@ -0,0 +1,262 @@
+// Package fakeapi provides a stub of the AutoTask REST API for testing the API backend without
+// network access. It serves the zone discovery, queries of resources and time entries and the
+// creation of time entries, which are kept in memory.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+const (
+	apiPath = "/atservicesrest/"
+
+	uriZoneInformation = apiPath + "v1.0/zoneInformation"
+	uriResourcesQuery  = apiPath + "v1.0/Resources/query"
+	uriTimeEntries     = apiPath + "v1.0/TimeEntries"
+	uriTimeEntryQuery  = apiPath + "v1.0/TimeEntries/query"
+)
+
+// TimeEntry is a time entry saved in the stub, using the field names of the API.
+type TimeEntry struct {
+	Id            int     `json:"id"`
+	ResourceId    int     `json:"resourceID"`
+	TicketId      int     `json:"ticketID,omitempty"`
+	TaskId        int     `json:"taskID,omitempty"`
+	DateWorked    string  `json:"dateWorked"`
+	StartDateTime string  `json:"startDateTime,omitempty"`
+	EndDateTime   string  `json:"endDateTime,omitempty"`
+	HoursWorked   float64 `json:"hoursWorked"`
+	SummaryNotes  string  `json:"summaryNotes"`
+	InternalNotes string  `json:"internalNotes,omitempty"`
+	ShowOnInvoice *bool   `json:"showOnInvoice,omitempty"`
+	IsNonBillable *bool   `json:"isNonBillable,omitempty"`
+}
+
+// Resource is a resource of the stub.
+type Resource struct {
+	Id    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+// Server is a stub of the AutoTask REST API. The exported fields must be set before the first request.
+type Server struct {
+	*httptest.Server
+
+	IntegrationCode string // ApiIntegrationCode accepted by the stub.
+	Username        string // Username of the API user accepted by the stub.
+	Secret          string // Secret accepted by the stub.
+
+	mu        sync.Mutex
+	resources []Resource
+	entries   []TimeEntry
+	nextId    int
+}
+
+// NewServer starts a stub API server, it must be closed when no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		IntegrationCode: "GTAT",
+		Username:        "api@example.com",
+		Secret:          "secret",
+		nextId:          1000,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(uriZoneInformation, s.handleZoneInformation)
+	mux.HandleFunc(uriResourcesQuery, s.authenticated(s.handleResourcesQuery))
+	mux.HandleFunc(uriTimeEntryQuery, s.authenticated(s.handleTimeEntryQuery))
+	mux.HandleFunc(uriTimeEntries, s.authenticated(s.handleCreateTimeEntry))
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// ZoneURL returns the zone discovery address of the stub.
+func (s *Server) ZoneURL() string {
+	return s.URL + uriZoneInformation
+}
+
+// AddResource adds a resource that can be looked up by email address.
+func (s *Server) AddResource(r Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resources = append(s.resources, r)
+}
+
+// AddEntry adds an existing time entry, its ID is assigned by the stub.
+func (s *Server) AddEntry(e TimeEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(e)
+}
+
+// Entries returns the time entries in the order they were added.
+func (s *Server) Entries() []TimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]TimeEntry(nil), s.entries...)
+}
+
+func (s *Server) add(e TimeEntry) int {
+	s.nextId++
+	e.Id = s.nextId
+	s.entries = append(s.entries, e)
+
+	return e.Id
+}
+
+func (s *Server) handleZoneInformation(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("user") != s.Username {
+		writeErrors(w, http.StatusNotFound, "zone not found")
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"zoneName": "Stub",
+		"url":      s.URL + apiPath,
+		"webUrl":   s.URL + "/",
+		"ci":       0,
+	})
+}
+
+// authenticated rejects requests without the credentials of the API user.
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("ApiIntegrationCode") != s.IntegrationCode ||
+			r.Header.Get("UserName") != s.Username ||
+			r.Header.Get("Secret") != s.Secret {
+			writeErrors(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			writeErrors(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func (s *Server) handleResourcesQuery(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]any, len(s.resources))
+	for i, res := range s.resources {
+		items[i] = res
+	}
+
+	s.writeQuery(w, r, items)
+}
+
+func (s *Server) handleTimeEntryQuery(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]any, len(s.entries))
+	for i, e := range s.entries {
+		items[i] = e
+	}
+
+	s.writeQuery(w, r, items)
+}
+
+func (s *Server) handleCreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	var e TimeEntry
+	err := json.NewDecoder(r.Body).Decode(&e)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if e.ResourceId == 0 || e.DateWorked == "" || e.HoursWorked <= 0 {
+		writeErrors(w, http.StatusBadRequest, "resourceID, dateWorked and hoursWorked are required")
+		return
+	}
+
+	if (e.TicketId == 0) == (e.TaskId == 0) {
+		writeErrors(w, http.StatusBadRequest, "either ticketID or taskID is required")
+		return
+	}
+
+	s.mu.Lock()
+	id := s.add(e)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{"itemId": id})
+}
+
+type filter struct {
+	Op    string `json:"op"`
+	Field string `json:"field"`
+	Value any    `json:"value"`
+}
+
+// writeQuery writes the items matching all the filters of the query, only "eq" is supported.
+func (s *Server) writeQuery(w http.ResponseWriter, r *http.Request, items []any) {
+	var query struct {
+		Filter []filter `json:"filter"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&query)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := make([]any, 0)
+	for _, item := range items {
+		fields := toFields(item)
+
+		match := true
+		for _, f := range query.Filter {
+			if f.Op != "eq" {
+				writeErrors(w, http.StatusBadRequest, fmt.Sprintf("unsupported op: %v", f.Op))
+				return
+			}
+
+			if fmt.Sprint(fields[f.Field]) != fmt.Sprint(f.Value) {
+				match = false
+			}
+		}
+
+		if match {
+			result = append(result, item)
+		}
+	}
+
+	writeJSON(w, map[string]any{
+		"items":       result,
+		"pageDetails": map[string]any{"count": len(result)},
+	})
+}
+
+// toFields returns the fields of an item by their JSON name.
+func toFields(item any) map[string]any {
+	data, _ := json.Marshal(item)
+
+	fields := map[string]any{}
+	json.Unmarshal(data, &fields)
+
+	return fields
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeErrors(w http.ResponseWriter, status int, errors ...string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"errors": errors})
+}
@@ -0,0 +1,90 @@
+package at
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBackend is the name of the backend used when none is chosen.
+const DefaultBackend = "playwright"
+
+// BackendConfig is the configuration section of a backend, e.g. the `api` section of the
+// configuration file for the backend named api.
+type BackendConfig map[string]any
+
+// Decode decodes the configuration into v, using the field names or json tags of v.
+func (c BackendConfig) Decode(v any) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("could not encode backend config: %v", err)
+	}
+
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("could not decode backend config: %v", err)
+	}
+
+	return nil
+}
+
+// BackendFactory creates a backend from its configuration section.
+type BackendFactory func(config BackendConfig) (AutoTasker, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a backend available by name, it is typically called from the init
+// function of the package implementing the backend. It panics if the name is already
+// registered or the factory is nil.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if factory == nil {
+		panic("at: RegisterBackend factory is nil")
+	}
+
+	if _, dup := backends[name]; dup {
+		panic("at: RegisterBackend called twice for backend " + name)
+	}
+
+	backends[name] = factory
+}
+
+// Backends returns the sorted names of the registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewBackend creates the backend registered with the name, an empty name selects the
+// DefaultBackend.
+func NewBackend(name string, config BackendConfig) (AutoTasker, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown backend: %v, expected one of: %v", name, strings.Join(Backends(), ", "))
+	}
+
+	return factory(config)
+}
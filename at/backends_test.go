@@ -0,0 +1,53 @@
+package at
+
+import (
+	"strings"
+	"testing"
+)
+
+type stubBackend struct {
+	Endpoint string `json:"endpoint"`
+	Retries  int    `json:"retries"`
+}
+
+func (s *stubBackend) CaptureTimes(entries TimeEntries, opts CaptureOptions) error {
+	return nil
+}
+
+func TestBackends(t *testing.T) {
+	RegisterBackend("test-stub", func(config BackendConfig) (AutoTasker, error) {
+		b := &stubBackend{}
+		err := config.Decode(b)
+		return b, err
+	})
+
+	found := false
+	for _, name := range Backends() {
+		found = found || name == "test-stub"
+	}
+	if !found {
+		t.Errorf("expected test-stub in %v", Backends())
+	}
+
+	b, err := NewBackend("test-stub", BackendConfig{"endpoint": "https://example.com", "retries": 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stub := b.(*stubBackend)
+	if stub.Endpoint != "https://example.com" || stub.Retries != 3 {
+		t.Errorf("expected the config to be decoded, got %+v", stub)
+	}
+
+	_, err = NewBackend("nope", nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown backend: nope") {
+		t.Errorf("expected unknown backend error, got: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a backend twice to panic")
+		}
+	}()
+	RegisterBackend("test-stub", func(config BackendConfig) (AutoTasker, error) { return nil, nil })
+}
@@ -0,0 +1,142 @@
+package at
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConsolidationPolicy decides how several entries of a task on the same day are captured, the
+// week entry of a task holds a single duration and summary per day.
+type ConsolidationPolicy string
+
+const (
+	// ConsolidateFail fails the entries of a task on the same day. This is the default.
+	ConsolidateFail ConsolidationPolicy = "fail"
+	// ConsolidateMerge captures the entries of a task on the same day as a single entry, with the
+	// sum of the durations and the summaries joined with their start times.
+	ConsolidateMerge ConsolidationPolicy = "merge"
+)
+
+// ParseConsolidationPolicy validates a policy name, an empty name is the default policy.
+func ParseConsolidationPolicy(s string) (ConsolidationPolicy, error) {
+	switch p := ConsolidationPolicy(s); p {
+	case "":
+		return ConsolidateFail, nil
+	case ConsolidateFail, ConsolidateMerge:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown same day policy: %v, use one of: fail, merge", s)
+}
+
+// MergeSameDay replaces the entries of a task on the same day by a single merged entry, the other
+// entries are kept as they are. A day on which every entry already exists isn't merged, the merged
+// entry would lose that. It returns the entries to capture and, for each merged entry, the
+// entries it replaces in order of their start time.
+func (entries TimeEntries) MergeSameDay(dateFormat string) (TimeEntries, map[*TimeEntry]TimeEntries) {
+	type dayKey struct {
+		id   int
+		date string
+	}
+
+	days := map[dayKey]TimeEntries{}
+	for _, te := range entries {
+		if !te.IsTicket {
+			k := dayKey{te.Id, te.Date.Format(time.DateOnly)}
+			days[k] = append(days[k], te)
+		}
+	}
+
+	var result TimeEntries
+	merges := map[*TimeEntry]TimeEntries{}
+
+	for _, te := range entries {
+		if te.IsTicket {
+			result = append(result, te)
+			continue
+		}
+
+		day := days[dayKey{te.Id, te.Date.Format(time.DateOnly)}]
+		if len(day) == 1 || day.allExist() {
+			result = append(result, te)
+			continue
+		}
+
+		// The merged entry takes the place of the first entry of the day
+		if day[0] != te {
+			continue
+		}
+
+		merged, sorted := mergeEntries(day, dateFormat)
+		merges[merged] = sorted
+		result = append(result, merged)
+	}
+
+	return result, merges
+}
+
+// allExist returns true if every entry already exists.
+func (entries TimeEntries) allExist() bool {
+	for _, te := range entries {
+		if !te.Exists {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeEntries merges entries of a task on the same day, it returns the merged entry and the
+// entries ordered by their start time. The internal notes are joined, the other optional fields
+// are taken from the earliest entry. The merged entry is on a day saved by gt-at if any of the
+// entries is, or already exists.
+func mergeEntries(day TimeEntries, dateFormat string) (*TimeEntry, TimeEntries) {
+	sorted := append(TimeEntries(nil), day...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartTimeStr < sorted[j].StartTimeStr })
+
+	var duration float32
+	var summaries, internalNotes []string
+	savedDay := false
+	for _, te := range sorted {
+		duration += te.Duration
+		savedDay = savedDay || te.SavedDay || te.Exists
+
+		if notes := strings.TrimSpace(te.InternalNotes); notes != "" {
+			internalNotes = append(internalNotes, notes)
+		}
+
+		summary := strings.TrimSpace(te.Summary)
+		if te.StartTimeStr != "" {
+			summary = te.StartTimeStr + " " + summary
+		}
+		summaries = append(summaries, summary)
+	}
+
+	first := sorted[0]
+	merged := NewEntry(first.Id, false, first.Date, first.StartTimeStr, duration, strings.Join(summaries, "\n"), first.Project, dateFormat)
+	merged.EmbedMarker = first.EmbedMarker
+	merged.Role = first.Role
+	merged.WorkType = first.WorkType
+	merged.BillingCode = first.BillingCode
+	merged.InternalNotes = strings.Join(internalNotes, "\n")
+	merged.ShowOnInvoice = first.ShowOnInvoice
+	merged.NonBillable = first.NonBillable
+	merged.SavedDay = savedDay
+
+	return merged, sorted
+}
+
+// SetMerged copies the outcome of capturing the merged entry to an entry it replaced, and keeps
+// the fingerprint of the merged entry to record it in the ledger.
+func (te *TimeEntry) SetMerged(merged *TimeEntry, count int) {
+	te.Exists = merged.Exists
+	te.Submitted = merged.Submitted
+	te.Error = merged.Error
+	te.Existing = merged.Existing
+	te.Started = merged.Started
+	te.Finished = merged.Finished
+	te.Merged = count
+	te.MergedFingerprint = merged.Fingerprint
+}
@@ -0,0 +1,100 @@
+package at
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeSameDay(t *testing.T) {
+	monday := time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC)
+	entry := func(id int, isTicket bool, date time.Time, start string, duration float32, summary string) *TimeEntry {
+		return NewEntry(id, isTicket, date, start, duration, summary, "", "2006/01/02")
+	}
+
+	entries := TimeEntries{
+		entry(200, false, monday, "13:00", 1, "Review"),
+		entry(100, true, monday, "09:00", 1, "Ticket"),
+		entry(200, false, monday, "09:00", 0.5, "Design "),
+		entry(200, false, monday.AddDate(0, 0, 1), "", 2, "Build"),
+		entry(100, true, monday, "10:00", 1, "Ticket again"),
+	}
+
+	result, merges := entries.MergeSameDay("2006/01/02")
+
+	if len(result) != 4 || len(merges) != 1 {
+		t.Fatalf("expected the task entries of Monday to be merged, got %v entries and %v merges", len(result), len(merges))
+	}
+	if result[1] != entries[1] || result[2] != entries[3] || result[3] != entries[4] {
+		t.Errorf("expected the other entries to be kept in order, got %v", result)
+	}
+
+	merged := result[0]
+	if merged.Duration != 1.5 || merged.StartTimeStr != "09:00" || merged.Summary != "09:00 Design\n13:00 Review" || merged.DateStr != "2023/09/11" {
+		t.Errorf("unexpected merged entry: %+v", merged)
+	}
+	if day := merges[merged]; len(day) != 2 || day[0] != entries[2] || day[1] != entries[0] {
+		t.Errorf("expected the merged entries in order of their start time, got %v", day)
+	}
+
+	merged.Submitted = true
+	merged.SetError(errors.New("boom"))
+	entries[0].SetMerged(merged, 2)
+	if !entries[0].Submitted || entries[0].Error == nil || entries[0].Merged != 2 || entries[0].MergedFingerprint != merged.Fingerprint {
+		t.Errorf("expected the outcome to be copied, got %+v", entries[0])
+	}
+}
+
+func TestMergeSameDayExisting(t *testing.T) {
+	monday := time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC)
+	entries := TimeEntries{
+		NewEntry(200, false, monday, "09:00", 0.5, "Design", "", "2006/01/02"),
+		NewEntry(200, false, monday, "13:00", 1, "Review", "", "2006/01/02"),
+	}
+
+	l := &Ledger{Entries: map[string]LedgerEntry{}}
+	result, merges := entries.MergeSameDay("2006/01/02")
+	merged := result[0]
+	merged.Submitted = true
+	for _, te := range merges[merged] {
+		te.SetMerged(merged, 2)
+	}
+	l.Record(entries)
+
+	if _, ok := l.Entries[merged.Fingerprint]; !ok || len(l.Entries) != 3 {
+		t.Errorf("expected the merged and original fingerprints to be recorded, got %v", l.Entries)
+	}
+
+	// Importing the file again, the day already exists and is kept as it is
+	again := TimeEntries{
+		NewEntry(200, false, monday, "09:00", 0.5, "Design", "", "2006/01/02"),
+		NewEntry(200, false, monday, "13:00", 1, "Review", "", "2006/01/02"),
+	}
+	l.MarkKnown(again, DuplicateSkip)
+
+	result, merges = again.MergeSameDay("2006/01/02")
+	if len(merges) != 0 || len(result) != 2 || !result[0].Exists || !result[1].Exists {
+		t.Errorf("expected the existing entries not to be merged, got %v and %v", result, merges)
+	}
+
+	// A new entry on the day is merged with the others
+	again = append(again, NewEntry(200, false, monday, "15:00", 1, "Fix", "", "2006/01/02"))
+	l.MarkKnown(again, DuplicateSkip)
+
+	result, merges = again.MergeSameDay("2006/01/02")
+	if len(merges) != 1 || len(result) != 1 || result[0].Exists || !result[0].SavedDay {
+		t.Errorf("expected the day to be merged again, got %v and %v", result, merges)
+	}
+}
+
+func TestParseConsolidationPolicy(t *testing.T) {
+	if p, err := ParseConsolidationPolicy(""); err != nil || p != ConsolidateFail {
+		t.Errorf("expected the default policy, got %v, %v", p, err)
+	}
+	if p, err := ParseConsolidationPolicy("merge"); err != nil || p != ConsolidateMerge {
+		t.Errorf("expected the merge policy, got %v, %v", p, err)
+	}
+	if _, err := ParseConsolidationPolicy("split"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,258 @@
+package at
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVColumns maps the fields of a RequestEntry to the column names in the header row.
+type CSVColumns struct {
+	Id        string
+	IsTicket  string
+	Date      string
+	StartTime string
+	Duration  string
+	Summary   string
+	Project   string
+}
+
+// CSVOptions defines how a csv file is read.
+type CSVOptions struct {
+	Delimiter  rune       // Field delimiter, defaults to a comma.
+	DateLayout string     // Layout of the date column, defined using https://pkg.go.dev/time#pkg-constants.
+	Columns    CSVColumns // Column names used in the header row.
+}
+
+// DefaultCSVOptions returns the csv options used when nothing is configured.
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{
+		Delimiter:  ',',
+		DateLayout: "2006-01-02",
+		Columns: CSVColumns{
+			Id:        "id",
+			IsTicket:  "isTicket",
+			Date:      "date",
+			StartTime: "startTime",
+			Duration:  "duration",
+			Summary:   "summary",
+			Project:   "project",
+		},
+	}
+}
+
+// withDefaults fills in any option that has not been set.
+func (o CSVOptions) withDefaults() CSVOptions {
+	d := DefaultCSVOptions()
+
+	if o.Delimiter == 0 {
+		o.Delimiter = d.Delimiter
+	}
+	if o.DateLayout == "" {
+		o.DateLayout = d.DateLayout
+	}
+
+	setDefault(&o.Columns.Id, d.Columns.Id)
+	setDefault(&o.Columns.IsTicket, d.Columns.IsTicket)
+	setDefault(&o.Columns.Date, d.Columns.Date)
+	setDefault(&o.Columns.StartTime, d.Columns.StartTime)
+	setDefault(&o.Columns.Duration, d.Columns.Duration)
+	setDefault(&o.Columns.Summary, d.Columns.Summary)
+	setDefault(&o.Columns.Project, d.Columns.Project)
+
+	return o
+}
+
+func setDefault(s *string, v string) {
+	if *s == "" {
+		*s = v
+	}
+}
+
+// readCSV reads a csv file with a header row into request entries. All invalid rows are
+// reported, each error is prefixed with the row number in the file.
+func readCSV(r io.Reader, opts ReadOptions) ([]RequestEntry, error) {
+	o := opts.CSV.withDefaults()
+
+	cr := csv.NewReader(r)
+	cr.Comma = o.Delimiter
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv: file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv: could not read header: %v", err)
+	}
+
+	cols, err := mapCSVColumns(header, o.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []RequestEntry
+	var errs []error
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %v", err)
+		}
+
+		row, _ := cr.FieldPos(0)
+
+		e, err := parseCSVRecord(record, cols, o.DateLayout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("csv: row %d: %v", row, err))
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return entries, nil
+}
+
+// csvColumnIndexes holds the index of each field in a record, -1 when the column is absent.
+type csvColumnIndexes struct {
+	id, isTicket, date, startTime, duration, summary, project int
+}
+
+// mapCSVColumns finds the configured columns in the header row, column names are not case-sensitive.
+func mapCSVColumns(header []string, c CSVColumns) (csvColumnIndexes, error) {
+	find := func(name string) int {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	cols := csvColumnIndexes{
+		id:        find(c.Id),
+		isTicket:  find(c.IsTicket),
+		date:      find(c.Date),
+		startTime: find(c.StartTime),
+		duration:  find(c.Duration),
+		summary:   find(c.Summary),
+		project:   find(c.Project),
+	}
+
+	required := []struct {
+		name  string
+		index int
+	}{
+		{c.Id, cols.id},
+		{c.IsTicket, cols.isTicket},
+		{c.Date, cols.date},
+		{c.Duration, cols.duration},
+	}
+
+	var missing []string
+	for _, r := range required {
+		if r.index < 0 {
+			missing = append(missing, r.name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return cols, fmt.Errorf("csv: header is missing required columns: %v", strings.Join(missing, ", "))
+	}
+
+	return cols, nil
+}
+
+// parseCSVRecord converts a single csv record into a RequestEntry.
+func parseCSVRecord(record []string, cols csvColumnIndexes, dateLayout string) (RequestEntry, error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var e RequestEntry
+	var err error
+
+	e.Id, err = strconv.Atoi(field(cols.id))
+	if err != nil {
+		return e, fmt.Errorf("invalid id %q", field(cols.id))
+	}
+
+	e.IsTicket, err = ParseIsTicket(field(cols.isTicket))
+	if err != nil {
+		return e, err
+	}
+
+	e.Date, err = time.Parse(dateLayout, field(cols.date))
+	if err != nil {
+		return e, fmt.Errorf("invalid date %q, expected format %v", field(cols.date), dateLayout)
+	}
+
+	e.Duration, err = ParseDuration(field(cols.duration))
+	if err != nil {
+		return e, err
+	}
+
+	e.StartTime = field(cols.startTime)
+	e.Summary = field(cols.summary)
+	e.Project = field(cols.project)
+
+	return e, nil
+}
+
+// ParseIsTicket interprets the value of a ticket/task column, accepting booleans as well as
+// "ticket"/"task" and the "S"/"P" (service desk/project) markers used in the summary.
+func ParseIsTicket(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "y", "1", "ticket", "s":
+		return true, nil
+	case "false", "no", "n", "0", "task", "p":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid ticket flag %q, expected true/false or ticket/task", s)
+}
+
+// ParseDuration parses a duration given either as decimal hours (1.5) or as hours and
+// minutes (1:30), optionally with seconds (1:30:00), into hours.
+func ParseDuration(s string) (float32, error) {
+	s = strings.TrimSpace(s)
+
+	if !strings.Contains(s, ":") {
+		h, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q, expected decimal hours or h:mm", s)
+		}
+		return float32(h), nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration %q, expected decimal hours or h:mm", s)
+	}
+
+	var values [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || (i > 0 && (v > 59 || len(p) != 2)) {
+			return 0, fmt.Errorf("invalid duration %q, expected decimal hours or h:mm", s)
+		}
+		values[i] = v
+	}
+
+	return float32(values[0]) + float32(values[1])/60 + float32(values[2])/3600, nil
+}
@@ -0,0 +1,120 @@
+package at
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float32
+		wantErr  bool
+	}{
+		{"1.5", 1.5, false},
+		{"0,75", 0.75, false},
+		{"1:30", 1.5, false},
+		{"0:45", 0.75, false},
+		{"2:15:00", 2.25, false},
+		{"1:5", 0, true},
+		{"1:75", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.expected {
+			t.Errorf("ParseDuration(%q) expected %v but got %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "Ticket;Type;Day;Start;Hours;Notes\n" +
+		"266016;task;15/09/2023;10:30;0:45;Stand-up\n" +
+		"266017;ticket;16/09/2023;11:00;1.5;\"Fix the\nprinter\"\n"
+
+	opts := ReadOptions{
+		DateFormat: "2006/01/02",
+		CSV: CSVOptions{
+			Delimiter:  ';',
+			DateLayout: "02/01/2006",
+			Columns: CSVColumns{
+				Id:        "ticket",
+				IsTicket:  "type",
+				Date:      "day",
+				StartTime: "start",
+				Duration:  "hours",
+				Summary:   "notes",
+			},
+		},
+	}
+
+	entries, err := ReadTimeEntries(strings.NewReader(data), "csv", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Id != 266016 || first.IsTicket || first.Duration != 0.75 || first.StartTimeStr != "10:30" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+
+	if !first.Date.Equal(time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)) || first.DateStr != "2023/09/15" {
+		t.Errorf("unexpected date for first entry: %v (%v)", first.Date, first.DateStr)
+	}
+
+	second := entries[1]
+	if !second.IsTicket || second.Duration != 1.5 || second.Summary != "Fix the\nprinter" {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+}
+
+func TestReadCSVReportsRowNumbers(t *testing.T) {
+	data := "id,isTicket,date,duration\n" +
+		"1,true,2023-09-15,1\n" +
+		"x,true,2023-09-15,1\n" +
+		"3,true,2023-09-15,1:99\n"
+
+	_, err := ReadRequestEntries(strings.NewReader(data), "csv", ReadOptions{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"row 3: invalid id", "row 4: invalid duration"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got: %v", expected, err)
+		}
+	}
+}
+
+func TestReadCSVMissingColumns(t *testing.T) {
+	_, err := ReadRequestEntries(strings.NewReader("id,date\n"), "csv", ReadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "isTicket, duration") {
+		t.Errorf("expected missing columns error, got: %v", err)
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	for filename, expected := range map[string]string{"time.json": "json", "/tmp/TIME.CSV": "csv"} {
+		got, err := FormatFromFilename(filename)
+		if err != nil || got != expected {
+			t.Errorf("FormatFromFilename(%q) expected %v but got %v (%v)", filename, expected, got, err)
+		}
+	}
+
+	if _, err := FormatFromFilename("time.txt"); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
@@ -0,0 +1,156 @@
+package at
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiffKind is the outcome of comparing an entry of a file with AutoTask.
+type DiffKind string
+
+const (
+	DiffSame    DiffKind = "same"    // AutoTask holds the entry as it is in the file.
+	DiffChanged DiffKind = "changed" // AutoTask holds the entry with a different start time, duration or summary.
+	DiffMissing DiffKind = "missing" // The entry of the file is not in AutoTask.
+	DiffExtra   DiffKind = "extra"   // AutoTask holds an entry that is not in the file.
+)
+
+// DiffEntry compares an entry of a file with the entry AutoTask holds on the same ticket or task and day.
+type DiffEntry struct {
+	Kind        DiffKind
+	File        *TimeEntry // The entry in the file, nil if it is extra.
+	AutoTask    *TimeEntry // The entry in AutoTask, nil if it is missing.
+	Differences []string   // The fields that differ, if changed.
+}
+
+// entry returns the entry the difference is about, the one in the file if there is one.
+func (d DiffEntry) entry() *TimeEntry {
+	if d.File != nil {
+		return d.File
+	}
+
+	return d.AutoTask
+}
+
+// diffKey identifies the ticket or task and day entries are compared on.
+type diffKey struct {
+	id       int
+	isTicket bool
+	date     string
+}
+
+func newDiffKey(te *TimeEntry) diffKey {
+	return diffKey{te.Id, te.IsTicket, te.Date.Format(time.DateOnly)}
+}
+
+// Diff compares the entries of a file with the entries in AutoTask per ticket or task and day.
+// On each day identical entries are paired first, the remaining entries are paired with the most
+// similar one and reported as changed. Entries without a counterpart are missing or extra.
+// The result is ordered by date, ID and start time.
+func Diff(file, autoTask TimeEntries) []DiffEntry {
+	remaining := map[diffKey]TimeEntries{}
+	for _, te := range autoTask {
+		k := newDiffKey(te)
+		remaining[k] = append(remaining[k], te)
+	}
+
+	var result []DiffEntry
+	var unpaired TimeEntries
+
+	// Identical entries first, so they aren't taken by a similar entry
+	for _, te := range file {
+		k := newDiffKey(te)
+		if i := indexOfEntry(remaining[k], func(other *TimeEntry) bool { return len(differences(te, other)) == 0 }); i >= 0 {
+			result = append(result, DiffEntry{Kind: DiffSame, File: te, AutoTask: remaining[k][i]})
+			remaining[k] = removeEntry(remaining[k], i)
+			continue
+		}
+
+		unpaired = append(unpaired, te)
+	}
+
+	for _, te := range unpaired {
+		k := newDiffKey(te)
+		if len(remaining[k]) == 0 {
+			result = append(result, DiffEntry{Kind: DiffMissing, File: te})
+			continue
+		}
+
+		best := 0
+		for i, other := range remaining[k] {
+			if len(differences(te, other)) < len(differences(te, remaining[k][best])) {
+				best = i
+			}
+		}
+
+		other := remaining[k][best]
+		result = append(result, DiffEntry{Kind: DiffChanged, File: te, AutoTask: other, Differences: differences(te, other)})
+		remaining[k] = removeEntry(remaining[k], best)
+	}
+
+	for _, entries := range remaining {
+		for _, te := range entries {
+			result = append(result, DiffEntry{Kind: DiffExtra, AutoTask: te})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].entry(), result[j].entry()
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.Id != b.Id {
+			return a.Id < b.Id
+		}
+		return a.StartTimeStr < b.StartTimeStr
+	})
+
+	return result
+}
+
+// differences returns the fields in which the entry in AutoTask differs from the one in the file.
+func differences(file, autoTask *TimeEntry) []string {
+	var result []string
+
+	if file.IsTicket && file.StartTimeStr != autoTask.StartTimeStr {
+		result = append(result, fmt.Sprintf("start time %v != %v", file.StartTimeStr, autoTask.StartTimeStr))
+	}
+
+	if fmt.Sprintf("%.2f", file.Duration) != fmt.Sprintf("%.2f", autoTask.Duration) {
+		result = append(result, fmt.Sprintf("duration %.2f != %.2f", file.Duration, autoTask.Duration))
+	}
+
+	if strings.TrimSpace(file.Summary) != strings.TrimSpace(autoTask.Summary) {
+		result = append(result, "summary")
+	}
+
+	return result
+}
+
+func indexOfEntry(entries TimeEntries, f func(*TimeEntry) bool) int {
+	for i, te := range entries {
+		if f(te) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func removeEntry(entries TimeEntries, i int) TimeEntries {
+	return append(entries[:i:i], entries[i+1:]...)
+}
+
+// CountDifferences returns the number of entries that are changed, missing or extra.
+func CountDifferences(diffs []DiffEntry) int {
+	count := 0
+	for _, d := range diffs {
+		if d.Kind != DiffSame {
+			count++
+		}
+	}
+
+	return count
+}
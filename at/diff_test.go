@@ -0,0 +1,67 @@
+package at
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	monday := time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC)
+	entry := func(id int, isTicket bool, date time.Time, start string, duration float32, summary string) *TimeEntry {
+		return NewEntry(id, isTicket, date, start, duration, summary, "", "2006/01/02")
+	}
+
+	file := TimeEntries{
+		entry(100, true, monday, "09:00", 1, "Same"),
+		entry(100, true, monday, "13:00", 1, "Longer"),
+		entry(200, false, monday, "", 0.5, "Design"),
+		entry(300, true, monday.AddDate(0, 0, 1), "10:00", 2, "Missing"),
+	}
+	autoTask := TimeEntries{
+		entry(100, true, monday, "13:00", 1.5, "Longer"),
+		entry(100, true, monday, "09:00", 1, "Same"),
+		entry(200, false, monday, "", 0.5, "Design notes"),
+		entry(200, false, monday.AddDate(0, 0, 1), "", 1, "Extra"),
+	}
+
+	diffs := Diff(file, autoTask)
+
+	expected := []struct {
+		kind DiffKind
+		id   int
+	}{
+		{DiffSame, 100},
+		{DiffChanged, 100},
+		{DiffChanged, 200},
+		{DiffExtra, 200},
+		{DiffMissing, 300},
+	}
+
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d differences, got %+v", len(expected), diffs)
+	}
+	for i, e := range expected {
+		if d := diffs[i]; d.Kind != e.kind || d.entry().Id != e.id {
+			t.Errorf("difference %d: expected %v of %d, got %v of %d", i, e.kind, e.id, d.Kind, d.entry().Id)
+		}
+	}
+
+	if d := diffs[1]; len(d.Differences) != 1 || d.Differences[0] != "duration 1.00 != 1.50" {
+		t.Errorf("expected only the duration to differ, got %v", d.Differences)
+	}
+	if d := diffs[2]; len(d.Differences) != 1 || d.Differences[0] != "summary" {
+		t.Errorf("expected only the summary to differ, got %v", d.Differences)
+	}
+
+	if n := CountDifferences(diffs); n != 4 {
+		t.Errorf("expected 4 differences, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	PrintDiff(&buf, diffs, false)
+	if strings.Contains(buf.String(), "same") || !strings.Contains(buf.String(), "missing") {
+		t.Errorf("expected only the differences to be printed, got:\n%v", buf.String())
+	}
+}
@@ -0,0 +1,81 @@
+package at
+
+import "time"
+
+// EventType identifies what happened during a capture.
+type EventType string
+
+const (
+	EventLoginStarted         EventType = "login-started"          // The login to AutoTask started.
+	EventMFAWaiting           EventType = "mfa-waiting"            // The login is waiting for the user to complete MFA.
+	EventLoggedIn             EventType = "logged-in"              // The user is logged in, with a stored session or a full login.
+	EventTicketOpened         EventType = "ticket-opened"          // The ticket of Event.Id was opened.
+	EventTaskOpened           EventType = "task-opened"            // The task of Event.Id was opened.
+	EventEntrySkippedExisting EventType = "entry-skipped-existing" // Event.Entry already exists and was skipped.
+	EventEntrySaved           EventType = "entry-saved"            // Event.Entry was saved.
+	EventEntryUpdated         EventType = "entry-updated"          // Event.Entry overwrote the existing entry in Event.Entry.Existing.
+	EventEntryFailed          EventType = "entry-failed"           // Event.Entry could not be saved, see Event.Err.
+	EventWeekSaved            EventType = "week-saved"             // The week of Event.Entry was saved on the task of Event.Id.
+	EventTimesheetChecked     EventType = "timesheet-checked"      // The status of Event.Timesheet was checked before capturing.
+	EventTimesheetSubmitted   EventType = "timesheet-submitted"    // Event.Timesheet was submitted, or could not be when Event.Err is set.
+)
+
+// Event describes progress of a capture.
+type Event struct {
+	Type  EventType
+	Time  time.Time
+	Id    int        // Ticket or task ID, if the event is about one.
+	Entry *TimeEntry // The entry the event is about, if any.
+	Err   error      // The error of a failed entry.
+
+	Timesheet *Timesheet // The timesheet the event is about, if any.
+}
+
+// Observer receives the events of a capture. It is called synchronously while capturing, so it
+// should return quickly, e.g. by handing the event to another goroutine.
+type Observer interface {
+	OnEvent(e Event)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(e Event)
+
+// OnEvent calls f(e).
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// Notify sends the event to the observer, if there is one.
+func Notify(o Observer, e Event) {
+	if o == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	o.OnEvent(e)
+}
+
+// NotifyEntry sends the outcome of a finished entry to the observer: failed, skipped because
+// it exists, updated or saved. Nothing is sent for an entry without an outcome, e.g. in a dry run.
+func NotifyEntry(o Observer, te *TimeEntry) {
+	e := Event{Id: te.Id, Entry: te}
+
+	switch {
+	case te.Error != nil:
+		e.Type = EventEntryFailed
+		e.Err = te.Error
+	case te.Exists:
+		e.Type = EventEntrySkippedExisting
+	case te.Updated():
+		e.Type = EventEntryUpdated
+	case te.Submitted:
+		e.Type = EventEntrySaved
+	default:
+		return
+	}
+
+	Notify(o, e)
+}
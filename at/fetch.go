@@ -0,0 +1,29 @@
+package at
+
+import (
+	"context"
+	"time"
+)
+
+// FetchQuery selects the time entries to read back from AutoTask.
+type FetchQuery struct {
+	TicketIds []int     // Tickets to read, with TaskIds. When both are empty the tickets and tasks with time in the date range are read.
+	TaskIds   []int     // Tasks to read.
+	From      time.Time // First day of the date range.
+	To        time.Time // Last day of the date range, inclusive.
+}
+
+// Contains returns true if the date falls within the date range of the query.
+func (q FetchQuery) Contains(date time.Time) bool {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(q.From.Year(), q.From.Month(), q.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(q.To.Year(), q.To.Month(), q.To.Day(), 0, 0, 0, 0, time.UTC)
+
+	return !day.Before(from) && !day.After(to)
+}
+
+// Fetcher is implemented by backends that can read back the user's time entries.
+type Fetcher interface {
+	// FetchTimes returns the time entries of the user selected by the query, ordered by date and time.
+	FetchTimes(ctx context.Context, query FetchQuery, opts CaptureOptions) (TimeEntries, error)
+}
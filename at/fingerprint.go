@@ -0,0 +1,310 @@
+package at
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DuplicatePolicy decides how entries that might already exist in AutoTask are handled.
+type DuplicatePolicy string
+
+const (
+	// DuplicateSkip skips entries with a matching fingerprint, and entries with a conversation of
+	// the user on the same date that doesn't carry a fingerprint marker. This is the default.
+	DuplicateSkip DuplicatePolicy = "skip"
+	// DuplicateWarn skips entries with a matching fingerprint, other conversations on the same
+	// date are logged as a warning and the entry is captured.
+	DuplicateWarn DuplicatePolicy = "warn"
+	// DuplicateForce captures all entries, existing entries are never skipped.
+	DuplicateForce DuplicatePolicy = "force"
+	// DuplicateUpdate skips entries with a matching fingerprint, the user's entry on the same date,
+	// and start time for tickets, is overwritten with the duration and summary of the entry.
+	DuplicateUpdate DuplicatePolicy = "update"
+)
+
+// ParseDuplicatePolicy validates a policy name, an empty name is the default policy.
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(s); p {
+	case "":
+		return DuplicateSkip, nil
+	case DuplicateSkip, DuplicateWarn, DuplicateForce, DuplicateUpdate:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown duplicate policy: %v, use one of: skip, warn, force, update", s)
+}
+
+// calculateFingerprint derives a stable key from the fields that identify an entry.
+func (te *TimeEntry) calculateFingerprint() {
+	summary := sha256.Sum256([]byte(te.Summary))
+	key := fmt.Sprintf("%d|%v|%v|%.2f|%x", te.Id, te.Date.Format("2006-01-02"), te.StartTimeStr, te.Duration, summary)
+	sum := sha256.Sum256([]byte(key))
+
+	te.Fingerprint = hex.EncodeToString(sum[:])[:12]
+}
+
+var markerRegEx = regexp.MustCompile(`\[gt-at:([0-9a-f]{12})\]`)
+
+// marker returns the fingerprint marker that is embedded in the summary notes.
+func (te *TimeEntry) marker() string {
+	return "[gt-at:" + te.Fingerprint + "]"
+}
+
+// EmbedMarkers enables embedding the fingerprint marker in the summary notes of each entry.
+func (entries TimeEntries) EmbedMarkers() {
+	for _, e := range entries {
+		e.EmbedMarker = true
+	}
+}
+
+// Notes returns the summary notes to capture in AutoTask, including the fingerprint marker if enabled.
+func (te *TimeEntry) Notes() string {
+	if !te.EmbedMarker {
+		return te.Summary
+	}
+
+	return te.Summary + "\n" + te.marker()
+}
+
+// FindMarkers returns the fingerprints of the markers in a text.
+func FindMarkers(text string) []string {
+	var result []string
+	for _, m := range markerRegEx.FindAllStringSubmatch(text, -1) {
+		result = append(result, m[1])
+	}
+
+	return result
+}
+
+// StripMarkers removes the fingerprint markers from a text, e.g. summary notes read back from AutoTask.
+func StripMarkers(text string) string {
+	return strings.TrimSpace(markerRegEx.ReplaceAllString(text, ""))
+}
+
+// MatchConversation marks the entry as existing when a conversation of the user matches it,
+// dateText is the date and time shown for the conversation and text is its full content.
+// A conversation carrying the entry's fingerprint marker is an exact match, one on the same
+// date without any marker is a possible match which is handled according to the policy.
+// With the update policy only exact matches are marked, see MatchExisting.
+func (te *TimeEntry) MatchConversation(dateText, text, dateStr string, policy DuplicatePolicy) {
+	if policy == DuplicateForce {
+		return
+	}
+
+	markers := FindMarkers(text)
+	for _, m := range markers {
+		if m == te.Fingerprint {
+			slog.Debug("Found fingerprint", "id", te.Id, "date", dateStr, "fingerprint", te.Fingerprint)
+			te.Exists = true
+			return
+		}
+	}
+
+	// Conversations with a marker belong to another entry
+	if policy == DuplicateUpdate || len(markers) > 0 || dateStr == "" || len(dateText) < len(dateStr) || dateText[:len(dateStr)] != dateStr {
+		return
+	}
+
+	if policy == DuplicateWarn {
+		slog.Warn("Found another entry on the same date, capturing it anyway", "id", te.Id, "date", dateStr)
+		return
+	}
+
+	// The conversations on the date are the other entries gt-at saved, see Ledger.MarkKnown
+	if te.SavedDay {
+		slog.Debug("Found entry on the same date saved by gt-at", "id", te.Id, "date", dateStr)
+		return
+	}
+
+	slog.Warn("Found another entry on the same date, skipping it, use --duplicates warn to capture it anyway", "id", te.Id, "date", dateStr)
+	te.Exists = true
+}
+
+// MatchExisting compares the entry with the user's entry in AutoTask, read from a conversation,
+// with the update policy. An identical entry on the same ticket or task, date and start time marks
+// the entry as existing, a different one is recorded as the entry to overwrite. It returns true
+// if the existing entry was matched.
+func (te *TimeEntry) MatchExisting(existing *TimeEntry) bool {
+	if te.Exists || te.Existing != nil || newDiffKey(te) != newDiffKey(existing) ||
+		(te.IsTicket && te.StartTimeStr != existing.StartTimeStr) {
+		return false
+	}
+
+	if len(differences(te, existing)) == 0 {
+		slog.Debug("Found identical entry", "id", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
+		te.Exists = true
+		return true
+	}
+
+	slog.Debug("Found entry to update", "id", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
+	te.Existing = existing
+
+	return true
+}
+
+// Updates returns true if the entry overwrites the user's existing entry in AutoTask.
+func (te *TimeEntry) Updates() bool {
+	return te.Existing != nil && !te.Exists
+}
+
+// Updated returns true if the entry overwrote the user's existing entry in AutoTask.
+func (te *TimeEntry) Updated() bool {
+	return te.Updates() && te.Submitted
+}
+
+// ConfirmUpdates asks to confirm each entry that overwrites an existing entry, a declined entry is
+// skipped as existing. Without a confirm function all updates are accepted.
+func (entries TimeEntries) ConfirmUpdates(confirm func(te *TimeEntry) bool) {
+	if confirm == nil {
+		return
+	}
+
+	for _, te := range entries {
+		if te.Updates() && !confirm(te) {
+			slog.Info("Update declined, keeping the existing entry", "id", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
+			te.Exists = true
+		}
+	}
+}
+
+// Ledger records the fingerprints of the entries gt-at has saved in AutoTask.
+type Ledger struct {
+	path    string
+	Entries map[string]LedgerEntry `json:"entries"`
+}
+
+// LedgerEntry describes the entry a fingerprint was recorded for.
+type LedgerEntry struct {
+	Id         int       `json:"id"`
+	Date       string    `json:"date"`
+	Start      string    `json:"start,omitempty"` // Start time of a ticket entry.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// ledgerStart returns the start time the ledger keeps for the entry, task entries don't have one.
+func ledgerStart(e *TimeEntry) string {
+	if !e.IsTicket {
+		return ""
+	}
+
+	return e.StartTimeStr
+}
+
+// DefaultLedgerPath returns the location of the ledger in the user's gt-at data directory.
+func DefaultLedgerPath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "fingerprints.json"), nil
+}
+
+// LoadLedger reads the ledger at path, a missing file is an empty ledger.
+func LoadLedger(path string) (*Ledger, error) {
+	l := &Ledger{path: path, Entries: map[string]LedgerEntry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read ledger: %v", err)
+	}
+
+	err = json.Unmarshal(data, l)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal ledger: %v", err)
+	}
+
+	if l.Entries == nil {
+		l.Entries = map[string]LedgerEntry{}
+	}
+
+	return l, nil
+}
+
+// MarkKnown marks entries with a recorded fingerprint as existing, unless the policy is force.
+// An unknown entry on a ticket or task and date on which gt-at saved other entries is marked as
+// such, a conversation on that date without a marker doesn't make it a duplicate. That doesn't
+// apply when gt-at saved an entry at the same start time, or on the same day of a task, as the
+// entry is most likely that one with its summary or duration changed.
+func (l *Ledger) MarkKnown(entries TimeEntries, policy DuplicatePolicy) {
+	if policy == DuplicateForce {
+		return
+	}
+
+	type dayKey struct {
+		id    int
+		date  string
+		start string
+	}
+
+	days := map[dayKey]bool{}
+	times := map[dayKey]bool{}
+	for _, le := range l.Entries {
+		days[dayKey{le.Id, le.Date, ""}] = true
+		times[dayKey{le.Id, le.Date, le.Start}] = true
+	}
+
+	for _, e := range entries {
+		if _, ok := l.Entries[e.Fingerprint]; ok {
+			slog.Info("Entry already saved by gt-at", "id", e.Id, "date", e.DateStr, "fingerprint", e.Fingerprint)
+			e.Exists = true
+			continue
+		}
+
+		date := e.Date.Format("2006-01-02")
+		if times[dayKey{e.Id, date, ledgerStart(e)}] || times[dayKey{e.Id, date, ""}] {
+			slog.Debug("Entry changed since it was saved by gt-at", "id", e.Id, "date", e.DateStr, "start", e.StartTimeStr)
+			continue
+		}
+
+		e.SavedDay = days[dayKey{e.Id, date, ""}]
+	}
+}
+
+// Record adds the fingerprints of the submitted entries, and of the entries they were merged into.
+func (l *Ledger) Record(entries TimeEntries) {
+	for _, e := range entries {
+		if !e.Submitted {
+			continue
+		}
+
+		le := LedgerEntry{Id: e.Id, Date: e.Date.Format("2006-01-02"), Start: ledgerStart(e), RecordedAt: time.Now()}
+		l.Entries[e.Fingerprint] = le
+		if e.MergedFingerprint != "" {
+			l.Entries[e.MergedFingerprint] = le
+		}
+	}
+}
+
+// Save writes the ledger, readable only by the current user.
+func (l *Ledger) Save() error {
+	err := os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create ledger directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal ledger: %v", err)
+	}
+
+	tmp := l.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write ledger: %v", err)
+	}
+
+	return os.Rename(tmp, l.path)
+}
@@ -0,0 +1,243 @@
+package at
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFingerprintEntry(summary string) *TimeEntry {
+	te := &TimeEntry{Id: 266016, IsTicket: true, Date: time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC), StartTimeStr: "10:30", Duration: 0.5, Summary: summary, DateStr: "2023/09/15"}
+	te.calculateDerived()
+	return te
+}
+
+func TestFingerprint(t *testing.T) {
+	a := newFingerprintEntry("Stand-up")
+	b := newFingerprintEntry("Stand-up")
+	c := newFingerprintEntry("Stand-up meeting")
+
+	if len(a.Fingerprint) != 12 {
+		t.Fatalf("expected a fingerprint of 12 characters but got %q", a.Fingerprint)
+	}
+	if a.Fingerprint != b.Fingerprint {
+		t.Errorf("expected equal entries to have the same fingerprint, got %v and %v", a.Fingerprint, b.Fingerprint)
+	}
+	if a.Fingerprint == c.Fingerprint {
+		t.Error("expected a different summary to change the fingerprint")
+	}
+
+	if a.Notes() != "Stand-up" {
+		t.Errorf("expected no marker by default, got %q", a.Notes())
+	}
+	TimeEntries{a}.EmbedMarkers()
+	if !strings.HasSuffix(a.Notes(), "[gt-at:"+a.Fingerprint+"]") {
+		t.Errorf("expected the marker in the notes, got %q", a.Notes())
+	}
+	if m := FindMarkers(a.Notes()); len(m) != 1 || m[0] != a.Fingerprint {
+		t.Errorf("expected to find the marker, got %v", m)
+	}
+}
+
+func TestMatchConversation(t *testing.T) {
+	other := newFingerprintEntry("Other")
+
+	tests := []struct {
+		name   string
+		text   string
+		policy DuplicatePolicy
+		exists bool
+	}{
+		{"exact match", "Stand-up [gt-at:%v]", DuplicateWarn, true},
+		{"other marker", "Other [gt-at:" + other.Fingerprint + "]", DuplicateSkip, false},
+		{"same date skip", "Something", DuplicateSkip, true},
+		{"same date default", "Something", "", true},
+		{"same date warn", "Something", DuplicateWarn, false},
+		{"exact match force", "Stand-up [gt-at:%v]", DuplicateForce, false},
+		{"exact match update", "Stand-up [gt-at:%v]", DuplicateUpdate, true},
+		{"same date update", "Something", DuplicateUpdate, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := newFingerprintEntry("Stand-up")
+			text := strings.Replace(tt.text, "%v", te.Fingerprint, 1)
+
+			te.MatchConversation("2023/09/15 10:30 AM", text, te.DateStr, tt.policy)
+			if te.Exists != tt.exists {
+				t.Errorf("expected exists to be %v", tt.exists)
+			}
+		})
+	}
+
+	te := newFingerprintEntry("Stand-up")
+	te.MatchConversation("2023/09/16 10:30 AM", "Something", te.DateStr, DuplicateSkip)
+	if te.Exists {
+		t.Error("expected a conversation on another date not to match")
+	}
+}
+
+func TestMatchExisting(t *testing.T) {
+	ticket := func(summary string) *TimeEntry {
+		e := newFingerprintEntry(summary)
+		e.IsTicket = true
+		return e
+	}
+	existing := func(start string, duration float32, summary string) *TimeEntry {
+		e := ticket(summary)
+		e.StartTimeStr, e.Duration = start, duration
+		return e
+	}
+
+	te := ticket("Stand-up")
+	if te.MatchExisting(existing("09:00", 0.5, "Stand-up")) || te.MatchExisting(&TimeEntry{Id: 1, IsTicket: true, Date: te.Date, StartTimeStr: "10:30"}) {
+		t.Fatal("expected entries at another start time or on another ticket not to match")
+	}
+
+	if !te.MatchExisting(existing("10:30", 0.5, "Stand-up")) || !te.Exists || te.Updates() {
+		t.Errorf("expected an identical entry to exist, got %+v", te)
+	}
+
+	te = ticket("Stand-up")
+	before := existing("10:30", 1, "Stand-up and planning")
+	if !te.MatchExisting(before) || te.Exists || !te.Updates() || te.Existing != before {
+		t.Fatalf("expected a changed entry to update the existing one, got %+v", te)
+	}
+	if te.MatchExisting(existing("10:30", 2, "Another")) || te.Existing != before {
+		t.Error("expected the entry to update a single existing entry")
+	}
+
+	var buf strings.Builder
+	PrintUpdate(&buf, te)
+	if !strings.Contains(buf.String(), "duration: 1.00 -> 0.50") || !strings.Contains(buf.String(), "- Stand-up and planning") {
+		t.Errorf("expected the duration and summary before and after, got:\n%v", buf.String())
+	}
+
+	var asked []*TimeEntry
+	TimeEntries{te}.ConfirmUpdates(func(e *TimeEntry) bool {
+		asked = append(asked, e)
+		return false
+	})
+	if len(asked) != 1 || te.Updates() || !te.Exists {
+		t.Errorf("expected the declined entry to be skipped, got %+v", te)
+	}
+}
+
+func TestStripMarkers(t *testing.T) {
+	if s := StripMarkers("Fixed it\n[gt-at:0123456789ab]"); s != "Fixed it" {
+		t.Errorf("expected the marker to be removed, got %q", s)
+	}
+	if s := StripMarkers("No marker [gt-at:xyz]"); s != "No marker [gt-at:xyz]" {
+		t.Errorf("expected text without a valid marker to be unchanged, got %q", s)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+
+	l, err := LoadLedger(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved := newFingerprintEntry("Stand-up")
+	saved.Submitted = true
+	failed := newFingerprintEntry("Failed")
+	failed.StartTimeStr = "14:00"
+	failed.calculateFingerprint()
+	l.Record(TimeEntries{saved, failed})
+
+	err = l.Save()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l, err = LoadLedger(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := TimeEntries{newFingerprintEntry("Stand-up"), failed}
+	l.MarkKnown(entries, DuplicateForce)
+	if entries[0].Exists {
+		t.Error("expected force to ignore the ledger")
+	}
+
+	l.MarkKnown(entries, DuplicateSkip)
+	if !entries[0].Exists || entries[1].Exists {
+		t.Errorf("expected only the submitted entry to be known, got %v and %v", entries[0].Exists, entries[1].Exists)
+	}
+
+	// The conversation on the date is the entry gt-at saved, not a duplicate of the second entry
+	second := entries[1]
+	second.MatchConversation("2023/09/15 10:30 AM", "Stand-up", second.DateStr, DuplicateSkip)
+	if !second.SavedDay || second.Exists {
+		t.Errorf("expected the second entry on the date to be captured, got saved day %v, exists %v", second.SavedDay, second.Exists)
+	}
+
+	other := newFingerprintEntry("Other day")
+	other.Date = other.Date.AddDate(0, 0, 1)
+	l.MarkKnown(TimeEntries{other}, DuplicateSkip)
+	if other.SavedDay {
+		t.Error("expected a date without saved entries not to be marked")
+	}
+}
+
+func TestLedgerEditedEntry(t *testing.T) {
+	l := &Ledger{Entries: map[string]LedgerEntry{}}
+
+	saved := newFingerprintEntry("Fix")
+	saved.Submitted = true
+	l.Record(TimeEntries{saved})
+
+	if le := l.Entries[saved.Fingerprint]; le.Start != "10:30" {
+		t.Errorf("expected the start time of the ticket entry to be recorded, got %+v", le)
+	}
+
+	// The summary is corrected, the entry saved at the same start time is a possible match
+	edited := newFingerprintEntry("Fix typo")
+	l.MarkKnown(TimeEntries{edited}, DuplicateSkip)
+	edited.MatchConversation("2023/09/15 10:30 AM", "Fix", edited.DateStr, DuplicateSkip)
+	if edited.SavedDay || !edited.Exists {
+		t.Errorf("expected the edited entry to be skipped, got saved day %v, exists %v", edited.SavedDay, edited.Exists)
+	}
+
+	// Ledger entries recorded without a start time match any start time
+	le := l.Entries[saved.Fingerprint]
+	le.Start = ""
+	l.Entries[saved.Fingerprint] = le
+	later := newFingerprintEntry("Later")
+	later.StartTimeStr = "14:00"
+	later.calculateFingerprint()
+	l.MarkKnown(TimeEntries{later}, DuplicateSkip)
+	if later.SavedDay {
+		t.Error("expected a ledger entry without a start time to be a possible match")
+	}
+
+	// Task entries don't have a start time, an entry on the same day is the saved one
+	task := newFingerprintEntry("Design")
+	task.Id, task.IsTicket = 17010, false
+	task.Submitted = true
+	l.Record(TimeEntries{task})
+	changed := newFingerprintEntry("Design and review")
+	changed.Id, changed.IsTicket = 17010, false
+	changed.StartTimeStr = "14:00"
+	changed.calculateFingerprint()
+	l.MarkKnown(TimeEntries{changed}, DuplicateSkip)
+	if changed.SavedDay {
+		t.Error("expected the changed task entry to be a possible match")
+	}
+}
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	if p, err := ParseDuplicatePolicy(""); err != nil || p != DuplicateSkip {
+		t.Errorf("expected the default policy, got %v, %v", p, err)
+	}
+	if p, err := ParseDuplicatePolicy("update"); err != nil || p != DuplicateUpdate {
+		t.Errorf("expected the update policy, got %v, %v", p, err)
+	}
+	if _, err := ParseDuplicatePolicy("ignore"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,99 @@
+package at
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryFilter selects entries from the run history, zero values match anything.
+type HistoryFilter struct {
+	Id     int         // AutoTask ticket or task ID.
+	From   time.Time   // First date of the entries, inclusive.
+	To     time.Time   // Last date of the entries, inclusive.
+	Status EntryStatus // Outcome of the entries.
+}
+
+// matches reports if the entry is selected by the filter.
+func (f HistoryFilter) matches(e RunEntry) bool {
+	date := e.Date.Format("2006-01-02")
+
+	return (f.Id == 0 || e.Id == f.Id) &&
+		(f.From.IsZero() || date >= f.From.Format("2006-01-02")) &&
+		(f.To.IsZero() || date <= f.To.Format("2006-01-02")) &&
+		(f.Status == "" || e.Status == f.Status)
+}
+
+// HistoryEntry is an entry from the run history together with the run it belongs to.
+type HistoryEntry struct {
+	RunId string    `json:"runId"`
+	RunAt time.Time `json:"runAt"`
+	RunEntry
+}
+
+// History returns the entries of all runs that match the filter, in the order of the runs.
+func History(runs []*Run, filter HistoryFilter) []HistoryEntry {
+	var result []HistoryEntry
+
+	for _, run := range runs {
+		for _, e := range run.Entries {
+			if filter.matches(e) {
+				result = append(result, HistoryEntry{RunId: run.Id, RunAt: run.CreatedAt, RunEntry: e})
+			}
+		}
+	}
+
+	return result
+}
+
+// WriteHistory writes history entries as JSON or CSV.
+func WriteHistory(w io.Writer, entries []HistoryEntry, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		if entries == nil {
+			entries = []HistoryEntry{}
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		return writeHistoryCSV(w, entries)
+	}
+
+	return fmt.Errorf("unknown history format: %v, use one of: json, csv", format)
+}
+
+func writeHistoryCSV(w io.Writer, entries []HistoryEntry) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"runId", "runAt", "id", "type", "date", "startTime", "duration", "status", "error", "summary"})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = cw.Write([]string{
+			e.RunId,
+			e.RunAt.Format(time.RFC3339),
+			strconv.Itoa(e.Id),
+			toTicketTask(e.IsTicket),
+			e.Date.Format("2006-01-02"),
+			e.StartTime,
+			fmt.Sprintf("%.2f", e.Duration),
+			string(e.Status),
+			e.Error,
+			e.Summary,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
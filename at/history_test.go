@@ -0,0 +1,40 @@
+package at
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	day := time.Date(2023, time.September, 12, 0, 0, 0, 0, time.UTC)
+	entry := func(id int, date time.Time, status EntryStatus) RunEntry {
+		return RunEntry{RequestEntry: RequestEntry{Id: id, IsTicket: true, Date: date, Duration: 1, Summary: "work"}, Status: status}
+	}
+
+	runs := []*Run{
+		{Id: "20230912-170000", Entries: []RunEntry{entry(266016, day, StatusFailed), entry(266017, day, StatusSaved)}},
+		{Id: "20230913-090000", Entries: []RunEntry{entry(266016, day, StatusSaved), entry(266016, day.AddDate(0, 0, 1), StatusSaved)}},
+	}
+
+	result := History(runs, HistoryFilter{Id: 266016, From: day, To: day})
+	if len(result) != 2 || result[0].RunId != "20230912-170000" || result[1].Status != StatusSaved {
+		t.Errorf("unexpected history: %+v", result)
+	}
+
+	result = History(runs, HistoryFilter{Status: StatusSaved})
+	if len(result) != 3 {
+		t.Errorf("expected 3 saved entries, got %d", len(result))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHistory(&buf, result, "csv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[1], "20230912-170000") {
+		t.Errorf("unexpected csv:\n%v", buf.String())
+	}
+}
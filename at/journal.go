@@ -0,0 +1,291 @@
+package at
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DataDir returns the directory where gt-at keeps its data, e.g. stored sessions and run journals.
+func DataDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user config directory: %v", err)
+	}
+
+	return filepath.Join(configDir, "gt-at"), nil
+}
+
+// Run is the journal of a single import. It records the entries in their original order and
+// the outcome of each entry once the capture has finished.
+type Run struct {
+	Id          string     `json:"id"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`  // Nil until the capture has finished.
+	Source      string     `json:"source"`                // File the entries were read from.
+	ResumedFrom string     `json:"resumedFrom,omitempty"` // Id of the run that was resumed.
+	Options     RunOptions `json:"options"`
+	Error       string     `json:"error,omitempty"`       // Error that stopped the capture as a whole.
+	Diagnostics string     `json:"diagnostics,omitempty"` // Directory with the diagnostics of failures.
+	Entries     []RunEntry `json:"entries"`
+
+	timeEntries TimeEntries
+}
+
+// RunOptions records the options a run was started with, credentials are never recorded.
+type RunOptions struct {
+	Username        string `json:"username"`
+	UserDisplayName string `json:"userDisplayName"`
+	BrowserType     string `json:"browserType"`
+	Headless        bool   `json:"headless"`
+	DryRun          bool   `json:"dryRun"`
+	FreshLogin      bool   `json:"freshLogin"`
+	Format          string `json:"format,omitempty"`
+	DuplicatePolicy string `json:"duplicatePolicy,omitempty"`
+	EmbedMarkers    bool   `json:"embedMarkers,omitempty"`
+	SameDayTasks    string `json:"sameDayTasks,omitempty"`
+	Backend         string `json:"backend,omitempty"`
+}
+
+// NewRunOptions records the options of a capture.
+func NewRunOptions(opts CaptureOptions, format string) RunOptions {
+	return RunOptions{
+		Username:        opts.Credentials.Username,
+		UserDisplayName: opts.UserDisplayName,
+		BrowserType:     opts.BrowserType,
+		Headless:        opts.Headless,
+		DryRun:          opts.DryRun,
+		FreshLogin:      opts.FreshLogin,
+		Format:          format,
+		DuplicatePolicy: string(opts.DuplicatePolicy),
+		EmbedMarkers:    opts.EmbedMarkers,
+		SameDayTasks:    string(opts.SameDayTasks),
+		Backend:         opts.Backend,
+	}
+}
+
+// RunEntry is a time entry in a run journal together with its outcome.
+type RunEntry struct {
+	RequestEntry
+	Status      EntryStatus `json:"status"`
+	Exists      bool        `json:"exists"`
+	Submitted   bool        `json:"submitted"`
+	Error       string      `json:"error,omitempty"`
+	Diagnostics []string    `json:"diagnostics,omitempty"` // Files saved to diagnose the failure.
+}
+
+// NewRun creates the journal for an import of the entries, the entries must still be in the
+// order they were read in. All entries start out as pending.
+func NewRun(source string, entries TimeEntries) *Run {
+	now := time.Now()
+
+	run := &Run{
+		Id:          now.Format("20060102-150405"),
+		CreatedAt:   now,
+		Source:      source,
+		timeEntries: append(TimeEntries{}, entries...),
+	}
+
+	run.Record()
+
+	return run
+}
+
+// Finish records the outcome of the capture, err is the error returned by the capture.
+func (r *Run) Finish(err error) {
+	now := time.Now()
+	r.FinishedAt = &now
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	r.Record()
+}
+
+// Count returns the number of entries in the run with the given status.
+func (r *Run) Count(status EntryStatus) int {
+	count := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Record updates the outcome of each entry from the time entries the run was created with.
+func (r *Run) Record() {
+	r.Entries = make([]RunEntry, 0, len(r.timeEntries))
+
+	for _, te := range r.timeEntries {
+		e := RunEntry{
+			RequestEntry: te.Request(),
+			Status:       te.Status(),
+			Exists:       te.Exists,
+			Submitted:    te.Submitted,
+			Diagnostics:  te.Diagnostics,
+		}
+
+		if te.Error != nil {
+			e.Error = te.Error.Error()
+		}
+
+		r.Entries = append(r.Entries, e)
+	}
+}
+
+// Unfinished returns the entries that were not saved in AutoTask, in their original order.
+// Entries that already existed are not included, there is nothing left to do for them.
+func (r *Run) Unfinished() []RequestEntry {
+	var result []RequestEntry
+
+	for _, e := range r.Entries {
+		if !e.Submitted && !e.Exists {
+			result = append(result, e.RequestEntry)
+		}
+	}
+
+	return result
+}
+
+// RunStore keeps run journals as JSON files in a directory, one file per run.
+type RunStore struct {
+	Dir string
+}
+
+// DefaultRunStore returns the run store in the user's gt-at data directory.
+func DefaultRunStore() (*RunStore, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RunStore{Dir: filepath.Join(dir, "runs")}, nil
+}
+
+// Save writes the run journal, readable only by the current user.
+func (s *RunStore) Save(run *Run) error {
+	err := os.MkdirAll(s.Dir, 0700)
+	if err != nil {
+		return fmt.Errorf("could not create run directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal run: %v", err)
+	}
+
+	path := s.path(run.Id)
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write run: %v", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// DiagnosticsDir returns the directory for the diagnostics of a run, next to its journal.
+func (s *RunStore) DiagnosticsDir(id string) string {
+	return filepath.Join(s.Dir, id)
+}
+
+// Load reads the journal of a run.
+func (s *RunStore) Load(id string) (*Run, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid run id: %q", id)
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("run not found: %v", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read run: %v", err)
+	}
+
+	var run Run
+	err = json.Unmarshal(data, &run)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal run %v: %v", id, err)
+	}
+
+	return &run, nil
+}
+
+// Ids returns the ids of all runs, oldest first.
+func (s *RunStore) Ids() ([]string, error) {
+	files, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read run directory: %v", err)
+	}
+
+	var ids []string
+	for _, f := range files {
+		if !f.IsDir() && filepath.Ext(f.Name()) == ".json" {
+			ids = append(ids, strings.TrimSuffix(f.Name(), ".json"))
+		}
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// List returns all runs, oldest first.
+func (s *RunStore) List() ([]*Run, error) {
+	ids, err := s.Ids()
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	for _, id := range ids {
+		run, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// Latest returns the most recent run.
+func (s *RunStore) Latest() (*Run, error) {
+	ids, err := s.Ids()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("no previous runs found")
+	}
+
+	return s.Load(ids[len(ids)-1])
+}
+
+// NextId returns id if it isn't used by another run yet, otherwise a numbered variant of it.
+func (s *RunStore) NextId(id string) string {
+	candidate := id
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.path(candidate)); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%v-%d", id, i)
+	}
+}
+
+func (s *RunStore) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
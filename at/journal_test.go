@@ -0,0 +1,71 @@
+package at
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunStore(t *testing.T) {
+	store := &RunStore{Dir: t.TempDir()}
+
+	if _, err := store.Latest(); err == nil {
+		t.Error("expected an error when there are no runs")
+	}
+
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	entries := TimeEntries{
+		NewEntry(2, false, day.AddDate(0, 0, 1), "", 1, "saved", "", "2006/01/02"),
+		NewEntry(1, true, day, "09:00", 1, "failed", "", "2006/01/02"),
+		NewEntry(3, true, day, "10:00", 1, "exists", "", "2006/01/02"),
+		NewEntry(4, true, day, "11:00", 1, "pending", "", "2006/01/02"),
+	}
+
+	run := NewRun("time.json", entries)
+	run.Id = store.NextId("20230918-080000")
+
+	// Capturing sorts the entries, the journal must keep the original order
+	entries.SortByDateAndTime()
+	entries[3].Submitted = true
+	entries[0].SetError(errors.New("could not find dialog"))
+	entries[0].Diagnostics = []string{"001-ticket-1.png"}
+	entries[1].Exists = true
+
+	// The diagnostics directory of a run is not mistaken for a run
+	run.Diagnostics = store.DiagnosticsDir(run.Id)
+	if err := os.MkdirAll(run.Diagnostics, 0700); err != nil {
+		t.Fatalf("could not create diagnostics directory: %v", err)
+	}
+
+	run.Record()
+	if err := store.Save(run); err != nil {
+		t.Fatalf("could not save run: %v", err)
+	}
+
+	if id := store.NextId("20230918-080000"); id != "20230918-080000-2" {
+		t.Errorf("expected a numbered id, got %v", id)
+	}
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatalf("could not load latest run: %v", err)
+	}
+
+	if latest.Id != run.Id || latest.Source != "time.json" || latest.Entries[1].Error != "could not find dialog" ||
+		latest.Diagnostics != run.Diagnostics || len(latest.Entries[1].Diagnostics) != 1 {
+		t.Errorf("unexpected run: %+v", latest)
+	}
+	if latest.FinishedAt != nil {
+		t.Errorf("expected an unfinished run to have no finish time, got %v", latest.FinishedAt)
+	}
+
+	unfinished := latest.Unfinished()
+	if len(unfinished) != 2 || unfinished[0].Id != 1 || unfinished[1].Id != 4 {
+		t.Errorf("expected entries 1 and 4 to be unfinished, got: %+v", unfinished)
+	}
+
+	if _, err := store.Load("../outside"); err == nil {
+		t.Error("expected an error for an invalid id")
+	}
+}
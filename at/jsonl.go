@@ -0,0 +1,59 @@
+package at
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+func init() {
+	RegisterReader("jsonl", readJSONL, ".jsonl", ".ndjson")
+}
+
+// readJSONL reads JSON Lines, one request entry per line. Blank lines and lines starting with
+// # or // are ignored, malformed records are reported with their line number.
+func readJSONL(r io.Reader, opts ReadOptions) ([]RequestEntry, error) {
+	br := bufio.NewReader(r)
+
+	var entries []RequestEntry
+	var errs []error
+
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("jsonl: line %d: %v", lineNo, err)
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if lineNo == 1 {
+			trimmed = strings.TrimPrefix(trimmed, "\uFEFF")
+		}
+
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "//") {
+			e, warnings, jsonErr := decodeRequestEntry(json.RawMessage(trimmed))
+			if jsonErr != nil {
+				errs = append(errs, fmt.Errorf("jsonl: line %d: %v", lineNo, jsonErr))
+			} else {
+				entries = append(entries, e)
+			}
+
+			for _, w := range warnings {
+				slog.Warn(w, "line", lineNo)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package at
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONL(t *testing.T) {
+	data := "# entries for the week\n" +
+		"{\"id\": 266016, \"isTicket\": false, \"date\": \"2023-09-15T00:00:00Z\", \"startTime\": \"10:30\", \"duration\": 0.75}\n" +
+		"\n" +
+		"// ticket\n" +
+		"{\"id\": 266017, \"isTicket\": true, \"date\": \"2023-09-16T00:00:00Z\", \"duration\": 0.5, \"summary\": \"Fix\"}"
+
+	entries, err := ReadRequestEntries(strings.NewReader(data), "jsonl", ReadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %d", len(entries))
+	}
+
+	if entries[0].Id != 266016 || entries[0].StartTime != "10:30" || entries[1].Id != 266017 || !entries[1].IsTicket {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestReadJSONLReportsLineNumbers(t *testing.T) {
+	data := "{\"id\": 1, \"duration\": 1}\n" +
+		"{\"id\": 2, \"duration\": \n" +
+		"\n" +
+		"{\"id\": \"3\"}\n"
+
+	_, err := ReadRequestEntries(strings.NewReader(data), "jsonl", ReadOptions{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"line 2:", "line 4:"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got: %v", expected, err)
+		}
+	}
+}
+
+func TestReadVersionedFileFromContent(t *testing.T) {
+	data, err := os.ReadFile("../time.json")
+	if err != nil {
+		t.Fatalf("could not read the example file: %v", err)
+	}
+
+	format, err := FormatFromContent(data)
+	if err != nil || format != "json" {
+		t.Fatalf("expected the versioned example to be detected as json, got %q (%v)", format, err)
+	}
+
+	entries, err := ReadRequestEntries(bytes.NewReader(data), format, ReadOptions{})
+	if err != nil || len(entries) == 0 {
+		t.Errorf("expected the entries of the example, got %v (%v)", entries, err)
+	}
+}
+
+func TestFormatFromContent(t *testing.T) {
+	tests := map[string]string{
+		"  [{\"id\": 1}]": "json",
+		"{\n  \"version\": 1,\n  \"entries\": [{\"id\": 1}]\n}\n": "json",
+		"{\"id\": 1}\n":   "jsonl",
+		"# comment\n{}":   "jsonl",
+		"id,date\n1,2023": "",
+	}
+
+	for data, expected := range tests {
+		got, err := FormatFromContent([]byte(data))
+		if got != expected || (expected == "") != (err != nil) {
+			t.Errorf("FormatFromContent(%q) expected %q but got %q (%v)", data, expected, got, err)
+		}
+	}
+}
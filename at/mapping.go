@@ -0,0 +1,118 @@
+package at
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// MappingRule maps records of a time tracker export to an AutoTask ticket or task.
+// All criteria that are set must match, criteria left empty match anything.
+type MappingRule struct {
+	Client      string `yaml:"client"`      // Client name, not case-sensitive.
+	Project     string `yaml:"project"`     // Project name, not case-sensitive.
+	Task        string `yaml:"task"`        // Task name, not case-sensitive.
+	Tag         string `yaml:"tag"`         // One of the tags, not case-sensitive.
+	Description string `yaml:"description"` // Regular expression matched against the description.
+	Id          int    `yaml:"id"`          // AutoTask ticket or task ID, when 0 it is taken from the first group of the description expression.
+	IsTicket    bool   `yaml:"isTicket"`    // True if the ID is a ticket, false for a task.
+
+	description *regexp.Regexp
+}
+
+// Mapping translates the project, tag and description fields of time tracker exports
+// to AutoTask IDs. Rules are evaluated in order, the first rule that matches is used.
+type Mapping struct {
+	Rules []MappingRule `yaml:"rules"`
+}
+
+// LoadMapping reads a YAML mapping file.
+func LoadMapping(filename string) (*Mapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read mapping file: %v", err)
+	}
+
+	return ParseMapping(data)
+}
+
+// ParseMapping parses the YAML content of a mapping file.
+func ParseMapping(data []byte) (*Mapping, error) {
+	var m Mapping
+	err := yaml.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse mapping file: %v", err)
+	}
+
+	for i := range m.Rules {
+		r := &m.Rules[i]
+		if r.Description != "" {
+			r.description, err = regexp.Compile("(?i)" + r.Description)
+			if err != nil {
+				return nil, fmt.Errorf("mapping rule %d: invalid description expression: %v", i+1, err)
+			}
+		}
+
+		if r.Id == 0 && (r.description == nil || r.description.NumSubexp() == 0) {
+			return nil, fmt.Errorf("mapping rule %d: an id or a description expression with a group for the id is required", i+1)
+		}
+	}
+
+	return &m, nil
+}
+
+// Resolve finds the AutoTask ID for a tracker record.
+func (m *Mapping) Resolve(r TrackerRecord) (id int, isTicket bool, err error) {
+	for _, rule := range m.Rules {
+		if !matchesName(rule.Client, r.Client) ||
+			!matchesName(rule.Project, r.Project) ||
+			!matchesName(rule.Task, r.Task) ||
+			!matchesTag(rule.Tag, r.Tags) {
+			continue
+		}
+
+		if rule.description == nil {
+			return rule.Id, rule.IsTicket, nil
+		}
+
+		match := rule.description.FindStringSubmatch(r.Description)
+		if match == nil {
+			continue
+		}
+
+		if rule.Id != 0 {
+			return rule.Id, rule.IsTicket, nil
+		}
+
+		id, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, false, fmt.Errorf("could not parse id %q from description", match[1])
+		}
+
+		return id, rule.IsTicket, nil
+	}
+
+	return 0, false, fmt.Errorf("no mapping rule for project %q, tags %q, description %q", r.Project, strings.Join(r.Tags, ", "), r.Description)
+}
+
+func matchesName(criteria, value string) bool {
+	return criteria == "" || strings.EqualFold(criteria, strings.TrimSpace(value))
+}
+
+func matchesTag(criteria string, tags []string) bool {
+	if criteria == "" {
+		return true
+	}
+
+	for _, t := range tags {
+		if strings.EqualFold(criteria, strings.TrimSpace(t)) {
+			return true
+		}
+	}
+
+	return false
+}
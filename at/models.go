@@ -1,6 +1,8 @@
 package at
 
 import (
+	"context"
+	"log/slog"
 	"sort"
 	"strconv"
 	"time"
@@ -16,6 +18,18 @@ type TimeEntry struct {
 	Summary      string
 	Project      string
 
+	// Optional fields, AutoTask's defaults are kept when they aren't set
+	EndTimeStr    string // Only for tickets
+	Role          string
+	WorkType      string
+	BillingCode   string
+	InternalNotes string
+	ShowOnInvoice *bool
+	NonBillable   *bool
+	TicketStatus  string // Status the ticket is changed to when the entry is saved, only for tickets
+
+	Index int // Position of the entry in the input file, starting at 1, zero if it wasn't read from a file
+
 	// Derived properties
 	Exists             bool
 	Submitted          bool
@@ -25,7 +39,15 @@ type TimeEntry struct {
 	DurationHoursStr   string
 	DurationMinutesStr string
 	WeekNo             int
-	WeekPeerLocator    interface{}
+	Fingerprint        string     // Stable key identifying the entry, see calculateFingerprint
+	EmbedMarker        bool       // If true, the fingerprint marker is added to the summary notes
+	Started            time.Time  // When capturing the entry started
+	Finished           time.Time  // When capturing the entry finished
+	Diagnostics        []string   // Files saved to diagnose a failure, e.g. a screenshot of the page
+	Existing           *TimeEntry // The user's entry in AutoTask that is overwritten, with the update policy
+	Merged             int        // Number of entries of the task on the same day captured as one, with the merge policy
+	MergedFingerprint  string     // Fingerprint of the entry this entry was merged into, recorded in the ledger as well
+	SavedDay           bool       // gt-at saved other entries of the ticket or task on the date, see Ledger.MarkKnown
 }
 
 // NewEntry constructs a TimeEntry and calculates its derived properties
@@ -63,6 +85,7 @@ func (te *TimeEntry) calculateDerived() {
 	te.DurationMinutesStr = strconv.Itoa(int(te.DurationMinutes))
 
 	te.WeekNo = WeekNo(te.Date)
+	te.calculateFingerprint()
 }
 
 // SetError sets an error for the TimeEntry
@@ -70,6 +93,16 @@ func (te *TimeEntry) SetError(err error) {
 	te.Error = err
 }
 
+// SetStarted records the time capturing the entry started
+func (te *TimeEntry) SetStarted() {
+	te.Started = time.Now()
+}
+
+// SetFinished records the time capturing the entry finished
+func (te *TimeEntry) SetFinished() {
+	te.Finished = time.Now()
+}
+
 type TimeEntries []*TimeEntry
 
 func (t TimeEntries) Len() int { return len(t) }
@@ -159,6 +192,37 @@ func (a TimeEntries) GroupByWeekNo() map[int]TimeEntries {
 	return groups
 }
 
+// SetUnprocessedError sets the error on all entries that were neither submitted, found to
+// exist nor already failed, e.g. when a ticket or task could not be opened. The entries the
+// error was set on are returned.
+func (entries TimeEntries) SetUnprocessedError(err error) TimeEntries {
+	marked := make(TimeEntries, 0)
+
+	for _, entry := range entries {
+		if !entry.Submitted && !entry.Exists && entry.Error == nil {
+			entry.SetError(err)
+			marked = append(marked, entry)
+		}
+	}
+
+	return marked
+}
+
+// Cancel returns the error of the context if it is done, marking the entries that were not
+// captured with it and notifying the observer, otherwise err is returned.
+func (entries TimeEntries) Cancel(ctx context.Context, obs Observer, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	slog.Warn("Capture cancelled", "error", ctx.Err())
+	for _, te := range entries.SetUnprocessedError(ctx.Err()) {
+		NotifyEntry(obs, te)
+	}
+
+	return ctx.Err()
+}
+
 // ByDate retrieves all entries that match a given date
 func (entries TimeEntries) ByDate(date time.Time) TimeEntries {
 	result := make(TimeEntries, 0)
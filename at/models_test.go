@@ -1,6 +1,8 @@
 package at
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -24,12 +26,11 @@ func TestCalculateDerivedDurations(t *testing.T) {
 
 	for _, test := range tests {
 		te := &TimeEntry{
-			Id:              1,
-			Date:            time.Now(),
-			Exists:          true,
-			Duration:        test.duration,
-			WeekNo:          1,
-			WeekPeerLocator: nil,
+			Id:       1,
+			Date:     time.Now(),
+			Exists:   true,
+			Duration: test.duration,
+			WeekNo:   1,
 		}
 
 		te.calculateDerived()
@@ -51,3 +52,31 @@ func TestCalculateDerivedDurations(t *testing.T) {
 		}
 	}
 }
+
+func TestCancel(t *testing.T) {
+	date := time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)
+	entries := TimeEntries{
+		NewEntry(100, true, date, "08:00", 1, "Saved", "", "2006/01/02"),
+		NewEntry(100, true, date, "10:00", 1, "Waiting", "", "2006/01/02"),
+	}
+	entries[0].Submitted = true
+
+	boom := errors.New("boom")
+	if err := entries.Cancel(context.Background(), nil, boom); err != boom || entries[1].Error != nil {
+		t.Errorf("expected the error to be returned as is, got %v, %v", err, entries[1].Error)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var notified TimeEntries
+	obs := ObserverFunc(func(e Event) { notified = append(notified, e.Entry) })
+
+	err := entries.Cancel(ctx, obs, boom)
+	if !errors.Is(err, context.Canceled) || !errors.Is(entries[1].Error, context.Canceled) || entries[0].Error != nil {
+		t.Errorf("expected the waiting entry to be cancelled, got %v, %v, %v", err, entries[0].Error, entries[1].Error)
+	}
+	if len(notified) != 1 || notified[0] != entries[1] {
+		t.Errorf("expected the cancelled entry to be notified, got %v", notified)
+	}
+}
@@ -0,0 +1,88 @@
+package at
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBarWidth is the number of characters of the bar.
+const progressBarWidth = 30
+
+// ProgressBar is an Observer printing the progress of a capture, a line with the bar is
+// printed for every finished entry.
+type ProgressBar struct {
+	w     io.Writer
+	total int
+
+	mu      sync.Mutex
+	saved   int
+	skipped int
+	failed  int
+}
+
+// NewProgressBar returns a progress bar for a capture of total entries.
+func NewProgressBar(w io.Writer, total int) *ProgressBar {
+	return &ProgressBar{w: w, total: total}
+}
+
+// OnEvent prints the progress after the event.
+func (p *ProgressBar) OnEvent(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch e.Type {
+	case EventLoginStarted:
+		fmt.Fprintln(p.w, "Logging in to AutoTask")
+	case EventMFAWaiting:
+		fmt.Fprintln(p.w, "Waiting for the login to complete, approve the MFA request if you are asked to")
+	case EventLoggedIn:
+		fmt.Fprintln(p.w, "Logged in")
+	case EventTimesheetChecked:
+		fmt.Fprintf(p.w, "Timesheet of the week of %v: %v\n", e.Timesheet.Week.Format(time.DateOnly), e.Timesheet.Status)
+	case EventTimesheetSubmitted:
+		if e.Err != nil {
+			fmt.Fprintf(p.w, "Timesheet of the week of %v not submitted: %v\n", e.Timesheet.Week.Format(time.DateOnly), e.Err)
+		} else {
+			fmt.Fprintf(p.w, "Timesheet of the week of %v submitted\n", e.Timesheet.Week.Format(time.DateOnly))
+		}
+	case EventEntrySaved:
+		p.saved++
+		p.print(e, "saved")
+	case EventEntryUpdated:
+		p.saved++
+		p.print(e, "updated")
+	case EventEntrySkippedExisting:
+		p.skipped++
+		p.print(e, "exists")
+	case EventEntryFailed:
+		p.failed++
+		p.print(e, fmt.Sprintf("failed: %v", e.Err))
+	}
+}
+
+func (p *ProgressBar) print(e Event, outcome string) {
+	done := p.saved + p.skipped + p.failed
+
+	filled := progressBarWidth
+	if p.total > 0 && done < p.total {
+		filled = done * progressBarWidth / p.total
+	}
+
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+
+	fmt.Fprintf(p.w, "[%s] %d/%d saved: %d, existing: %d, failed: %d | %v %v %v %v\n",
+		bar, done, p.total, p.saved, p.skipped, p.failed,
+		toTicketOrTask(e.Entry.IsTicket), e.Entry.Id, e.Entry.DateStr, outcome)
+}
+
+// toTicketOrTask names the kind of entry.
+func toTicketOrTask(isTicket bool) string {
+	if isTicket {
+		return "ticket"
+	}
+
+	return "task"
+}
@@ -0,0 +1,71 @@
+package at
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyEntry(t *testing.T) {
+	var events []Event
+	obs := ObserverFunc(func(e Event) { events = append(events, e) })
+
+	saved := NewEntry(1, true, time.Now(), "08:00", 1, "Saved", "", "2006/01/02")
+	saved.Submitted = true
+	exists := NewEntry(2, true, time.Now(), "08:00", 1, "Exists", "", "2006/01/02")
+	exists.Exists = true
+	failed := NewEntry(3, false, time.Now(), "", 1, "Failed", "", "2006/01/02")
+	failed.SetError(errors.New("boom"))
+	pending := NewEntry(4, false, time.Now(), "", 1, "Pending", "", "2006/01/02")
+
+	for _, te := range []*TimeEntry{saved, exists, failed, pending} {
+		NotifyEntry(obs, te)
+	}
+	NotifyEntry(nil, saved)
+
+	expected := []EventType{EventEntrySaved, EventEntrySkippedExisting, EventEntryFailed}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+
+	for i, e := range events {
+		if e.Type != expected[i] || e.Time.IsZero() {
+			t.Errorf("event %d: expected %v, got %+v", i, expected[i], e)
+		}
+	}
+
+	if events[2].Err == nil || events[2].Id != 3 {
+		t.Errorf("expected the failed event to carry the error and id, got %+v", events[2])
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgressBar(&buf, 2)
+
+	date := time.Date(2023, 9, 12, 0, 0, 0, 0, time.UTC)
+	saved := NewEntry(266016, true, date, "08:00", 1, "Saved", "", "2006/01/02")
+	saved.Submitted = true
+	failed := NewEntry(17010, false, date, "", 1, "Failed", "", "2006/01/02")
+	failed.SetError(errors.New("could not open task"))
+
+	Notify(p, Event{Type: EventLoginStarted})
+	Notify(p, Event{Type: EventTicketOpened, Id: 266016})
+	NotifyEntry(p, saved)
+	NotifyEntry(p, failed)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got:\n%v", buf.String())
+	}
+
+	if lines[1] != "[###############---------------] 1/2 saved: 1, existing: 0, failed: 0 | ticket 266016 2023/09/12 saved" {
+		t.Errorf("unexpected line: %v", lines[1])
+	}
+
+	if lines[2] != "[##############################] 2/2 saved: 1, existing: 0, failed: 1 | task 17010 2023/09/12 failed: could not open task" {
+		t.Errorf("unexpected line: %v", lines[2])
+	}
+}
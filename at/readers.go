@@ -0,0 +1,145 @@
+package at
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReadOptions holds the settings used by readers when parsing an import file.
+type ReadOptions struct {
+	DateFormat string     // AutoTask date format, used to derive the DateStr of each entry.
+	CSV        CSVOptions // Settings for the csv reader.
+	Mapping    *Mapping   // Translates time tracker exports to AutoTask IDs, required by the tracker readers.
+}
+
+// Reader parses the content of an import file into request entries.
+type Reader func(r io.Reader, opts ReadOptions) ([]RequestEntry, error)
+
+// readers holds the registered readers by format name.
+var readers = map[string]Reader{}
+
+// extensions maps file extensions to format names.
+var extensions = map[string]string{}
+
+func init() {
+	RegisterReader("json", readJSON, ".json")
+	RegisterReader("csv", readCSV, ".csv")
+}
+
+// RegisterReader makes a reader available for the given format name and file extensions.
+// Registering a format again replaces the existing reader.
+func RegisterReader(format string, reader Reader, exts ...string) {
+	format = strings.ToLower(format)
+	readers[format] = reader
+
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// Formats returns the names of the registered formats.
+func Formats() []string {
+	var result []string
+	for format := range readers {
+		result = append(result, format)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// FormatFromFilename determines the format of a file from its extension.
+func FormatFromFilename(filename string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	format, ok := extensions[ext]
+	if !ok {
+		return "", fmt.Errorf("unknown file format for %v, use one of: %v", filename, strings.Join(Formats(), ", "))
+	}
+
+	return format, nil
+}
+
+// FormatFromContent guesses the format from the content, used when there is no file extension
+// to go by, e.g. when reading from stdin. Only the JSON based formats can be detected.
+func FormatFromContent(data []byte) (string, error) {
+	trimmed := strings.TrimLeft(string(data), "\uFEFF \t\r\n")
+
+	switch {
+	case strings.HasPrefix(trimmed, "["), isRequestFile(trimmed):
+		return "json", nil
+	case strings.HasPrefix(trimmed, "{"), strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, "//"):
+		return "jsonl", nil
+	}
+
+	return "", fmt.Errorf("could not detect the file format, use one of: %v", strings.Join(Formats(), ", "))
+}
+
+// isRequestFile reports if the content is a single JSON object with the version or entries of
+// a versioned file, rather than the first entry of a JSON Lines file.
+func isRequestFile(s string) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(s), &fields) != nil {
+		return false
+	}
+
+	_, version := fields["version"]
+	_, entries := fields["entries"]
+
+	return version || entries
+}
+
+// ReadRequestEntries reads the request entries from r using the reader of the given format.
+func ReadRequestEntries(r io.Reader, format string, opts ReadOptions) ([]RequestEntry, error) {
+	reader, ok := readers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format: %v, use one of: %v", format, strings.Join(Formats(), ", "))
+	}
+
+	return reader(r, opts)
+}
+
+// ReadTimeEntries reads the time entries from r using the reader of the given format.
+func ReadTimeEntries(r io.Reader, format string, opts ReadOptions) (TimeEntries, error) {
+	requests, err := ReadRequestEntries(r, format, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ToTimeEntries(requests, opts.DateFormat), nil
+}
+
+// ToTimeEntries converts request entries into TimeEntries.
+func ToTimeEntries(r []RequestEntry, dateFormat string) TimeEntries {
+	var entries TimeEntries
+
+	for i, e := range r {
+		te := NewEntry(e.Id, e.IsTicket, e.Date, e.StartTime, e.Duration, e.Summary, e.Project, dateFormat)
+		te.Index = i + 1
+		te.EndTimeStr = e.EndTime
+		te.Role = e.Role
+		te.WorkType = e.WorkType
+		te.BillingCode = e.BillingCode
+		te.InternalNotes = e.InternalNotes
+		te.ShowOnInvoice = e.ShowOnInvoice
+		te.NonBillable = e.NonBillable
+		te.TicketStatus = e.TicketStatus
+		entries = append(entries, te)
+	}
+
+	return entries
+}
+
+// readJSON reads a JSON array of request entries.
+func readJSON(r io.Reader, opts ReadOptions) ([]RequestEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnmarshalToRequestEntries(data)
+}
@@ -0,0 +1,332 @@
+package at
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntryStatus is the outcome of capturing a single time entry.
+type EntryStatus string
+
+const (
+	StatusSaved   EntryStatus = "saved"   // The entry was saved in AutoTask.
+	StatusExists  EntryStatus = "exists"  // The entry was skipped as it already exists in AutoTask.
+	StatusFailed  EntryStatus = "failed"  // The entry could not be saved.
+	StatusPending EntryStatus = "pending" // The entry was not processed, e.g. on a dry run.
+)
+
+// Status derives the outcome of the entry from its derived properties.
+func (te *TimeEntry) Status() EntryStatus {
+	switch {
+	case te.Error != nil:
+		return StatusFailed
+	case te.Submitted:
+		return StatusSaved
+	case te.Exists:
+		return StatusExists
+	}
+
+	return StatusPending
+}
+
+// EntryResult is the outcome of a single time entry in a Report.
+type EntryResult struct {
+	Index      int         `json:"index"` // Position of the entry in the input file, starting at 1.
+	Id         int         `json:"id"`
+	IsTicket   bool        `json:"isTicket"`
+	Date       string      `json:"date"`
+	StartTime  string      `json:"startTime"`
+	Duration   float32     `json:"duration"`
+	Project    string      `json:"project,omitempty"`
+	Status     EntryStatus `json:"status"`
+	Exists     bool        `json:"exists"`
+	Submitted  bool        `json:"submitted"`
+	Updated    bool        `json:"updated,omitempty"` // The entry overwrote an existing entry, with the update policy.
+	Merged     int         `json:"merged,omitempty"`  // Number of entries of the task on the same day captured as one.
+	Error      string      `json:"error,omitempty"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	ElapsedMs  int64       `json:"elapsedMs"`
+}
+
+// Report holds the results of an import, it can be written as JSON, JUnit XML or CSV.
+type Report struct {
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	ElapsedMs  int64         `json:"elapsedMs"`
+	Total      int           `json:"total"`
+	Saved      int           `json:"saved"`
+	Existing   int           `json:"existing"`
+	Failed     int           `json:"failed"`
+	Pending    int           `json:"pending"`
+	Error      string        `json:"error,omitempty"` // Error that stopped the capture as a whole.
+	ExitCode   int           `json:"exitCode"`        // Non-zero when the capture or any entry failed.
+	Entries    []EntryResult `json:"entries"`
+}
+
+// NewReport creates a report of the entries after a capture, err is the error returned by the capture.
+func NewReport(entries TimeEntries, startedAt, finishedAt time.Time, err error) Report {
+	r := Report{
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		ElapsedMs:  finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      len(entries),
+		Entries:    make([]EntryResult, 0, len(entries)),
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	for i, e := range entries {
+		// The entries are sorted by now, the report refers to their position in the input
+		index := e.Index
+		if index == 0 {
+			index = i + 1
+		}
+
+		result := EntryResult{
+			Index:     index,
+			Id:        e.Id,
+			IsTicket:  e.IsTicket,
+			Date:      e.Date.Format("2006-01-02"),
+			StartTime: e.StartTimeStr,
+			Duration:  e.Duration,
+			Project:   e.Project,
+			Status:    e.Status(),
+			Exists:    e.Exists,
+			Submitted: e.Submitted,
+			Updated:   e.Updated(),
+			Merged:    e.Merged,
+		}
+
+		if e.Error != nil {
+			result.Error = e.Error.Error()
+		}
+
+		if !e.Started.IsZero() {
+			started, finished := e.Started, e.Finished
+			result.StartedAt = &started
+			if !finished.IsZero() {
+				result.FinishedAt = &finished
+				result.ElapsedMs = finished.Sub(started).Milliseconds()
+			}
+		}
+
+		switch result.Status {
+		case StatusSaved:
+			r.Saved++
+		case StatusExists:
+			r.Existing++
+		case StatusFailed:
+			r.Failed++
+		default:
+			r.Pending++
+		}
+
+		r.Entries = append(r.Entries, result)
+	}
+
+	if r.Failed > 0 || r.Error != "" {
+		r.ExitCode = 1
+	}
+
+	return r
+}
+
+// ReportFormats are the supported report formats.
+var ReportFormats = []string{"json", "junit", "csv"}
+
+// ReportFormatFromFilename determines the report format from the file extension, .xml is written as JUnit XML.
+func ReportFormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json", nil
+	case ".xml":
+		return "junit", nil
+	case ".csv":
+		return "csv", nil
+	}
+
+	return "", fmt.Errorf("unknown report format for %v, use one of: %v", filename, strings.Join(ReportFormats, ", "))
+}
+
+// WriteFile writes the report to a file, if no format is given it is derived from the file extension.
+func (r Report) WriteFile(filename, format string) error {
+	if format == "" {
+		var err error
+		format, err = ReportFormatFromFilename(filename)
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("could not create report: %v", err)
+	}
+	defer f.Close()
+
+	err = r.Write(f, format)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write writes the report in the given format.
+func (r Report) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "junit":
+		return r.writeJUnit(w)
+	case "csv":
+		return r.writeCSV(w)
+	}
+
+	return fmt.Errorf("unknown report format: %v, use one of: %v", format, strings.Join(ReportFormats, ", "))
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Error     *junitMessage   `xml:"error,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the report as JUnit XML, each entry is a test case.
+func (r Report) writeJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      "gt-at import",
+		Tests:     r.Total,
+		Failures:  r.Failed,
+		Skipped:   r.Existing + r.Pending,
+		Time:      seconds(r.ElapsedMs),
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+	}
+
+	if r.Error != "" {
+		suite.Errors = 1
+		suite.Error = &junitMessage{Message: r.Error, Text: r.Error}
+	}
+
+	for _, e := range r.Entries {
+		c := junitTestCase{
+			Name:      fmt.Sprintf("%d %v %v %.2fh", e.Index, e.Date, e.StartTime, e.Duration),
+			ClassName: fmt.Sprintf("%v.%d", toTicketTask(e.IsTicket), e.Id),
+			Time:      seconds(e.ElapsedMs),
+		}
+
+		switch e.Status {
+		case StatusFailed:
+			c.Failure = &junitMessage{Message: e.Error, Text: e.Error}
+		case StatusExists:
+			c.Skipped = &junitMessage{Message: "already exists"}
+		case StatusPending:
+			c.Skipped = &junitMessage{Message: "not captured"}
+		}
+
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeCSV writes the report as CSV, one row per entry.
+func (r Report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"index", "id", "type", "date", "startTime", "duration", "status", "exists", "submitted", "error", "startedAt", "finishedAt", "elapsedMs"})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range r.Entries {
+		err = cw.Write([]string{
+			strconv.Itoa(e.Index),
+			strconv.Itoa(e.Id),
+			toTicketTask(e.IsTicket),
+			e.Date,
+			e.StartTime,
+			fmt.Sprintf("%.2f", e.Duration),
+			string(e.Status),
+			strconv.FormatBool(e.Exists),
+			strconv.FormatBool(e.Submitted),
+			e.Error,
+			formatOptionalTime(e.StartedAt),
+			formatOptionalTime(e.FinishedAt),
+			strconv.FormatInt(e.ElapsedMs, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// toTicketTask converts a boolean indicating if an entry is a ticket to either "ticket" or "task".
+func toTicketTask(isTicket bool) string {
+	return map[bool]string{true: "ticket", false: "task"}[isTicket]
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
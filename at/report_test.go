@@ -0,0 +1,99 @@
+package at
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReport() Report {
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	started := time.Date(2023, time.September, 18, 8, 0, 0, 0, time.UTC)
+
+	saved := NewEntry(1, true, day, "09:00", 1, "saved", "", "2006/01/02")
+	saved.Submitted = true
+	saved.Started, saved.Finished = started, started.Add(1500*time.Millisecond)
+
+	exists := NewEntry(2, false, day, "", 0.5, "exists", "", "2006/01/02")
+	exists.Exists = true
+
+	failed := NewEntry(3, true, day, "10:00", 0.25, "failed", "", "2006/01/02")
+	failed.SetError(errors.New("captureEntry: could not find dialog"))
+
+	return NewReport(TimeEntries{saved, exists, failed}, started, started.Add(time.Minute), nil)
+}
+
+func TestNewReport(t *testing.T) {
+	r := testReport()
+
+	if r.Total != 3 || r.Saved != 1 || r.Existing != 1 || r.Failed != 1 || r.Pending != 0 || r.ExitCode != 1 {
+		t.Errorf("unexpected totals: %+v", r)
+	}
+
+	if r.Entries[0].ElapsedMs != 1500 || r.Entries[2].Error != "captureEntry: could not find dialog" {
+		t.Errorf("unexpected entries: %+v", r.Entries)
+	}
+
+	ok := NewReport(TimeEntries{}, time.Now(), time.Now(), nil)
+	if ok.ExitCode != 0 {
+		t.Errorf("expected a zero exit code, got %d", ok.ExitCode)
+	}
+}
+
+func TestNewReportInputIndex(t *testing.T) {
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	entries := ToTimeEntries([]RequestEntry{
+		{Id: 1, IsTicket: true, Date: day.AddDate(0, 0, 1), StartTime: "09:00", Duration: 1, Summary: "later"},
+		{Id: 2, IsTicket: true, Date: day, StartTime: "09:00", Duration: 1, Summary: "earlier"},
+	}, "2006/01/02")
+
+	// The summary sorts the entries before the report is made
+	entries.SortByDateAndTime()
+
+	r := NewReport(entries, day, day, nil)
+	if r.Entries[0].Id != 2 || r.Entries[0].Index != 2 || r.Entries[1].Index != 1 {
+		t.Errorf("expected the positions in the input, got %+v", r.Entries)
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	r := testReport()
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, "json"); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Entries[1].Status != StatusExists {
+		t.Errorf("json: could not read back report: %v, %+v", err, decoded)
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, "junit"); err != nil {
+		t.Fatalf("junit: %v", err)
+	}
+
+	for _, expected := range []string{`tests="3" failures="1"`, `classname="ticket.3"`, `<failure message="captureEntry: could not find dialog">`, `<skipped message="already exists">`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("junit: expected %q in:\n%v", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := r.Write(&buf, "csv"); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[3], "3,3,ticket,2023-09-15,10:00,0.25,failed,false,false,captureEntry: could not find dialog") {
+		t.Errorf("csv: unexpected output:\n%v", buf.String())
+	}
+
+	if err := r.Write(&buf, "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
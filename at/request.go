@@ -2,6 +2,12 @@ package at
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -11,24 +17,205 @@ type Credentials struct {
 	Password string
 }
 
+// FormatVersion is the version of the import file format, files without a version are
+// treated as the original format: a plain array of entries.
+const FormatVersion = 1
+
+// RequestFile is the versioned import file, holding the format version and the entries.
+type RequestFile struct {
+	Version int            `json:"version"`
+	Entries []RequestEntry `json:"entries"`
+}
+
 // RequestEntry represents a single entry as received in a JSON request.
+// Fields without omitempty are required, the description is published in the JSON Schema.
 type RequestEntry struct {
-	Id        int       `json:"id"`
-	IsTicket  bool      `json:"isTicket"`
-	Date      time.Time `json:"date"`
-	StartTime string    `json:"startTime"`
-	Duration  float32   `json:"duration"`
-	Summary   string    `json:"summary"`
-	Project   string    `json:"project"`
+	Id        int       `json:"id" description:"The AutoTask ticket or task ID."`
+	IsTicket  bool      `json:"isTicket" description:"True if the entry is for a ticket (service desk), false for a task (project)."`
+	Date      time.Time `json:"date" description:"The date of the entry, e.g. 2023-09-15T00:00:00Z."`
+	StartTime string    `json:"startTime,omitempty" description:"The start time of the entry in HH:MM format, required for tickets."`
+	Duration  float32   `json:"duration" description:"The duration of the entry in hours."`
+	Summary   string    `json:"summary" description:"The summary notes of the entry."`
+	Project   string    `json:"project,omitempty" description:"The project name, only used for reporting."`
+
+	// Optional fields, AutoTask's defaults are kept when they aren't set
+	EndTime       string `json:"endTime,omitempty" description:"The end time of the entry in HH:MM format, only for tickets. Defaults to the start time plus the duration."`
+	Role          string `json:"role,omitempty" description:"The role the time is worked as."`
+	WorkType      string `json:"workType,omitempty" description:"The work type of the entry."`
+	BillingCode   string `json:"billingCode,omitempty" description:"The billing code of the entry."`
+	InternalNotes string `json:"internalNotes,omitempty" description:"Internal notes, not shown to the customer."`
+	ShowOnInvoice *bool  `json:"showOnInvoice,omitempty" description:"True to show the entry on the invoice."`
+	NonBillable   *bool  `json:"nonBillable,omitempty" description:"True if the entry is non-billable."`
+	TicketStatus  string `json:"ticketStatus,omitempty" description:"The status the ticket is changed to when the entry is saved, only for tickets."`
 }
 
-// UnmarshalToRequestEntries converts JSON data into a slice of RequestEntry.
+// legacyFields maps field names used by earlier versions of the format to their current name.
+var legacyFields = map[string]string{
+	"startTimeStr": "startTime",
+}
+
+// UnmarshalToRequestEntries converts JSON data into a slice of RequestEntry. Both the versioned
+// file and a plain array of entries are accepted, warnings about legacy or unknown fields are logged.
 func UnmarshalToRequestEntries(data []byte) ([]RequestEntry, error) {
-	var r []RequestEntry
-	err := json.Unmarshal(data, &r)
+	r, warnings, err := DecodeRequestEntries(data)
+	for _, w := range warnings {
+		slog.Warn(w)
+	}
+
 	return r, err
 }
 
+// DecodeRequestEntries converts JSON data into a slice of RequestEntry, legacy field names are
+// migrated to their current name. It returns warnings for each migrated or unknown field.
+func DecodeRequestEntries(data []byte) ([]RequestEntry, []string, error) {
+	var items []json.RawMessage
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		var file struct {
+			Version int               `json:"version"`
+			Entries []json.RawMessage `json:"entries"`
+		}
+
+		err := json.Unmarshal(data, &file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if file.Version < 1 || file.Version > FormatVersion {
+			return nil, nil, fmt.Errorf("unsupported file version: %v, this version of gt-at supports version %v", file.Version, FormatVersion)
+		}
+
+		items = file.Entries
+	} else {
+		err := json.Unmarshal(data, &items)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var result []RequestEntry
+	var warnings []string
+
+	for i, raw := range items {
+		e, w, err := decodeRequestEntry(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %d: %v", i+1, err)
+		}
+
+		for _, msg := range w {
+			warnings = append(warnings, fmt.Sprintf("entry %d: %v", i+1, msg))
+		}
+
+		result = append(result, e)
+	}
+
+	return result, warnings, nil
+}
+
+// decodeRequestEntry decodes a single entry object, migrating legacy field names.
+func decodeRequestEntry(raw json.RawMessage) (RequestEntry, []string, error) {
+	var e RequestEntry
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return e, nil, err
+	}
+
+	var warnings []string
+
+	for legacy, current := range legacyFields {
+		value, ok := fields[legacy]
+		if !ok {
+			continue
+		}
+
+		delete(fields, legacy)
+		if _, ok := fields[current]; ok {
+			warnings = append(warnings, fmt.Sprintf("legacy field %q is ignored, %q is used instead", legacy, current))
+			continue
+		}
+
+		fields[current] = value
+		warnings = append(warnings, fmt.Sprintf("legacy field %q is deprecated, rename it to %q", legacy, current))
+	}
+
+	known := requestFieldNames()
+	var unknown []string
+	for name := range fields {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		warnings = append(warnings, fmt.Sprintf("unknown field %q is ignored", name))
+	}
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return e, nil, err
+	}
+
+	err = json.Unmarshal(migrated, &e)
+	return e, warnings, err
+}
+
+// requestFieldNames returns the JSON names of the RequestEntry fields.
+func requestFieldNames() map[string]bool {
+	names := map[string]bool{}
+
+	t := reflect.TypeOf(RequestEntry{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names[name] = true
+	}
+
+	return names
+}
+
+// Request converts the TimeEntry back into the RequestEntry it was created from.
+func (te *TimeEntry) Request() RequestEntry {
+	return RequestEntry{
+		Id:        te.Id,
+		IsTicket:  te.IsTicket,
+		Date:      te.Date,
+		StartTime: te.StartTimeStr,
+		Duration:  te.Duration,
+		Summary:   te.Summary,
+		Project:   te.Project,
+
+		EndTime:       te.EndTimeStr,
+		Role:          te.Role,
+		WorkType:      te.WorkType,
+		BillingCode:   te.BillingCode,
+		InternalNotes: te.InternalNotes,
+		ShowOnInvoice: te.ShowOnInvoice,
+		NonBillable:   te.NonBillable,
+		TicketStatus:  te.TicketStatus,
+	}
+}
+
+// WriteRequestFile writes the entries as a versioned import file, which can be imported again.
+func WriteRequestFile(w io.Writer, entries TimeEntries) error {
+	f := RequestFile{Version: FormatVersion, Entries: make([]RequestEntry, 0, len(entries))}
+	for _, te := range entries {
+		f.Entries = append(f.Entries, te.Request())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(f)
+	if err != nil {
+		return fmt.Errorf("could not write entries: %v", err)
+	}
+
+	return nil
+}
+
 // UnmarshalToTimeEntries converts JSON data into a TimeEntries.
 func UnmarshalToTimeEntries(data []byte, dateFormat string) (TimeEntries, error) {
 	r, err := UnmarshalToRequestEntries(data)
@@ -37,12 +224,5 @@ func UnmarshalToTimeEntries(data []byte, dateFormat string) (TimeEntries, error)
 		return nil, err
 	}
 
-	var entries TimeEntries
-
-	for _, e := range r {
-		te := NewEntry(e.Id, e.IsTicket, e.Date, e.StartTime, e.Duration, e.Summary, e.Project, dateFormat)
-		entries = append(entries, te)
-	}
-
-	return entries, nil
+	return ToTimeEntries(r, dateFormat), nil
 }
@@ -0,0 +1,160 @@
+package at
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeRequestEntriesVersions(t *testing.T) {
+	legacy := `[{"id": 1, "isTicket": true, "date": "2023-09-15T00:00:00Z", "startTime": "10:30", "duration": 1, "summary": "a"}]`
+	versioned := `{"version": 1, "entries": ` + legacy + `}`
+
+	for _, data := range []string{legacy, versioned} {
+		entries, warnings, err := DecodeRequestEntries([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(warnings) != 0 || len(entries) != 1 || entries[0].StartTime != "10:30" {
+			t.Errorf("unexpected result: %+v, warnings: %v", entries, warnings)
+		}
+	}
+
+	_, _, err := DecodeRequestEntries([]byte(`{"version": 99, "entries": []}`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported file version") {
+		t.Errorf("expected an unsupported version error, got: %v", err)
+	}
+}
+
+func TestDecodeRequestEntriesMigratesLegacyFields(t *testing.T) {
+	data := `[
+		{"id": 1, "date": "2023-09-15T00:00:00Z", "startTimeStr": "10:30", "duration": 1, "summary": "a"},
+		{"id": 2, "date": "2023-09-15T00:00:00Z", "startTimeStr": "09:00", "startTime": "11:00", "duration": 1, "summary": "b", "minutes": 5}
+	]`
+
+	entries, warnings, err := DecodeRequestEntries([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if entries[0].StartTime != "10:30" || entries[1].StartTime != "11:00" {
+		t.Errorf("unexpected start times: %v, %v", entries[0].StartTime, entries[1].StartTime)
+	}
+
+	expected := []string{
+		`entry 1: legacy field "startTimeStr" is deprecated, rename it to "startTime"`,
+		`entry 2: legacy field "startTimeStr" is ignored, "startTime" is used instead`,
+		`entry 2: unknown field "minutes" is ignored`,
+	}
+
+	if strings.Join(warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected warnings:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(warnings, "\n"))
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Defs struct {
+			Entry struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"entry"`
+		} `json:"$defs"`
+	}
+
+	err = json.Unmarshal(data, &schema)
+	if err != nil {
+		t.Fatalf("could not unmarshal schema: %v", err)
+	}
+
+	entry := schema.Defs.Entry
+	if strings.Join(entry.Required, ",") != "id,isTicket,date,duration,summary" {
+		t.Errorf("unexpected required fields: %v", entry.Required)
+	}
+
+	if entry.Properties["date"]["format"] != "date-time" || entry.Properties["duration"]["type"] != "number" || entry.Properties["nonBillable"]["type"] != "boolean" {
+		t.Errorf("unexpected properties: %v", entry.Properties)
+	}
+}
+
+func TestOptionalFields(t *testing.T) {
+	data := []byte(`[{"id": 100, "isTicket": true, "date": "2023-09-15T00:00:00Z", "startTime": "10:30", "duration": 1, "summary": "Fixed it",
+		"endTime": "11:45", "role": "Engineer", "workType": "Remote", "billingCode": "Support", "internalNotes": "Reboot needed",
+		"showOnInvoice": false, "nonBillable": true, "ticketStatus": "Complete"}]`)
+
+	r, warnings, err := DecodeRequestEntries(data)
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("unexpected error: %v, %v", err, warnings)
+	}
+
+	te := ToTimeEntries(r, "2006/01/02")[0]
+	if te.EndTimeStr != "11:45" || te.Role != "Engineer" || te.WorkType != "Remote" || te.BillingCode != "Support" ||
+		te.InternalNotes != "Reboot needed" || te.TicketStatus != "Complete" {
+		t.Errorf("expected the optional fields to be read, got %+v", te)
+	}
+
+	// Unset flags keep the default of AutoTask, so false must be distinguished from unset
+	if te.ShowOnInvoice == nil || *te.ShowOnInvoice || te.NonBillable == nil || !*te.NonBillable {
+		t.Errorf("expected the flags to be set, got %v, %v", te.ShowOnInvoice, te.NonBillable)
+	}
+
+	var buf bytes.Buffer
+	err = WriteRequestFile(&buf, TimeEntries{te})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"showOnInvoice": false`) || !strings.Contains(buf.String(), `"ticketStatus": "Complete"`) {
+		t.Errorf("expected the optional fields to be written, got:\n%v", buf.String())
+	}
+}
+
+func TestWriteRequestFile(t *testing.T) {
+	date := time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)
+	entries := TimeEntries{
+		NewEntry(100, true, date, "10:30", 1.25, "Fixed it", "", "2006/01/02"),
+		NewEntry(200, false, date, "", 0.5, "Design", "", "2006/01/02"),
+	}
+
+	var buf bytes.Buffer
+	err := WriteRequestFile(&buf, entries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The file can be imported again
+	read, warnings, err := DecodeRequestEntries(buf.Bytes())
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("unexpected error: %v, %v", err, warnings)
+	}
+	if len(read) != 2 || read[0] != entries[0].Request() || read[1] != entries[1].Request() {
+		t.Errorf("expected the entries to round trip, got %+v", read)
+	}
+	if !strings.Contains(buf.String(), `"version": 1`) {
+		t.Errorf("expected the format version, got:\n%v", buf.String())
+	}
+}
+
+func TestFetchQueryContains(t *testing.T) {
+	q := FetchQuery{From: time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)}
+
+	tests := map[time.Time]bool{
+		time.Date(2023, 9, 10, 23, 0, 0, 0, time.UTC): false,
+		time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC):  true,
+		time.Date(2023, 9, 15, 17, 0, 0, 0, time.UTC): true,
+		time.Date(2023, 9, 16, 0, 0, 0, 0, time.UTC):  false,
+	}
+
+	for date, expected := range tests {
+		if q.Contains(date) != expected {
+			t.Errorf("expected %v in the range to be %v", date, expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package at
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaID is the identifier of the JSON Schema for the current file format version.
+var SchemaID = fmt.Sprintf("https://github.com/philipf/gt-at/schema/v%d/time-entries.json", FormatVersion)
+
+// JSONSchema returns the JSON Schema of the import file, generated from RequestEntry. Both the
+// versioned file and the original plain array of entries are described.
+func JSONSchema() ([]byte, error) {
+	entry := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+	}
+
+	properties := map[string]interface{}{}
+	var required []string
+
+	t := reflect.TypeOf(RequestEntry{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, options, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+		property := schemaType(f.Type)
+		if d := f.Tag.Get("description"); d != "" {
+			property["description"] = d
+		}
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	entry["properties"] = properties
+	entry["required"] = required
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         SchemaID,
+		"title":       "gt-at time entries",
+		"description": "Time entries to import into AutoTask with gt-at.",
+		"oneOf": []interface{}{
+			map[string]interface{}{"$ref": "#/$defs/file"},
+			map[string]interface{}{
+				"description": "Unversioned file, a plain array of entries.",
+				"type":        "array",
+				"items":       map[string]interface{}{"$ref": "#/$defs/entry"},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"file": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"version", "entries"},
+				"properties": map[string]interface{}{
+					"$schema": map[string]interface{}{"type": "string"},
+					"version": map[string]interface{}{
+						"description": "The version of the file format.",
+						"const":       FormatVersion,
+					},
+					"entries": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"$ref": "#/$defs/entry"},
+					},
+				},
+			},
+			"entry": entry,
+		},
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaType maps a Go type to its JSON Schema type.
+func schemaType(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaType(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaType(t.Elem())}
+	}
+
+	return map[string]interface{}{"type": "string"}
+}
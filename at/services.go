@@ -1,5 +1,7 @@
 package at
 
+import "context"
+
 // Constants for specific AutoTask URIs.
 const (
 	// Base URL for AutoTask.
@@ -16,6 +18,12 @@ const (
 
 	// Format string for the landing URL, expects the base URL.
 	URI_LANDING = "%s/" + URI_LANDING_SUFFIX
+
+	// Format string for the timesheet URL, expects the base URL and the Sunday of the week as yyyy-mm-dd.
+	URI_TIMESHEET = "%s/Mvc/Timesheets/TimesheetEntry.mvc?weekStart=%s"
+
+	// Zone discovery address of the AutoTask REST API, returns the API address of a user's zone.
+	URI_API_ZONE = "https://webservices.autotask.net/atservicesrest/v1.0/zoneInformation"
 )
 
 // BaseURL is the default base URL for AutoTask operations.
@@ -23,13 +31,28 @@ var BaseURL string = URI_AUTOTASK
 
 // CaptureOptions defines the options for the CaptureTimes method.
 type CaptureOptions struct {
-	Credentials     Credentials // Authentication details.
-	DryRun          bool        // If true, does a dry run without actual capture.
-	UserDisplayName string      // Display name of the user in AutoTask, this available under the user profile. This value is used to find time entries for the user.
-	BrowserType     string      // Type of the browser to use, e.g., "chromium", "firefox" and "webkit".
-	Headless        bool        // If true, browser operates in headless mode.
-	DateFormat      string      // Format for date representation.
-	DayFormat       string      // Format for day representation.
+	Credentials       Credentials         // Authentication details.
+	DryRun            bool                // If true, does a dry run without actual capture.
+	UserDisplayName   string              // Display name of the user in AutoTask, this available under the user profile. This value is used to find time entries for the user.
+	BrowserType       string              // Type of the browser to use, e.g., "chromium", "firefox" and "webkit".
+	Headless          bool                // If true, browser operates in headless mode.
+	DateFormat        string              // Format for date representation.
+	DayFormat         string              // Format for day representation.
+	FreshLogin        bool                // If true, ignores any stored browser session and performs a full login.
+	DuplicatePolicy   DuplicatePolicy     // How entries that might already exist are handled, defaults to skip.
+	EmbedMarkers      bool                // If true, a fingerprint marker is added to the summary notes of each entry.
+	StartURL          string              // Address the login starts at, defaults to URI_AUTOTASK.
+	SelectorsFile     string              // YAML file overriding the built-in selectors of the browser automation.
+	Backend           string              // Name of the backend used to capture the entries, e.g. "playwright" or "api".
+	Observer          Observer            // Receives the progress of the capture, optional.
+	DiagnosticsDir    string              // If set, a screenshot and the HTML of the page are saved here when an entry fails.
+	Trace             bool                // If true, a Playwright trace of the capture after logging in is saved in DiagnosticsDir.
+	SubmitAfterImport bool                // If true, the timesheets of the weeks in which every entry was captured are submitted.
+	SameDayTasks      ConsolidationPolicy // How several entries of a task on the same day are captured, defaults to fail.
+
+	// ConfirmUpdate is asked before an existing entry is overwritten with the update policy, the
+	// entry is skipped if it returns false. Without it existing entries are overwritten.
+	ConfirmUpdate func(te *TimeEntry) bool
 }
 
 // AutoTasker is an interface for capturing time entries.
@@ -37,3 +60,30 @@ type AutoTasker interface {
 	// CaptureTimes captures time entries based on the provided options.
 	CaptureTimes(entries TimeEntries, opts CaptureOptions) error
 }
+
+// ContextAutoTasker is implemented by backends that can be cancelled.
+type ContextAutoTasker interface {
+	AutoTasker
+
+	// CaptureTimesContext captures time entries until the context is done. When cancelled the
+	// entries captured so far keep their result, the others are marked with the error of the
+	// context, which is also returned.
+	CaptureTimesContext(ctx context.Context, entries TimeEntries, opts CaptureOptions) error
+}
+
+// CaptureTimesContext captures time entries with the backend, honouring the context if the
+// backend implements ContextAutoTasker. Other backends can only be cancelled before they start.
+func CaptureTimesContext(ctx context.Context, a AutoTasker, entries TimeEntries, opts CaptureOptions) error {
+	if c, ok := a.(ContextAutoTasker); ok {
+		return c.CaptureTimesContext(ctx, entries, opts)
+	}
+
+	if err := ctx.Err(); err != nil {
+		for _, te := range entries.SetUnprocessedError(err) {
+			NotifyEntry(opts.Observer, te)
+		}
+		return err
+	}
+
+	return a.CaptureTimes(entries, opts)
+}
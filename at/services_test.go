@@ -0,0 +1,41 @@
+package at
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingBackend struct {
+	calls int
+}
+
+func (c *countingBackend) CaptureTimes(entries TimeEntries, opts CaptureOptions) error {
+	c.calls++
+	return nil
+}
+
+func TestCaptureTimesContextCancelled(t *testing.T) {
+	entries := TimeEntries{NewEntry(1, true, time.Now(), "08:00", 1, "Work", "", "2006/01/02")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := &countingBackend{}
+	err := CaptureTimesContext(ctx, b, entries, CaptureOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+	if b.calls != 0 {
+		t.Errorf("expected the backend not to be called")
+	}
+	if !errors.Is(entries[0].Error, context.Canceled) {
+		t.Errorf("expected the entry to be marked as cancelled, got: %v", entries[0].Error)
+	}
+
+	err = CaptureTimesContext(context.Background(), b, entries, CaptureOptions{})
+	if err != nil || b.calls != 1 {
+		t.Errorf("expected the backend to be called, got: %v, %d calls", err, b.calls)
+	}
+}
@@ -0,0 +1,75 @@
+package at
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimesheetStatus is the approval status of the timesheet of a week.
+type TimesheetStatus string
+
+const (
+	TimesheetOpen      TimesheetStatus = "open"      // Time can be entered, the timesheet is not submitted.
+	TimesheetSubmitted TimesheetStatus = "submitted" // Submitted for approval, it has to be recalled to enter time.
+	TimesheetApproved  TimesheetStatus = "approved"  // Approved, time can no longer be entered.
+	TimesheetUnknown   TimesheetStatus = "unknown"   // The status could not be determined.
+)
+
+// ParseTimesheetStatus interprets the status shown on a timesheet, e.g. "Submitted for Approval".
+// A rejected timesheet can be changed again, so it is open.
+func ParseTimesheetStatus(text string) TimesheetStatus {
+	s := strings.ToLower(strings.TrimSpace(text))
+
+	switch {
+	case strings.Contains(s, "rejected"), strings.Contains(s, "open"), strings.Contains(s, "not submitted"):
+		return TimesheetOpen
+	case strings.Contains(s, "approved"):
+		return TimesheetApproved
+	case strings.Contains(s, "submitted"):
+		return TimesheetSubmitted
+	}
+
+	return TimesheetUnknown
+}
+
+// Locked returns true if AutoTask doesn't accept time in the week of the timesheet.
+func (s TimesheetStatus) Locked() bool {
+	return s == TimesheetSubmitted || s == TimesheetApproved
+}
+
+// Timesheet is the status of the timesheet of a week.
+type Timesheet struct {
+	Week   time.Time // Sunday the week starts on.
+	Status TimesheetStatus
+	Err    error // Why the status is unknown, if it is.
+}
+
+// Weeks returns the Sundays of the weeks the entries fall in, in order.
+func (entries TimeEntries) Weeks() []time.Time {
+	seen := map[time.Time]bool{}
+
+	var weeks []time.Time
+	for _, te := range entries {
+		sunday := SundayOfTheWeek(te.Date)
+		if !seen[sunday] {
+			seen[sunday] = true
+			weeks = append(weeks, sunday)
+		}
+	}
+
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Before(weeks[j]) })
+
+	return weeks
+}
+
+// Timesheeter is implemented by backends that can manage the weekly timesheets of the user.
+type Timesheeter interface {
+	// Timesheets returns the status of the timesheets of the weeks.
+	Timesheets(ctx context.Context, weeks []time.Time, opts CaptureOptions) ([]Timesheet, error)
+	// SubmitTimesheet submits the timesheet of the week for approval.
+	SubmitTimesheet(ctx context.Context, week time.Time, opts CaptureOptions) error
+	// RecallTimesheet recalls the submitted timesheet of the week, so time can be entered again.
+	RecallTimesheet(ctx context.Context, week time.Time, opts CaptureOptions) error
+}
@@ -0,0 +1,46 @@
+package at
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimesheetStatus(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected TimesheetStatus
+	}{
+		{"Open", TimesheetOpen},
+		{"Not Submitted", TimesheetOpen},
+		{"Rejected", TimesheetOpen},
+		{" Submitted for Approval ", TimesheetSubmitted},
+		{"Approved", TimesheetApproved},
+		{"", TimesheetUnknown},
+	}
+
+	for _, test := range tests {
+		if s := ParseTimesheetStatus(test.text); s != test.expected {
+			t.Errorf("expected %q to be %v, got %v", test.text, test.expected, s)
+		}
+	}
+
+	if TimesheetOpen.Locked() || TimesheetUnknown.Locked() || !TimesheetSubmitted.Locked() || !TimesheetApproved.Locked() {
+		t.Error("expected only submitted and approved timesheets to be locked")
+	}
+}
+
+func TestWeeks(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2023, 9, day, 0, 0, 0, 0, time.UTC) }
+
+	entries := TimeEntries{
+		NewEntry(1, true, date(19), "08:00", 1, "Tuesday", "", "2006/01/02"),
+		NewEntry(1, true, date(11), "08:00", 1, "Monday", "", "2006/01/02"),
+		NewEntry(2, false, date(17), "", 1, "Sunday", "", "2006/01/02"),
+		NewEntry(2, false, date(16), "", 1, "Saturday", "", "2006/01/02"),
+	}
+
+	weeks := entries.Weeks()
+	if len(weeks) != 2 || !weeks[0].Equal(date(10)) || !weeks[1].Equal(date(17)) {
+		t.Errorf("expected the weeks of 2023-09-10 and 2023-09-17, got %v", weeks)
+	}
+}
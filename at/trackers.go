@@ -0,0 +1,530 @@
+package at
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrackerRecord is a time entry as exported by a commercial time tracker, before it is mapped
+// to an AutoTask ticket or task.
+type TrackerRecord struct {
+	Ref         string // Location of the record in the export, e.g. "row 3", used in errors.
+	Client      string
+	Project     string
+	Task        string
+	Description string
+	Tags        []string
+	Date        time.Time // Date the time was spent.
+	StartTime   string    // Start time in 15:04 format, empty if the tracker does not export it.
+	Duration    float32   // in hours
+}
+
+// trackerParser parses the content of a tracker export into records.
+type trackerParser func(data []byte) ([]TrackerRecord, error)
+
+func init() {
+	RegisterReader("toggl", trackerReader("toggl", parseTogglCSV, parseTogglJSON))
+	RegisterReader("clockify", trackerReader("clockify", parseClockifyCSV, parseClockifyJSON))
+	RegisterReader("harvest", trackerReader("harvest", parseHarvestCSV, parseHarvestJSON))
+}
+
+// trackerReader creates a Reader for a tracker's native export, both the CSV and JSON
+// exports are supported and detected from the content.
+func trackerReader(name string, parseCSV, parseJSON trackerParser) Reader {
+	return func(r io.Reader, opts ReadOptions) ([]RequestEntry, error) {
+		if opts.Mapping == nil {
+			return nil, fmt.Errorf("%v: a mapping file is required to translate the export to AutoTask IDs", name)
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		parse := parseCSV
+		if isJSON(data) {
+			parse = parseJSON
+		}
+
+		records, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+
+		return mapTrackerRecords(name, records, opts.Mapping)
+	}
+}
+
+// mapTrackerRecords converts tracker records into request entries using the mapping.
+func mapTrackerRecords(name string, records []TrackerRecord, m *Mapping) ([]RequestEntry, error) {
+	var entries []RequestEntry
+	var errs []error
+
+	for _, r := range records {
+		id, isTicket, err := m.Resolve(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v: %v", name, r.Ref, err))
+			continue
+		}
+
+		entries = append(entries, RequestEntry{
+			Id:        id,
+			IsTicket:  isTicket,
+			Date:      r.Date,
+			StartTime: r.StartTime,
+			Duration:  r.Duration,
+			Summary:   r.Description,
+			Project:   r.Project,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return entries, nil
+}
+
+// isJSON reports if the data looks like a JSON document rather than CSV.
+func isJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, "\uFEFF \t\r\n")
+	return len(data) > 0 && (data[0] == '[' || data[0] == '{')
+}
+
+// dateOnly returns the date part of t at midnight UTC, matching dates in the JSON import format.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseFirst parses value with the first layout that matches.
+func parseFirst(value string, layouts ...string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not parse %q", value)
+}
+
+var (
+	dateLayouts = []string{"2006-01-02", "01/02/2006", "02.01.2006", "2006/01/02"}
+	timeLayouts = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM", "3:04pm", "3:04PM"}
+)
+
+// splitTags splits a comma separated list of tags.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+
+	return tags
+}
+
+// csvTable gives access to csv rows by column name.
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+	lines   []int
+}
+
+// readCSVTable reads a csv export with a header row.
+func readCSVTable(data []byte) (*csvTable, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header: %v", err)
+	}
+
+	t := &csvTable{columns: map[string]int{}}
+	for i, h := range header {
+		t.columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		t.rows = append(t.rows, record)
+		t.lines = append(t.lines, line)
+	}
+
+	return t, nil
+}
+
+// get returns the value of the first of the named columns present in the header.
+func (t *csvTable) get(row int, names ...string) string {
+	for _, name := range names {
+		i, ok := t.columns[strings.ToLower(name)]
+		if ok && i < len(t.rows[row]) {
+			return strings.TrimSpace(t.rows[row][i])
+		}
+	}
+
+	return ""
+}
+
+// parseRows converts each row of a csv export into a record, collecting errors per row.
+func parseRows(data []byte, parseRow func(t *csvTable, i int, r *TrackerRecord) error) ([]TrackerRecord, error) {
+	t, err := readCSVTable(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TrackerRecord
+	var errs []error
+
+	for i := range t.rows {
+		r := TrackerRecord{Ref: fmt.Sprintf("row %d", t.lines[i])}
+		err := parseRow(t, i, &r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", r.Ref, err))
+			continue
+		}
+
+		records = append(records, r)
+	}
+
+	return records, errors.Join(errs...)
+}
+
+// parseTogglCSV parses a Toggl Track detailed report CSV export.
+func parseTogglCSV(data []byte) ([]TrackerRecord, error) {
+	return parseRows(data, func(t *csvTable, i int, r *TrackerRecord) error {
+		r.Client = t.get(i, "Client")
+		r.Project = t.get(i, "Project")
+		r.Task = t.get(i, "Task")
+		r.Description = t.get(i, "Description")
+		r.Tags = splitTags(t.get(i, "Tags"))
+
+		date, err := parseFirst(t.get(i, "Start date"), dateLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid start date: %v", err)
+		}
+		r.Date = dateOnly(date)
+
+		start, err := parseFirst(t.get(i, "Start time"), timeLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid start time: %v", err)
+		}
+		r.StartTime = start.Format("15:04")
+
+		r.Duration, err = ParseDuration(t.get(i, "Duration"))
+		return err
+	})
+}
+
+// parseClockifyCSV parses a Clockify detailed report CSV export.
+func parseClockifyCSV(data []byte) ([]TrackerRecord, error) {
+	return parseRows(data, func(t *csvTable, i int, r *TrackerRecord) error {
+		r.Client = t.get(i, "Client")
+		r.Project = t.get(i, "Project")
+		r.Task = t.get(i, "Task")
+		r.Description = t.get(i, "Description")
+		r.Tags = splitTags(t.get(i, "Tags"))
+
+		date, err := parseFirst(t.get(i, "Start Date"), dateLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid start date: %v", err)
+		}
+		r.Date = dateOnly(date)
+
+		start, err := parseFirst(t.get(i, "Start Time"), timeLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid start time: %v", err)
+		}
+		r.StartTime = start.Format("15:04")
+
+		r.Duration, err = ParseDuration(t.get(i, "Duration (decimal)", "Duration (h)"))
+		return err
+	})
+}
+
+// parseHarvestCSV parses a Harvest detailed time report CSV export, Harvest does not export
+// start times unless timestamps are enabled.
+func parseHarvestCSV(data []byte) ([]TrackerRecord, error) {
+	return parseRows(data, func(t *csvTable, i int, r *TrackerRecord) error {
+		r.Client = t.get(i, "Client")
+		r.Project = t.get(i, "Project")
+		r.Task = t.get(i, "Task")
+		r.Description = t.get(i, "Notes")
+
+		date, err := parseFirst(t.get(i, "Date"), dateLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid date: %v", err)
+		}
+		r.Date = dateOnly(date)
+
+		if s := t.get(i, "Started At", "Start Time"); s != "" {
+			start, err := parseFirst(s, timeLayouts...)
+			if err != nil {
+				return fmt.Errorf("invalid start time: %v", err)
+			}
+			r.StartTime = start.Format("15:04")
+		}
+
+		r.Duration, err = ParseDuration(t.get(i, "Hours"))
+		return err
+	})
+}
+
+// named is a JSON value that is either a plain string or an object with a name.
+type named string
+
+func (n *named) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*n = named(s)
+		return nil
+	}
+
+	var o struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+
+	*n = named(o.Name)
+	return nil
+}
+
+func namesToStrings(names []named) []string {
+	var result []string
+	for _, n := range names {
+		result = append(result, string(n))
+	}
+
+	return result
+}
+
+// unwrapJSON returns the array of entries in data, which is either the array itself or an
+// object holding the array in one of the given keys.
+func unwrapJSON(data []byte, keys ...string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err == nil {
+		return items, nil
+	}
+
+	var object map[string]json.RawMessage
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if raw, ok := object[key]; ok {
+			return items, json.Unmarshal(raw, &items)
+		}
+	}
+
+	return nil, fmt.Errorf("expected an array or an object with one of: %v", strings.Join(keys, ", "))
+}
+
+// parseJSONItems decodes each item into a record, collecting errors per entry.
+func parseJSONItems(data []byte, keys []string, parseItem func(raw json.RawMessage, r *TrackerRecord) error) ([]TrackerRecord, error) {
+	items, err := unwrapJSON(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TrackerRecord
+	var errs []error
+
+	for i, raw := range items {
+		r := TrackerRecord{Ref: fmt.Sprintf("entry %d", i+1)}
+		err := parseItem(raw, &r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", r.Ref, err))
+			continue
+		}
+
+		records = append(records, r)
+	}
+
+	return records, errors.Join(errs...)
+}
+
+// parseTogglJSON parses Toggl Track time entries, as exported by the detailed report or the
+// time entries API.
+func parseTogglJSON(data []byte) ([]TrackerRecord, error) {
+	return parseJSONItems(data, []string{"data", "time_entries"}, func(raw json.RawMessage, r *TrackerRecord) error {
+		var e struct {
+			Description string    `json:"description"`
+			Start       time.Time `json:"start"`
+			Duration    float64   `json:"duration"` // seconds
+			Dur         float64   `json:"dur"`      // milliseconds, detailed report
+			Project     string    `json:"project"`
+			ProjectName string    `json:"project_name"`
+			Client      string    `json:"client"`
+			ClientName  string    `json:"client_name"`
+			Task        string    `json:"task"`
+			TaskName    string    `json:"task_name"`
+			Tags        []named   `json:"tags"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		seconds := e.Duration
+		if e.Dur > 0 {
+			seconds = e.Dur / 1000
+		}
+		if seconds <= 0 {
+			return errors.New("time entry is still running")
+		}
+
+		r.Client = firstNonEmpty(e.Client, e.ClientName)
+		r.Project = firstNonEmpty(e.Project, e.ProjectName)
+		r.Task = firstNonEmpty(e.Task, e.TaskName)
+		r.Description = e.Description
+		r.Tags = namesToStrings(e.Tags)
+		r.Date = dateOnly(e.Start)
+		r.StartTime = e.Start.Format("15:04")
+		r.Duration = float32(seconds / 3600)
+
+		return nil
+	})
+}
+
+// parseClockifyJSON parses Clockify time entries, as exported by the detailed report or the
+// time entries API.
+func parseClockifyJSON(data []byte) ([]TrackerRecord, error) {
+	return parseJSONItems(data, []string{"timeentries", "timeEntries"}, func(raw json.RawMessage, r *TrackerRecord) error {
+		var e struct {
+			Description  string  `json:"description"`
+			ProjectName  string  `json:"projectName"`
+			ClientName   string  `json:"clientName"`
+			TaskName     string  `json:"taskName"`
+			Project      *named  `json:"project"`
+			Task         *named  `json:"task"`
+			Tags         []named `json:"tags"`
+			TimeInterval struct {
+				Start    time.Time       `json:"start"`
+				Duration json.RawMessage `json:"duration"`
+			} `json:"timeInterval"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		hours, err := parseClockifyDuration(e.TimeInterval.Duration)
+		if err != nil {
+			return err
+		}
+
+		r.Client = e.ClientName
+		r.Project = e.ProjectName
+		if e.Project != nil && r.Project == "" {
+			r.Project = string(*e.Project)
+		}
+		r.Task = e.TaskName
+		if e.Task != nil && r.Task == "" {
+			r.Task = string(*e.Task)
+		}
+		r.Description = e.Description
+		r.Tags = namesToStrings(e.Tags)
+		r.Date = dateOnly(e.TimeInterval.Start)
+		r.StartTime = e.TimeInterval.Start.Format("15:04")
+		r.Duration = hours
+
+		return nil
+	})
+}
+
+var isoDuration = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// parseClockifyDuration parses a duration given either in seconds or as an ISO 8601 duration (PT1H30M).
+func parseClockifyDuration(raw json.RawMessage) (float32, error) {
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err == nil {
+		return float32(seconds / 3600), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil || s == "" {
+		return 0, errors.New("time entry is still running or has no duration")
+	}
+
+	match := isoDuration.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	h, _ := strconv.ParseFloat(firstNonEmpty(match[1], "0"), 64)
+	m, _ := strconv.ParseFloat(firstNonEmpty(match[2], "0"), 64)
+	sec, _ := strconv.ParseFloat(firstNonEmpty(match[3], "0"), 64)
+
+	return float32(h + m/60 + sec/3600), nil
+}
+
+// parseHarvestJSON parses Harvest time entries as returned by the time entries API.
+func parseHarvestJSON(data []byte) ([]TrackerRecord, error) {
+	return parseJSONItems(data, []string{"time_entries"}, func(raw json.RawMessage, r *TrackerRecord) error {
+		var e struct {
+			SpentDate   string  `json:"spent_date"`
+			Hours       float64 `json:"hours"`
+			Notes       string  `json:"notes"`
+			StartedTime string  `json:"started_time"`
+			Client      named   `json:"client"`
+			Project     named   `json:"project"`
+			Task        named   `json:"task"`
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		date, err := parseFirst(e.SpentDate, dateLayouts...)
+		if err != nil {
+			return fmt.Errorf("invalid spent date: %v", err)
+		}
+
+		if e.StartedTime != "" {
+			start, err := parseFirst(e.StartedTime, timeLayouts...)
+			if err != nil {
+				return fmt.Errorf("invalid started time: %v", err)
+			}
+			r.StartTime = start.Format("15:04")
+		}
+
+		r.Client = string(e.Client)
+		r.Project = string(e.Project)
+		r.Task = string(e.Task)
+		r.Description = e.Notes
+		r.Date = dateOnly(date)
+		r.Duration = float32(e.Hours)
+
+		return nil
+	})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,110 @@
+package at
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMapping = `
+rules:
+  - project: Internal
+    tag: meeting
+    id: 266016
+  - description: '#(\d+)'
+    isTicket: true
+  - client: Acme
+    id: 266017
+    isTicket: true
+`
+
+func readTracker(t *testing.T, format, data string) []RequestEntry {
+	t.Helper()
+
+	mapping, err := ParseMapping([]byte(testMapping))
+	if err != nil {
+		t.Fatalf("could not parse mapping: %v", err)
+	}
+
+	entries, err := ReadRequestEntries(strings.NewReader(data), format, ReadOptions{Mapping: mapping})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return entries
+}
+
+func assertEntry(t *testing.T, e RequestEntry, id int, isTicket bool, date time.Time, start string, duration float32) {
+	t.Helper()
+
+	if e.Id != id || e.IsTicket != isTicket || !e.Date.Equal(date) || e.StartTime != start || e.Duration != duration {
+		t.Errorf("expected %d/%v/%v/%v/%v but got %d/%v/%v/%v/%v",
+			id, isTicket, date.Format("2006-01-02"), start, duration,
+			e.Id, e.IsTicket, e.Date.Format("2006-01-02"), e.StartTime, e.Duration)
+	}
+}
+
+func TestReadToggl(t *testing.T) {
+	csv := "User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()\n" +
+		"Jo,jo@example.com,,Internal,,Stand-up,No,2023-09-15,10:30:00,2023-09-15,10:45:00,00:15:00,\"meeting, daily\",\n" +
+		"Jo,jo@example.com,,Support,,Printer #123,Yes,2023-09-15,11:00:00,2023-09-15,12:30:00,01:30:00,,\n"
+
+	entries := readTracker(t, "toggl", csv)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %d", len(entries))
+	}
+	assertEntry(t, entries[0], 266016, false, time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC), "10:30", 0.25)
+	assertEntry(t, entries[1], 123, true, time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC), "11:00", 1.5)
+
+	json := `[{"description":"Printer #124","start":"2023-09-16T08:15:00+02:00","dur":2700000,"project":"Support","tags":[]}]`
+	entries = readTracker(t, "toggl", json)
+	assertEntry(t, entries[0], 124, true, time.Date(2023, 9, 16, 0, 0, 0, 0, time.UTC), "08:15", 0.75)
+}
+
+func TestReadClockify(t *testing.T) {
+	csv := "Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)\n" +
+		"Website,Acme,Design review,,Jo,,jo@example.com,,Yes,09/15/2023,02:00:00 PM,09/15/2023,03:00:00 PM,01:00:00,1.00\n"
+
+	entries := readTracker(t, "clockify", csv)
+	assertEntry(t, entries[0], 266017, true, time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC), "14:00", 1)
+
+	json := `{"timeentries":[{"description":"Weekly sync","projectName":"Internal","tags":[{"name":"Meeting"}],
+		"timeInterval":{"start":"2023-09-18T09:00:00Z","duration":"PT1H30M"}}]}`
+	entries = readTracker(t, "clockify", json)
+	assertEntry(t, entries[0], 266016, false, time.Date(2023, 9, 18, 0, 0, 0, 0, time.UTC), "09:00", 1.5)
+}
+
+func TestReadHarvest(t *testing.T) {
+	csv := "Date,Client,Project,Project Code,Task,Notes,Hours,Hours Rounded,Billable?\n" +
+		"2023-09-15,Acme,Website,,Design,Mock-ups,2.5,2.5,Yes\n"
+
+	entries := readTracker(t, "harvest", csv)
+	assertEntry(t, entries[0], 266017, true, time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC), "", 2.5)
+
+	json := `{"time_entries":[{"spent_date":"2023-09-19","hours":0.5,"notes":"Printer #125",
+		"started_time":"9:30am","client":{"name":"Other"},"project":{"name":"Support"},"task":{"name":"Fix"}}]}`
+	entries = readTracker(t, "harvest", json)
+	assertEntry(t, entries[0], 125, true, time.Date(2023, 9, 19, 0, 0, 0, 0, time.UTC), "09:30", 0.5)
+}
+
+func TestReadTrackerUnmapped(t *testing.T) {
+	mapping, _ := ParseMapping([]byte(testMapping))
+	csv := "Date,Client,Project,Task,Notes,Hours\n2023-09-15,Other,Unknown,,Lunch,1\n"
+
+	_, err := ReadRequestEntries(strings.NewReader(csv), "harvest", ReadOptions{Mapping: mapping})
+	if err == nil || !strings.Contains(err.Error(), "row 2: no mapping rule") {
+		t.Errorf("expected an unmapped row error, got: %v", err)
+	}
+
+	_, err = ReadRequestEntries(strings.NewReader(csv), "harvest", ReadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "mapping file is required") {
+		t.Errorf("expected a missing mapping error, got: %v", err)
+	}
+}
+
+func TestParseMappingRequiresId(t *testing.T) {
+	_, err := ParseMapping([]byte("rules:\n  - project: Internal\n"))
+	if err == nil {
+		t.Error("expected an error for a rule without an id")
+	}
+}
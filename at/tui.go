@@ -2,16 +2,18 @@ package at
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
 )
 
-// PrintSummary prints a summary table of the time entries.
+// PrintSummary prints a summary table of the time entries to stdout, it is printed regardless
+// of the log level.
 func (entries TimeEntries) PrintSummary() {
-	// Initialise a table writer using the log's writer.
 	entries.SortByDateAndTime()
-	table := tablewriter.NewWriter(log.Writer())
+	table := tablewriter.NewWriter(os.Stdout)
 	// table.SetAutoWrapText(false)
 	// Set the table header.
 	table.Header([]string{"#", "AT-ID", "T", "Date", "Start", "Hrs", "EXS", "SAV", "ERR", "Project"})
@@ -44,6 +46,47 @@ func (entries TimeEntries) PrintSummary() {
 	// Set the table footer to show the total duration.
 	table.Footer([]string{"", "", "", "Total", fmt.Sprintf("%.2f", total), "", "", "", "", "EOF"})
 	table.Render()
+
+	// List the full error text below the table
+	for i, e := range entries {
+		if e.Error != nil {
+			fmt.Printf("#%d %d %v: %v\n", i+1, e.Id, e.DateStr, e.Error)
+			for _, f := range e.Diagnostics {
+				fmt.Printf("    %v\n", f)
+			}
+		}
+	}
+
+	printMerged(os.Stdout, entries)
+}
+
+// printMerged lists the entries of a task on the same day that were captured as one.
+func printMerged(w io.Writer, entries TimeEntries) {
+	type dayKey struct {
+		id   int
+		date string
+	}
+
+	var days []dayKey
+	numbers := map[dayKey][]string{}
+	hours := map[dayKey]float32{}
+
+	for i, e := range entries {
+		if e.Merged == 0 {
+			continue
+		}
+
+		k := dayKey{e.Id, e.DateStr}
+		if _, ok := numbers[k]; !ok {
+			days = append(days, k)
+		}
+		numbers[k] = append(numbers[k], fmt.Sprintf("#%d", i+1))
+		hours[k] += e.Duration
+	}
+
+	for _, k := range days {
+		fmt.Fprintf(w, "Merged %v of task %d on %v into one entry of %.2f hours\n", strings.Join(numbers[k], ", "), k.id, k.date, hours[k])
+	}
 }
 
 // toPS converts a boolean indicating if an entry is a ticket to either "S" or "P".
@@ -64,3 +107,127 @@ func trim(s string, l int) string {
 	}
 	return string(asRunes[:l])
 }
+
+// PrintRuns prints a table of runs from the run history.
+func PrintRuns(w io.Writer, runs []*Run) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Run", "Started", "Source", "Entries", "SAV", "EXS", "ERR", "PND"})
+
+	for _, r := range runs {
+		table.Append([]string{
+			r.Id,
+			r.CreatedAt.Format("2006-01-02 15:04"),
+			trim(r.Source, 45),
+			fmt.Sprintf("%d", len(r.Entries)),
+			fmt.Sprintf("%d", r.Count(StatusSaved)),
+			fmt.Sprintf("%d", r.Count(StatusExists)),
+			fmt.Sprintf("%d", r.Count(StatusFailed)),
+			fmt.Sprintf("%d", r.Count(StatusPending)),
+		})
+	}
+
+	table.Render()
+}
+
+// PrintTimesheets prints a table of the status of timesheets.
+func PrintTimesheets(w io.Writer, sheets []Timesheet) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Week", "Status", "Error"})
+
+	for _, s := range sheets {
+		msg := ""
+		if s.Err != nil {
+			msg = s.Err.Error()
+		}
+
+		table.Append([]string{s.Week.Format("2006-01-02"), string(s.Status), msg})
+	}
+
+	table.Render()
+}
+
+// PrintHistory prints a table of entries from the run history.
+func PrintHistory(w io.Writer, entries []HistoryEntry) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Run", "AT-ID", "T", "Date", "Start", "Hrs", "Status", "Summary"})
+
+	for _, e := range entries {
+		table.Append([]string{
+			e.RunId,
+			fmt.Sprintf("%d", e.Id),
+			toPS(e.IsTicket),
+			e.Date.Format("2006-01-02"),
+			e.StartTime,
+			fmt.Sprintf("%.2f", e.Duration),
+			string(e.Status),
+			trim(strings.ReplaceAll(e.Summary, "\n", " "), 45),
+		})
+	}
+
+	table.Render()
+
+	for _, e := range entries {
+		if e.Error != "" {
+			fmt.Fprintf(w, "%v %d %v: %v\n", e.RunId, e.Id, e.Date.Format("2006-01-02"), e.Error)
+		}
+	}
+}
+
+// PrintDiff prints a table of the differences, entries that are the same are only printed if all is set.
+func PrintDiff(w io.Writer, diffs []DiffEntry, all bool) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Status", "AT-ID", "T", "Date", "Start", "File", "AutoTask", "Summary", "Differences"})
+
+	hours := func(te *TimeEntry) string {
+		if te == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.2f", te.Duration)
+	}
+
+	for _, d := range diffs {
+		if d.Kind == DiffSame && !all {
+			continue
+		}
+
+		te := d.entry()
+		table.Append([]string{
+			string(d.Kind),
+			fmt.Sprintf("%d", te.Id),
+			toPS(te.IsTicket),
+			te.Date.Format("2006-01-02"),
+			te.StartTimeStr,
+			hours(d.File),
+			hours(d.AutoTask),
+			trim(strings.ReplaceAll(te.Summary, "\n", " "), 40),
+			strings.Join(d.Differences, ", "),
+		})
+	}
+
+	table.Render()
+}
+
+// PrintUpdate prints how an entry overwrites the user's existing entry in AutoTask, the duration
+// and summary notes before and after.
+func PrintUpdate(w io.Writer, te *TimeEntry) {
+	before := te.Existing
+	if before == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "Update %v %d on %v %v\n", toTicketOrTask(te.IsTicket), te.Id, te.DateStr, te.StartTimeStr)
+
+	if fmt.Sprintf("%.2f", before.Duration) != fmt.Sprintf("%.2f", te.Duration) {
+		fmt.Fprintf(w, "  duration: %.2f -> %.2f\n", before.Duration, te.Duration)
+	}
+
+	if strings.TrimSpace(before.Summary) != strings.TrimSpace(te.Summary) {
+		fmt.Fprintln(w, "  summary:")
+		for _, line := range strings.Split(strings.TrimSpace(before.Summary), "\n") {
+			fmt.Fprintf(w, "  - %v\n", line)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(te.Summary), "\n") {
+			fmt.Fprintf(w, "  + %v\n", line)
+		}
+	}
+}
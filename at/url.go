@@ -1,7 +1,7 @@
 package at
 
 import (
-	"log"
+	"log/slog"
 	"net/url"
 )
 
@@ -12,7 +12,7 @@ func GetBaseURL(rawURL string) string {
 	u, err := url.Parse(rawURL)
 	if err != nil {
 		// Log the error if the URL is invalid.
-		log.Printf("could not parse url: %v\n", err)
+		slog.Error("could not parse url", "url", rawURL, "error", err)
 		return ""
 	}
 
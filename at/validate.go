@@ -0,0 +1,287 @@
+package at
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationRules configures the checks performed by Validate. Limits set to zero are not checked.
+type ValidationRules struct {
+	MinDuration     float32   // Minimum duration of an entry in hours, entries must always be longer than zero.
+	MaxDuration     float32   // Maximum duration of an entry in hours.
+	Granularity     int       // Durations must be a multiple of this number of minutes, e.g. 15.
+	MaxHoursPerDay  float32   // Maximum total hours captured on a day.
+	CheckOverlaps   bool      // If true, entries on the same day may not overlap.
+	MaxDaysInPast   int       // Entries may not be dated more than this number of days in the past.
+	MaxDaysInFuture int       // Entries may not be dated more than this number of days in the future.
+	Now             time.Time // Reference date for the date window, defaults to the current time.
+	Allowed         AllowedValues
+}
+
+// AllowedValues lists the values accepted for the optional fields of an entry, the values are
+// not case-sensitive. An empty list accepts any value.
+type AllowedValues struct {
+	Roles          []string
+	WorkTypes      []string
+	BillingCodes   []string
+	TicketStatuses []string
+}
+
+// allows reports if a value is in the list, any value is allowed by an empty list.
+func allows(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultValidationRules returns the rules used when nothing is configured.
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		MaxDuration:     24,
+		MaxHoursPerDay:  24,
+		CheckOverlaps:   true,
+		MaxDaysInFuture: 7,
+	}
+}
+
+// Violation describes an entry that breaks a validation rule.
+type Violation struct {
+	Index   int    // Position of the entry in the input, starting at 1.
+	Id      int    // AutoTask ID of the entry.
+	Field   string // Field that is invalid, in the JSON naming of RequestEntry.
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("entry %d (id %d): %v: %v", v.Index, v.Id, v.Field, v.Message)
+}
+
+// Violations is a list of rule violations, it can be returned as an error.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	lines := make([]string, len(v))
+	for i, violation := range v {
+		lines[i] = violation.String()
+	}
+
+	return fmt.Sprintf("%d validation errors:\n%v", len(v), strings.Join(lines, "\n"))
+}
+
+var startTimeRegEx = regexp.MustCompile(`^([01]?\d|2[0-3]):([0-5]\d)$`)
+
+// Validate checks every entry against the rules and returns all violations ordered by the
+// position of the entries. The entries must still be in the order they were read in.
+func (entries TimeEntries) Validate(rules ValidationRules) Violations {
+	now := rules.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var result Violations
+	add := func(i int, e *TimeEntry, field, format string, args ...interface{}) {
+		result = append(result, Violation{Index: i + 1, Id: e.Id, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, e := range entries {
+		if e.Id <= 0 {
+			add(i, e, "id", "is required")
+		}
+
+		if strings.TrimSpace(e.Summary) == "" {
+			add(i, e, "summary", "is required")
+		}
+
+		if e.StartTimeStr == "" {
+			if e.IsTicket {
+				add(i, e, "startTime", "is required for tickets")
+			}
+		} else if !startTimeRegEx.MatchString(e.StartTimeStr) {
+			add(i, e, "startTime", "%q is not a valid time, expected HH:MM", e.StartTimeStr)
+		}
+
+		validateDuration(rules, func(format string, args ...interface{}) { add(i, e, "duration", format, args...) }, e.Duration)
+
+		if e.EndTimeStr != "" {
+			end, ok := minutesSinceMidnight(e.EndTimeStr)
+			switch {
+			case !e.IsTicket:
+				add(i, e, "endTime", "is only supported for tickets")
+			case !ok:
+				add(i, e, "endTime", "%q is not a valid time, expected HH:MM", e.EndTimeStr)
+			default:
+				start, ok := minutesSinceMidnight(e.StartTimeStr)
+				if ok && end-start < int(math.Round(float64(e.Duration)*60)) {
+					add(i, e, "endTime", "%v is earlier than the start time plus the duration", e.EndTimeStr)
+				}
+			}
+		}
+
+		if e.TicketStatus != "" && !e.IsTicket {
+			add(i, e, "ticketStatus", "is only supported for tickets")
+		}
+
+		optional := []struct {
+			field, value string
+			allowed      []string
+		}{
+			{"role", e.Role, rules.Allowed.Roles},
+			{"workType", e.WorkType, rules.Allowed.WorkTypes},
+			{"billingCode", e.BillingCode, rules.Allowed.BillingCodes},
+			{"ticketStatus", e.TicketStatus, rules.Allowed.TicketStatuses},
+		}
+		for _, o := range optional {
+			if o.value != "" && !allows(o.allowed, o.value) {
+				add(i, e, o.field, "%q is not allowed, use one of: %v", o.value, strings.Join(o.allowed, ", "))
+			}
+		}
+
+		if e.Date.IsZero() {
+			add(i, e, "date", "is required")
+		} else {
+			date := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, time.UTC)
+			days := int(date.Sub(today).Hours() / 24)
+
+			if rules.MaxDaysInPast > 0 && -days > rules.MaxDaysInPast {
+				add(i, e, "date", "%v is more than %d days in the past", date.Format("2006-01-02"), rules.MaxDaysInPast)
+			}
+			if rules.MaxDaysInFuture > 0 && days > rules.MaxDaysInFuture {
+				add(i, e, "date", "%v is more than %d days in the future", date.Format("2006-01-02"), rules.MaxDaysInFuture)
+			}
+		}
+	}
+
+	result = append(result, entries.validateDays(rules)...)
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+
+	return result
+}
+
+// validateDuration checks a single duration against the duration rules.
+func validateDuration(rules ValidationRules, add func(format string, args ...interface{}), duration float32) {
+	if duration <= 0 {
+		add("must be greater than zero")
+		return
+	}
+
+	if rules.MinDuration > 0 && duration < rules.MinDuration {
+		add("%.2f is less than the minimum of %.2f hours", duration, rules.MinDuration)
+	}
+
+	if rules.MaxDuration > 0 && duration > rules.MaxDuration {
+		add("%.2f is more than the maximum of %.2f hours", duration, rules.MaxDuration)
+	}
+
+	if rules.Granularity > 0 {
+		minutes := float64(duration) * 60
+		rounded := math.Round(minutes)
+		if math.Abs(minutes-rounded) > 0.01 || int(rounded)%rules.Granularity != 0 {
+			add("%.2f is not a multiple of %d minutes", duration, rules.Granularity)
+		}
+	}
+}
+
+// validateDays checks the total hours per day and overlapping entries within a day.
+func (entries TimeEntries) validateDays(rules ValidationRules) Violations {
+	var result Violations
+
+	days := map[string][]int{}
+	var order []string
+	for i, e := range entries {
+		key := e.Date.Format("2006-01-02")
+		if _, ok := days[key]; !ok {
+			order = append(order, key)
+		}
+		days[key] = append(days[key], i)
+	}
+
+	for _, day := range order {
+		indexes := days[day]
+
+		if rules.MaxHoursPerDay > 0 {
+			var total float32
+			for _, i := range indexes {
+				total += entries[i].Duration
+			}
+
+			if total > rules.MaxHoursPerDay {
+				last := indexes[len(indexes)-1]
+				result = append(result, Violation{
+					Index:   last + 1,
+					Id:      entries[last].Id,
+					Field:   "duration",
+					Message: fmt.Sprintf("%.2f hours on %v is more than the maximum of %.2f hours per day", total, day, rules.MaxHoursPerDay),
+				})
+			}
+		}
+
+		if rules.CheckOverlaps {
+			result = append(result, entries.overlaps(indexes)...)
+		}
+	}
+
+	return result
+}
+
+// overlaps reports entries of which the start and end times overlap an earlier entry of the same day.
+func (entries TimeEntries) overlaps(indexes []int) Violations {
+	var result Violations
+
+	type interval struct {
+		index      int
+		start, end int // minutes since midnight
+	}
+
+	var intervals []interval
+	for _, i := range indexes {
+		start, ok := minutesSinceMidnight(entries[i].StartTimeStr)
+		if !ok {
+			continue
+		}
+
+		end := start + int(math.Round(float64(entries[i].Duration)*60))
+		for _, other := range intervals {
+			if start < other.end && other.start < end {
+				result = append(result, Violation{
+					Index:   i + 1,
+					Id:      entries[i].Id,
+					Field:   "startTime",
+					Message: fmt.Sprintf("overlaps with entry %d on %v", other.index+1, entries[i].Date.Format("2006-01-02")),
+				})
+				break
+			}
+		}
+
+		intervals = append(intervals, interval{index: i, start: start, end: end})
+	}
+
+	return result
+}
+
+// minutesSinceMidnight converts a HH:MM start time to minutes.
+func minutesSinceMidnight(s string) (int, bool) {
+	match := startTimeRegEx.FindStringSubmatch(s)
+	if match == nil {
+		return 0, false
+	}
+
+	h, _ := strconv.Atoi(match[1])
+	m, _ := strconv.Atoi(match[2])
+
+	return h*60 + m, true
+}
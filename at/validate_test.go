@@ -0,0 +1,114 @@
+package at
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	entry := func(id int, isTicket bool, date time.Time, start string, duration float32, summary string) *TimeEntry {
+		return NewEntry(id, isTicket, date, start, duration, summary, "", "2006/01/02")
+	}
+
+	entries := TimeEntries{
+		entry(1, true, day, "09:00", 1, "valid"),
+		entry(0, true, day, "25:99", 0, ""),
+		entry(3, true, day, "09:30", 0.5, "overlaps the first"),
+		entry(4, false, day.AddDate(0, 0, 30), "", 0.3, "in the future, not a quarter"),
+		entry(5, true, day.AddDate(0, 0, 1), "", 1, "no start time"),
+	}
+
+	rules := DefaultValidationRules()
+	rules.Granularity = 15
+	rules.Now = day
+
+	violations := entries.Validate(rules)
+
+	expected := []string{
+		"entry 2 (id 0): id: is required",
+		"entry 2 (id 0): summary: is required",
+		"entry 2 (id 0): startTime: \"25:99\" is not a valid time, expected HH:MM",
+		"entry 2 (id 0): duration: must be greater than zero",
+		"entry 3 (id 3): startTime: overlaps with entry 1 on 2023-09-15",
+		"entry 4 (id 4): duration: 0.30 is not a multiple of 15 minutes",
+		"entry 4 (id 4): date: 2023-10-15 is more than 7 days in the future",
+		"entry 5 (id 5): startTime: is required for tickets",
+	}
+
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %d violations but got %d:\n%v", len(expected), len(violations), violations.Error())
+	}
+
+	for i, v := range violations {
+		if v.String() != expected[i] {
+			t.Errorf("violation %d: expected %q but got %q", i, expected[i], v.String())
+		}
+	}
+}
+
+func TestValidateMaxHoursPerDay(t *testing.T) {
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	entries := TimeEntries{
+		NewEntry(1, false, day, "", 6, "morning", "", "2006/01/02"),
+		NewEntry(2, false, day, "", 4, "afternoon", "", "2006/01/02"),
+	}
+
+	rules := ValidationRules{MaxHoursPerDay: 8, Now: day}
+	violations := entries.Validate(rules)
+
+	if len(violations) != 1 || violations[0].Index != 2 || !strings.Contains(violations[0].Message, "10.00 hours on 2023-09-15") {
+		t.Errorf("expected a max hours per day violation, got: %v", violations)
+	}
+}
+
+func TestValidateOptionalFields(t *testing.T) {
+	day := time.Date(2023, time.September, 15, 0, 0, 0, 0, time.UTC)
+	entry := func(id int, isTicket bool, start string, end string) *TimeEntry {
+		e := NewEntry(id, isTicket, day, start, 1, "work", "", "2006/01/02")
+		e.EndTimeStr = end
+		return e
+	}
+
+	entries := TimeEntries{
+		entry(1, true, "08:00", "09:15"),
+		entry(2, true, "10:00", "10:30"),
+		entry(3, true, "11:00", "noon"),
+		entry(4, false, "", "13:00"),
+	}
+	entries[0].Role = "engineer"
+	entries[0].TicketStatus = "Complete"
+	entries[1].WorkType = "Travel"
+	entries[3].BillingCode = "Internal"
+	entries[3].TicketStatus = "Complete"
+
+	rules := ValidationRules{
+		Now: day,
+		Allowed: AllowedValues{
+			Roles:          []string{"Engineer", "Consultant"},
+			WorkTypes:      []string{"Remote", "Onsite"},
+			TicketStatuses: []string{"In Progress", "Complete"},
+		},
+	}
+
+	violations := entries.Validate(rules)
+
+	expected := []string{
+		"entry 2 (id 2): endTime: 10:30 is earlier than the start time plus the duration",
+		"entry 2 (id 2): workType: \"Travel\" is not allowed, use one of: Remote, Onsite",
+		"entry 3 (id 3): endTime: \"noon\" is not a valid time, expected HH:MM",
+		"entry 4 (id 4): endTime: is only supported for tickets",
+		"entry 4 (id 4): ticketStatus: is only supported for tickets",
+	}
+
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %d violations but got %d:\n%v", len(expected), len(violations), violations.Error())
+	}
+
+	for i, v := range violations {
+		if v.String() != expected[i] {
+			t.Errorf("violation %d: expected %q but got %q", i, expected[i], v.String())
+		}
+	}
+}
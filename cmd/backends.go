@@ -0,0 +1,7 @@
+package cmd
+
+import (
+	// Register the built-in backends
+	_ "github.com/philipf/gt-at/apiplugin"
+	_ "github.com/philipf/gt-at/pwplugin"
+)
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// Options of the diff command.
+	diffAll         bool
+	diffFileIdsOnly bool
+)
+
+// diffCmd compares a file of time entries with the entries in AutoTask
+var diffCmd = &cobra.Command{
+	Use:   "diff [file]",
+	Short: "Compare a file of time entries with AutoTask",
+	Long: `Compare a file of time entries with the entries AutoTask holds per ticket or task and day, over the dates of the file.
+Entries that are missing from AutoTask, present with a different start time, duration or summary, and present in AutoTask
+but not in the file are printed, the exit code is non-zero if any are found`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		if len(args) > 0 {
+			importFile = args[0]
+		}
+		if importFrom != "" {
+			importFormat = importFrom
+		}
+
+		opts := getLoadOptions()
+
+		readOpts, err := getReadOptions(opts.DateFormat)
+		cobra.CheckErr(err)
+
+		entries, err := readFile(importFile, importFormat, readOpts)
+		cobra.CheckErr(err)
+
+		if len(entries) == 0 {
+			slog.Info("The file has no entries")
+			return
+		}
+
+		autoTasker, err := at.NewBackend(opts.Backend, viper.GetStringMap(opts.Backend))
+		cobra.CheckErr(err)
+
+		fetcher, ok := autoTasker.(at.Fetcher)
+		if !ok {
+			cobra.CheckErr(fmt.Errorf("the %v backend can't read time entries, set %v to %v", opts.Backend, settingImportBackend, at.DefaultBackend))
+		}
+
+		actual, err := fetcher.FetchTimes(cmd.Context(), getDiffQuery(entries), opts)
+		cobra.CheckErr(err)
+
+		diffs := at.Diff(entries, actual)
+		at.PrintDiff(os.Stdout, diffs, diffAll)
+
+		if n := at.CountDifferences(diffs); n > 0 {
+			cobra.CheckErr(fmt.Errorf("%d of %d entries differ", n, len(diffs)))
+		}
+
+		slog.Info("AutoTask holds the entries of the file", "entries", len(entries))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&importFile, "filename", "f", "/tmp/time.json", "name of the file that should be compared, use - to read from stdin")
+	diffCmd.Flags().StringVar(&importFormat, "format", "", "format of the file (json|jsonl|csv), by default it is derived from the file extension")
+	diffCmd.Flags().StringVar(&importFrom, "from", "", "compare the native export of a time tracker (toggl|clockify|harvest)")
+	diffCmd.Flags().StringVar(&mappingFile, "mapping", "", "mapping file translating time tracker projects, tags and descriptions to AutoTask IDs")
+	diffCmd.Flags().BoolVar(&diffAll, "all", false, "also print the entries that are the same")
+	diffCmd.Flags().BoolVar(&diffFileIdsOnly, "file-ids-only", false, "only read the tickets and tasks of the file, instead of those on your timesheets")
+	diffCmd.MarkFlagsMutuallyExclusive("format", "from")
+}
+
+// getDiffQuery selects the entries in AutoTask to compare with, over the dates of the file.
+func getDiffQuery(entries at.TimeEntries) at.FetchQuery {
+	query := at.FetchQuery{From: entries[0].Date, To: entries[0].Date}
+	for _, te := range entries {
+		if te.Date.Before(query.From) {
+			query.From = te.Date
+		}
+		if te.Date.After(query.To) {
+			query.To = te.Date
+		}
+	}
+
+	if diffFileIdsOnly {
+		tickets, tasks := entries.SplitEntries()
+		query.TicketIds = tickets.DistinctIds()
+		query.TaskIds = tasks.DistinctIds()
+	}
+
+	return query
+}
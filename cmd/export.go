@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// Date range, filters and output of the export command.
+	exportFrom    string
+	exportTo      string
+	exportTickets []int
+	exportTasks   []int
+	exportOutput  string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports your time entries from AutoTask",
+	Long: `Exports your time entries from AutoTask in the import file format, e.g. to reconcile them with your time tracker:
+gt-at export --from 2023-09-11 --to 2023-09-15 -o week.json
+
+The tickets and tasks are found on your timesheets of the date range, unless they are given with --ticket and --task`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		opts := getLoadOptions()
+
+		query, err := getFetchQuery()
+		cobra.CheckErr(err)
+
+		autoTasker, err := at.NewBackend(opts.Backend, viper.GetStringMap(opts.Backend))
+		cobra.CheckErr(err)
+
+		fetcher, ok := autoTasker.(at.Fetcher)
+		if !ok {
+			cobra.CheckErr(fmt.Errorf("the %v backend can't export time entries, set %v to %v", opts.Backend, settingImportBackend, at.DefaultBackend))
+		}
+
+		entries, err := fetcher.FetchTimes(cmd.Context(), query, opts)
+		cobra.CheckErr(err)
+
+		out := os.Stdout
+		if exportOutput != "" {
+			out, err = os.Create(exportOutput)
+			cobra.CheckErr(err)
+			defer out.Close()
+		}
+
+		cobra.CheckErr(at.WriteRequestFile(out, entries))
+
+		if exportOutput != "" {
+			slog.Info("Exported time entries", "entries", len(entries), "file", exportOutput)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportFrom, "from", "", "first day to export (YYYY-MM-DD)")
+	exportCmd.Flags().StringVar(&exportTo, "to", "", "last day to export (YYYY-MM-DD), defaults to the from date")
+	exportCmd.Flags().IntSliceVar(&exportTickets, "ticket", nil, "only export the entries of this ticket, can be repeated")
+	exportCmd.Flags().IntSliceVar(&exportTasks, "task", nil, "only export the entries of this task, can be repeated")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to export to, by default the entries are written to stdout")
+	exportCmd.MarkFlagRequired("from")
+}
+
+// getFetchQuery builds the query from the export flags.
+func getFetchQuery() (at.FetchQuery, error) {
+	query := at.FetchQuery{TicketIds: exportTickets, TaskIds: exportTasks}
+
+	var err error
+	query.From, err = time.Parse("2006-01-02", exportFrom)
+	if err != nil {
+		return query, fmt.Errorf("invalid from date, expected YYYY-MM-DD: %v", err)
+	}
+
+	query.To = query.From
+	if exportTo != "" {
+		query.To, err = time.Parse("2006-01-02", exportTo)
+		if err != nil {
+			return query, fmt.Errorf("invalid to date, expected YYYY-MM-DD: %v", err)
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/philipf/gt-at/apiplugin"
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Filters and output for the history export command.
+	historyId     int
+	historyFrom   string
+	historyTo     string
+	historyStatus string
+	historyOutput string
+	historyFormat string
+)
+
+// historyCmd groups the commands to query the run history
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Query the history of imports",
+	Long:  `Every import is recorded in a local run history, these commands list, show and export it`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all runs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runs, err := listRuns()
+		cobra.CheckErr(err)
+
+		at.PrintRuns(os.Stdout, runs)
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <run>",
+	Short: "Shows the options and entries of a run",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := at.DefaultRunStore()
+		cobra.CheckErr(err)
+
+		run, err := store.Load(args[0])
+		cobra.CheckErr(err)
+
+		printRun(run)
+	},
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the entries of all runs",
+	Long:  `Exports the entries of all runs as JSON or CSV, e.g. to check if an entry has already been imported: gt-at history export --id 266016 --from 2023-09-12 --to 2023-09-12`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := getHistoryFilter()
+		cobra.CheckErr(err)
+
+		runs, err := listRuns()
+		cobra.CheckErr(err)
+
+		entries := at.History(runs, filter)
+
+		out := os.Stdout
+		if historyOutput != "" {
+			out, err = os.Create(historyOutput)
+			cobra.CheckErr(err)
+			defer out.Close()
+		}
+
+		if historyFormat == "table" {
+			at.PrintHistory(out, entries)
+			return
+		}
+
+		cobra.CheckErr(at.WriteHistory(out, entries, historyFormat))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyListCmd, historyShowCmd, historyExportCmd)
+
+	historyExportCmd.Flags().IntVar(&historyId, "id", 0, "only export entries for this ticket or task ID")
+	historyExportCmd.Flags().StringVar(&historyFrom, "from", "", "only export entries on or after this date (YYYY-MM-DD)")
+	historyExportCmd.Flags().StringVar(&historyTo, "to", "", "only export entries on or before this date (YYYY-MM-DD)")
+	historyExportCmd.Flags().StringVar(&historyStatus, "status", "", "only export entries with this status (saved|exists|failed|pending)")
+	historyExportCmd.Flags().StringVarP(&historyOutput, "output", "o", "", "file to export to, by default the entries are written to stdout")
+	historyExportCmd.Flags().StringVar(&historyFormat, "format", "json", "format of the export (json|csv|table)")
+}
+
+// listRuns loads all runs from the run history.
+func listRuns() ([]*at.Run, error) {
+	store, err := at.DefaultRunStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.List()
+}
+
+// getHistoryFilter builds the filter from the export flags.
+func getHistoryFilter() (at.HistoryFilter, error) {
+	filter := at.HistoryFilter{
+		Id:     historyId,
+		Status: at.EntryStatus(historyStatus),
+	}
+
+	var err error
+	if historyFrom != "" {
+		filter.From, err = time.Parse("2006-01-02", historyFrom)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date, expected YYYY-MM-DD: %v", err)
+		}
+	}
+
+	if historyTo != "" {
+		filter.To, err = time.Parse("2006-01-02", historyTo)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date, expected YYYY-MM-DD: %v", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// printRun prints the details of a single run.
+func printRun(run *at.Run) {
+	fmt.Printf("Run:         %v\n", run.Id)
+	fmt.Printf("Started:     %v\n", run.CreatedAt.Format(time.RFC1123))
+	if run.FinishedAt != nil {
+		fmt.Printf("Finished:    %v\n", run.FinishedAt.Format(time.RFC1123))
+	}
+	fmt.Printf("Source:      %v\n", run.Source)
+	if run.ResumedFrom != "" {
+		fmt.Printf("Resumed:     %v\n", run.ResumedFrom)
+	}
+	fmt.Printf("Username:    %v\n", run.Options.Username)
+	if run.Options.Backend == apiplugin.BackendName {
+		fmt.Printf("Backend:     %v\n", run.Options.Backend)
+	} else {
+		fmt.Printf("Browser:     %v (headless: %v)\n", run.Options.BrowserType, run.Options.Headless)
+	}
+	fmt.Printf("Fresh login: %v\n", run.Options.FreshLogin)
+	if run.Error != "" {
+		fmt.Printf("Error:       %v\n", run.Error)
+	}
+	if run.Diagnostics != "" {
+		fmt.Printf("Diagnostics: %v\n", run.Diagnostics)
+	}
+
+	at.PrintHistory(os.Stdout, at.History([]*at.Run{run}, at.HistoryFilter{}))
+}
@@ -1,30 +1,59 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/philipf/gt-at/at"
-	"github.com/philipf/gt-at/pwplugin"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	// Filename for JSON import and flag to indicate if only a report is required.
-	jsonfile   string
-	reportOnly bool
+	// Filename and format for import and flag to indicate if only a report is required.
+	importFile     string
+	importFormat   string
+	importFrom     string
+	mappingFile    string
+	reportOnly     bool
+	freshLogin     bool
+	skipValidation bool
+	reportFile     string
+	reportFormat   string
+	resumeRunId    string
+	retryFailed    bool
+	duplicates     string
+	embedMarkers   bool
+	backend        string
+	diagnostics    bool
+	trace          bool
+	submitAfter    bool
+	update         bool
+	assumeYes      bool
+	sameDayTasks   string
 )
 
 // importCmd represents the import command for Cobra
 var importCmd = &cobra.Command{
-	Use:   "import",
+	Use:   "import [file]",
 	Short: "Import a file of time entries into AutoTask",
-	Long:  `Import a file of time entries into AutoTask`,
+	Long:  `Import a file of time entries into AutoTask, the file can be given as an argument or with --filename`,
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		isConfigured()
-		err := load(jsonfile)
+		if len(args) > 0 {
+			importFile = args[0]
+		}
+		if importFrom != "" {
+			importFormat = importFrom
+		}
+		err := load(cmd.Context(), importFile)
 		cobra.CheckErr(err)
 	},
 }
@@ -33,36 +62,224 @@ func init() {
 	rootCmd.AddCommand(importCmd)
 
 	// Flags for the import command.
-	importCmd.Flags().StringVarP(&jsonfile, "filename", "f", "/tmp/time.json", "name of json file that should be imported")
+	importCmd.Flags().StringVarP(&importFile, "filename", "f", "/tmp/time.json", "name of the file that should be imported, use - to read from stdin")
+	importCmd.Flags().StringVar(&importFormat, "format", "", "format of the file (json|jsonl|csv), by default it is derived from the file extension")
+	importCmd.Flags().StringVar(&importFrom, "from", "", "import the native export of a time tracker (toggl|clockify|harvest)")
+	importCmd.Flags().StringVar(&mappingFile, "mapping", "", "mapping file translating time tracker projects, tags and descriptions to AutoTask IDs")
+	importCmd.MarkFlagsMutuallyExclusive("format", "from")
 	importCmd.Flags().BoolVarP(&reportOnly, "reportOnly", "r", false, "print a summary of the time entries, but doesn't import them")
+	importCmd.Flags().BoolVar(&freshLogin, "fresh-login", false, "ignore the stored browser session and login again")
+	importCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "import the entries without validating them first")
+	importCmd.Flags().StringVar(&reportFile, "report", "", "write the results of the import to a report file, e.g. out.json")
+	importCmd.Flags().StringVar(&reportFormat, "report-format", "", "format of the report (json|junit|csv), by default it is derived from the file extension")
+	importCmd.Flags().StringVar(&resumeRunId, "resume", "", "replay the entries of a previous run that were not saved, instead of reading a file")
+	importCmd.Flags().BoolVar(&retryFailed, "retry-failed", false, "replay the entries of the latest run that were not saved, instead of reading a file")
+	importCmd.MarkFlagsMutuallyExclusive("resume", "retry-failed")
+	importCmd.Flags().StringVar(&duplicates, "duplicates", "", "how entries that might already exist are handled (skip|warn|force|update), defaults to skip")
+	importCmd.Flags().BoolVar(&update, "update", false, "overwrite the duration and summary of your existing entries on the same date and start time, same as --duplicates update")
+	importCmd.MarkFlagsMutuallyExclusive("duplicates", "update")
+	importCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "overwrite existing entries without asking, with the update policy")
+	importCmd.Flags().BoolVar(&embedMarkers, "embed-fingerprint", false, "add a fingerprint marker to the summary notes, to recognise the entries in later imports")
+	importCmd.Flags().BoolVar(&diagnostics, "diagnostics", false, "save a screenshot and the HTML of the page when an entry fails, in a directory of the run")
+	importCmd.Flags().BoolVar(&trace, "trace", false, "save a Playwright trace of the import after logging in with the diagnostics, it holds the session cookies, implies --diagnostics")
+	importCmd.Flags().StringVar(&sameDayTasks, "same-day-tasks", "", "how several entries of a task on the same day are captured (fail|merge), defaults to fail")
+	importCmd.Flags().BoolVar(&submitAfter, "submit-after-import", false, "submit the timesheets of the weeks in which every entry was captured")
+	importCmd.Flags().StringVar(&backend, "backend", "", fmt.Sprintf("how the entries are captured (%v), defaults to %v", strings.Join(at.Backends(), "|"), at.DefaultBackend))
 }
 
-// load processes the file and imports it.
-func load(filename string) error {
-	log.Printf("Loading file: %v\n", filename)
-	defer log.Println("Done")
+// load processes the file and imports it, a cancelled import still records the entries captured so far.
+func load(ctx context.Context, filename string) error {
+	defer slog.Debug("Done")
 
 	opts := getLoadOptions()
 
-	entries, err := readFile(filename, opts.DateFormat)
+	// The backend reads its own section of the configuration, e.g. api for the api backend
+	autoTasker, err := at.NewBackend(opts.Backend, viper.GetStringMap(opts.Backend))
 	if err != nil {
 		return err
 	}
 
+	if opts.DuplicatePolicy == at.DuplicateUpdate && !assumeYes {
+		// The answers are read from stdin, which can't also hold the entries
+		if filename == stdinFilename && resumeRunId == "" && !retryFailed {
+			return fmt.Errorf("updates are confirmed on stdin, use --yes to update entries read from stdin")
+		}
+		opts.ConfirmUpdate = confirmUpdate
+	}
+
+	if _, ok := autoTasker.(at.Timesheeter); opts.SubmitAfterImport && !ok {
+		slog.Warn("the backend can't submit timesheets, they are left open", "backend", opts.Backend)
+	}
+
+	// Fail early on an unknown report format rather than after the import
+	if reportFile != "" && reportFormat == "" {
+		_, err := at.ReportFormatFromFilename(reportFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	readOpts, err := getReadOptions(opts.DateFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := at.DefaultRunStore()
+	if err != nil {
+		return err
+	}
+
+	var entries at.TimeEntries
+	var resumed *at.Run
+
+	if resumeRunId != "" || retryFailed {
+		resumed, err = getResumeRun(store)
+		if err != nil {
+			return err
+		}
+
+		entries = at.ToTimeEntries(resumed.Unfinished(), opts.DateFormat)
+		slog.Info("Resuming run", "resumed", resumed.Id, "unsaved", len(entries), "entries", len(resumed.Entries))
+		if len(entries) == 0 {
+			return nil
+		}
+
+		filename = resumed.Source
+	} else {
+		slog.Info("Loading file", "file", filename)
+		entries, err = readFile(filename, importFormat, readOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate and start the journal before printing the summary, which sorts the entries
+	if !skipValidation {
+		err = validate(entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	ledger := loadLedger()
+	if ledger != nil {
+		ledger.MarkKnown(entries, opts.DuplicatePolicy)
+	}
+
+	run := at.NewRun(filename, entries)
+	run.Id = store.NextId(run.Id)
+	run.Options = at.NewRunOptions(opts, importFormat)
+	if resumed != nil {
+		run.ResumedFrom = resumed.Id
+	}
+
 	entries.PrintSummary()
 
 	if reportOnly {
 		return nil
 	}
 
-	log.Printf("Importing time entries\n")
-	autoTasker := pwplugin.NewAutoTaskPlaywright()
-	err = autoTasker.CaptureTimes(entries, opts)
+	saveRun(store, run)
+
+	// Attach the run to everything logged from here on
+	slog.SetDefault(slog.Default().With("run", run.Id))
+	slog.Info("Importing time entries", "backend", opts.Backend, "entries", len(entries))
+
+	if slog.Default().Enabled(ctx, slog.LevelInfo) {
+		opts.Observer = at.NewProgressBar(os.Stderr, len(entries))
+	}
+
+	if opts.Trace || diagnostics || viper.GetBool(settingDiagnosticsEnabled) {
+		opts.DiagnosticsDir = store.DiagnosticsDir(run.Id)
+	}
+	startedAt := time.Now()
+	err = at.CaptureTimesContext(ctx, autoTasker, entries, opts)
 	entries.PrintSummary()
+
+	if opts.DiagnosticsDir != "" {
+		if _, statErr := os.Stat(opts.DiagnosticsDir); statErr == nil {
+			run.Diagnostics = opts.DiagnosticsDir
+			slog.Info("Diagnostics saved", "dir", opts.DiagnosticsDir)
+		}
+	}
+
+	run.Finish(err)
+	saveRun(store, run)
+
+	if ledger != nil {
+		ledger.Record(entries)
+		ledgerErr := ledger.Save()
+		if ledgerErr != nil {
+			slog.Warn("could not save fingerprints", "error", ledgerErr)
+		}
+	}
+
+	report := at.NewReport(entries, startedAt, time.Now(), err)
+	if reportFile != "" {
+		reportErr := report.WriteFile(reportFile, reportFormat)
+		if reportErr != nil {
+			slog.Error("could not write report", "error", reportErr)
+		} else {
+			slog.Info("Report written", "file", reportFile)
+		}
+	}
+
 	if err != nil {
 		return err
 	}
 
+	if report.ExitCode != 0 {
+		return fmt.Errorf("%d of %d entries failed, retry them with: gt-at import --resume %v", report.Failed, report.Total, run.Id)
+	}
+
+	return nil
+}
+
+// confirmUpdate shows the duration and summary of an existing entry before and after the update
+// and asks to overwrite it, anything but yes keeps the existing entry.
+func confirmUpdate(te *at.TimeEntry) bool {
+	at.PrintUpdate(os.Stdout, te)
+	fmt.Print("Overwrite the existing entry? [y/N]:")
+
+	answer, err := readLine()
+	if err != nil {
+		slog.Warn("could not read the answer, keeping the existing entry", "error", err)
+		return false
+	}
+
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// getResumeRun loads the run to resume, either the given run or the latest one.
+func getResumeRun(store *at.RunStore) (*at.Run, error) {
+	if resumeRunId != "" {
+		return store.Load(resumeRunId)
+	}
+
+	return store.Latest()
+}
+
+// saveRun saves the run journal, failing to do so doesn't stop the import.
+func saveRun(store *at.RunStore, run *at.Run) {
+	err := store.Save(run)
+	if err != nil {
+		slog.Warn("could not save run journal", "error", err)
+	}
+}
+
+// loadLedger loads the fingerprints of previously saved entries, failing to do so doesn't stop the import.
+func loadLedger() *at.Ledger {
+	path, err := at.DefaultLedgerPath()
+	if err == nil {
+		var ledger *at.Ledger
+		ledger, err = at.LoadLedger(path)
+		if err == nil {
+			return ledger
+		}
+	}
+
+	slog.Warn("could not load fingerprints", "error", err)
 	return nil
 }
 
@@ -75,39 +292,140 @@ func getLoadOptions() at.CaptureOptions {
 		cobra.CheckErr(fmt.Errorf("fatal error config file: %s \n", err))
 	}
 
+	if update {
+		duplicates = string(at.DuplicateUpdate)
+	}
+	if duplicates == "" {
+		duplicates = viper.GetString(settingDuplicatesPolicy)
+	}
+	policy, err := at.ParseDuplicatePolicy(duplicates)
+	cobra.CheckErr(err)
+
+	if sameDayTasks == "" {
+		sameDayTasks = viper.GetString(settingImportSameDayTasks)
+	}
+	consolidation, err := at.ParseConsolidationPolicy(sameDayTasks)
+	cobra.CheckErr(err)
+
+	if backend == "" {
+		backend = viper.GetString(settingImportBackend)
+	}
+	if backend == "" {
+		backend = at.DefaultBackend
+	}
+
 	opts := at.CaptureOptions{
 		Credentials: at.Credentials{
 			Username: viper.GetString(settingCredentialsUsername),
 		},
-		UserDisplayName: viper.GetString(settingAutoTaskDisplayName),
-		DateFormat:      viper.GetString(settingAutoTaskDateFormat),
-		DayFormat:       viper.GetString(settingAutoTaskDayFormat),
-		BrowserType:     viper.GetString(settingPlaywrightBrowser),
-		Headless:        viper.GetBool(settingPlaywrightHeadless),
-		DryRun:          false,
+		UserDisplayName:   viper.GetString(settingAutoTaskDisplayName),
+		DateFormat:        viper.GetString(settingAutoTaskDateFormat),
+		DayFormat:         viper.GetString(settingAutoTaskDayFormat),
+		BrowserType:       viper.GetString(settingPlaywrightBrowser),
+		Headless:          viper.GetBool(settingPlaywrightHeadless),
+		DryRun:            false,
+		FreshLogin:        freshLogin,
+		DuplicatePolicy:   policy,
+		EmbedMarkers:      embedMarkers || viper.GetBool(settingDuplicatesEmbedFingerprint),
+		SelectorsFile:     viper.GetString(settingPlaywrightSelectors),
+		Trace:             trace || viper.GetBool(settingDiagnosticsTrace),
+		SubmitAfterImport: submitAfter || viper.GetBool(settingTimesheetSubmitAfterImport),
+		SameDayTasks:      consolidation,
+		Backend:           backend,
 	}
 
 	return opts
 }
 
-// readFile reads and unmarshals a JSON file into time entries.
-func readFile(filename, dateFormat string) (at.TimeEntries, error) {
+// getReadOptions retrieves the options for reading import files from configuration.
+func getReadOptions(dateFormat string) (at.ReadOptions, error) {
+	opts := at.ReadOptions{
+		DateFormat: dateFormat,
+		CSV: at.CSVOptions{
+			DateLayout: viper.GetString(settingImportCSVDateFormat),
+			Columns: at.CSVColumns{
+				Id:        viper.GetString(settingImportCSVColumnId),
+				IsTicket:  viper.GetString(settingImportCSVColumnIsTicket),
+				Date:      viper.GetString(settingImportCSVColumnDate),
+				StartTime: viper.GetString(settingImportCSVColumnStartTime),
+				Duration:  viper.GetString(settingImportCSVColumnDuration),
+				Summary:   viper.GetString(settingImportCSVColumnSummary),
+				Project:   viper.GetString(settingImportCSVColumnProject),
+			},
+		},
+	}
+
+	if delimiter := []rune(viper.GetString(settingImportCSVDelimiter)); len(delimiter) > 0 {
+		opts.CSV.Delimiter = delimiter[0]
+	}
+
+	if mappingFile == "" {
+		mappingFile = viper.GetString(settingImportMapping)
+	}
+
+	if mappingFile != "" {
+		mapping, err := at.LoadMapping(mappingFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Mapping = mapping
+	}
+
+	return opts, nil
+}
+
+// readFile reads and parses a file into time entries, if no format is given it is derived from the file extension.
+// A filename of "-" reads from stdin, in which case the format is derived from the content.
+func readFile(filename, format string, opts at.ReadOptions) (at.TimeEntries, error) {
+	if filename == stdinFilename {
+		return readStdin(format, opts)
+	}
+
 	// Ensure the file exists.
 	_, err := os.Stat(filename)
 	if os.IsNotExist(err) {
 		return nil, fmt.Errorf("file does not exist: %v, %v", filename, err)
 	}
 
-	data, err := os.ReadFile(filename)
+	if format == "" {
+		format, err = at.FormatFromFilename(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	log.Printf("Unmarshalling json file: %v\n", filename)
-	entries, err := at.UnmarshalToTimeEntries(data, dateFormat)
+	slog.Debug("Reading file", "format", format, "file", filename)
+	entries, err := at.ReadTimeEntries(f, format, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return entries, nil
 }
+
+// stdinFilename is the filename used to read entries from stdin.
+const stdinFilename = "-"
+
+// readStdin reads and parses time entries piped to stdin.
+func readStdin(format string, opts at.ReadOptions) (at.TimeEntries, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("could not read stdin: %v", err)
+	}
+
+	if format == "" {
+		format, err = at.FormatFromContent(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Debug("Reading stdin", "format", format)
+	return at.ReadTimeEntries(bytes.NewReader(data), format, opts)
+}
@@ -3,7 +3,7 @@ package cmd
 import (
 	"bufio"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path"
 	"strings"
@@ -55,7 +55,7 @@ func getConfigFile() string {
 }
 
 func initialiseConfigFile(cf string) error {
-	log.Printf("Initialising config file: %v\n", cf)
+	slog.Info("Initialising config file", "file", cf)
 
 	setViperDefaults()
 
@@ -107,6 +107,42 @@ const (
 	settingCredentialsUsername = "credentials.username"
 	settingPlaywrightBrowser   = "playwright.browser-type"
 	settingPlaywrightHeadless  = "playwright.headless"
+	settingPlaywrightSelectors = "playwright.selectors-file"
+
+	settingImportMapping            = "import.mapping"
+	settingImportSameDayTasks       = "import.same-day-tasks"
+	settingImportCSVDelimiter       = "import.csv.delimiter"
+	settingImportCSVDateFormat      = "import.csv.date-format"
+	settingImportCSVColumnId        = "import.csv.columns.id"
+	settingImportCSVColumnIsTicket  = "import.csv.columns.is-ticket"
+	settingImportCSVColumnDate      = "import.csv.columns.date"
+	settingImportCSVColumnStartTime = "import.csv.columns.start-time"
+	settingImportCSVColumnDuration  = "import.csv.columns.duration"
+	settingImportCSVColumnSummary   = "import.csv.columns.summary"
+	settingImportCSVColumnProject   = "import.csv.columns.project"
+
+	settingValidationMinDuration     = "validation.min-duration"
+	settingValidationMaxDuration     = "validation.max-duration"
+	settingValidationGranularity     = "validation.granularity"
+	settingValidationMaxHoursPerDay  = "validation.max-hours-per-day"
+	settingValidationCheckOverlaps   = "validation.check-overlaps"
+	settingValidationMaxDaysInPast   = "validation.max-days-in-past"
+	settingValidationMaxDaysInFuture = "validation.max-days-in-future"
+	settingValidationRoles           = "validation.allowed.roles"
+	settingValidationWorkTypes       = "validation.allowed.work-types"
+	settingValidationBillingCodes    = "validation.allowed.billing-codes"
+	settingValidationTicketStatuses  = "validation.allowed.ticket-statuses"
+
+	settingDuplicatesPolicy           = "duplicates.policy"
+	settingDuplicatesEmbedFingerprint = "duplicates.embed-fingerprint"
+
+	settingDiagnosticsEnabled = "diagnostics.enabled"
+	settingDiagnosticsTrace   = "diagnostics.trace"
+
+	settingTimesheetSubmitAfterImport = "timesheet.submit-after-import"
+
+	settingImportBackend = "import.backend"
+	settingAPISecret     = "api.secret" // The other settings of the api section are decoded by the backend
 )
 
 func prompt(question, defaultValue string) (string, error) {
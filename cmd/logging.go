@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLevel  string
+	logFormat string
+	logFile   string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "minimum level of the messages logged (debug|info|warn|error), error only prints the summary")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "format of the log (text|json)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append the log to a file instead of writing it to stderr")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return setupLogging()
+	}
+}
+
+// setupLogging sets the default logger from the logging flags.
+func setupLogging() error {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(logLevel))
+	if err != nil {
+		return fmt.Errorf("invalid log level: %v, expected debug, info, warn or error", logLevel)
+	}
+
+	var w io.Writer = os.Stderr
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("could not open log file: %v", err)
+		}
+		// The file is closed when the process exits
+		w = f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(logFormat) {
+	case "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return fmt.Errorf("invalid log format: %v, expected text or json", logFormat)
+	}
+
+	slog.SetDefault(slog.New(handler))
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var logoutLocal bool
+
+// logoutCmd ends and removes the stored browser session
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logs out of AutoTask and removes the stored browser session",
+	Long: `Logs out of AutoTask with the stored browser session and removes it, the next import will require a full login (and MFA).
+Use --local to only remove the stored session, without opening a browser`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+
+		viper.SetConfigFile(getConfigFile())
+		err := viper.ReadInConfig()
+		if err != nil {
+			cobra.CheckErr(fmt.Errorf("fatal error config file: %s \n", err))
+		}
+
+		username := viper.GetString(settingCredentialsUsername)
+		if logoutLocal {
+			err = pwplugin.ClearSession(username)
+		} else {
+			err = pwplugin.Logout(at.CaptureOptions{
+				Credentials:   at.Credentials{Username: username},
+				BrowserType:   viper.GetString(settingPlaywrightBrowser),
+				Headless:      viper.GetBool(settingPlaywrightHeadless),
+				SelectorsFile: viper.GetString(settingPlaywrightSelectors),
+			})
+		}
+		cobra.CheckErr(err)
+
+		slog.Info("Stored session removed", "username", username)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+
+	logoutCmd.Flags().BoolVar(&logoutLocal, "local", false, "only remove the stored session, without logging out of AutoTask")
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd prints the JSON Schema of the import file
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints the JSON Schema of the import file",
+	Long:  `Prints the JSON Schema of the import file, it can be used by editors to validate time entry files`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := at.JSONSchema()
+		cobra.CheckErr(err)
+
+		fmt.Println(string(schema))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/philipf/gt-at/pwplugin"
+	"github.com/philipf/gt-at/pwplugin/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkTicketId int
+	checkTaskId   int
+)
+
+var selectorsCmd = &cobra.Command{
+	Use:   "selectors",
+	Short: "List and check the selectors used to automate AutoTask",
+	Long: `AutoTask is automated by finding elements on its pages with selectors. When AutoTask changes its pages,
+the selectors can be overridden in a YAML file mapping selector names to selectors, configured with playwright.selectors-file`,
+}
+
+var selectorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the selectors in use",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		opts := getLoadOptions()
+
+		sel, err := common.LoadSelectors(opts.SelectorsFile)
+		cobra.CheckErr(err)
+
+		pwplugin.PrintSelectorCatalogue(os.Stdout, sel)
+	},
+}
+
+var selectorsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Logs in and reports which selectors are found on the AutoTask pages",
+	Long: `Logs in and reports which selectors are found on the AutoTask pages. Give a ticket and a task with your own
+time entries to check their pages, the time entry dialogs are opened but nothing is saved`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		opts := getLoadOptions()
+
+		checks, err := pwplugin.CheckSelectors(cmd.Context(), opts, checkTicketId, checkTaskId)
+		cobra.CheckErr(err)
+
+		pwplugin.PrintSelectors(os.Stdout, checks)
+
+		missing := 0
+		for _, c := range checks {
+			if c.Status == pwplugin.SelectorMissing {
+				missing++
+			}
+		}
+
+		if missing > 0 {
+			cobra.CheckErr(fmt.Errorf("%d selectors were not found, override them in the selectors file", missing))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selectorsCmd)
+	selectorsCmd.AddCommand(selectorsListCmd)
+	selectorsCmd.AddCommand(selectorsCheckCmd)
+
+	selectorsCheckCmd.Flags().IntVar(&checkTicketId, "ticket", 0, "ID of a ticket to check the ticket pages with")
+	selectorsCheckCmd.Flags().IntVar(&checkTaskId, "task", 0, "ID of a task to check the task pages with")
+}
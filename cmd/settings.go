@@ -23,6 +23,11 @@ var settingsCmd = &cobra.Command{
 		// print out the current settings
 		fmt.Println("Current settings:")
 		for _, key := range viper.AllKeys() {
+			// Never print the secret of the API user
+			if key == settingAPISecret {
+				fmt.Printf("%s: %s\n", key, "********")
+				continue
+			}
 			fmt.Printf("%s: %s\n", key, viper.Get(key))
 		}
 	},
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var timesheetWeeks []string
+
+var timesheetCmd = &cobra.Command{
+	Use:   "timesheet",
+	Short: "Show, submit and recall your weekly timesheets",
+	Long: `Show, submit and recall your weekly timesheets. A week is given by any of its days as yyyy-mm-dd,
+the timesheet of the week starting on the Sunday before it is used`,
+}
+
+var timesheetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows the status of the timesheets of the weeks",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		opts := getLoadOptions()
+
+		weeks, err := parseWeeks(timesheetWeeks)
+		cobra.CheckErr(err)
+
+		tasker, err := getTimesheeter(opts)
+		cobra.CheckErr(err)
+
+		sheets, err := tasker.Timesheets(cmd.Context(), weeks, opts)
+		cobra.CheckErr(err)
+
+		at.PrintTimesheets(os.Stdout, sheets)
+	},
+}
+
+var timesheetSubmitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Submits the timesheets of the weeks for approval",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTimesheets(cmd.Context(), "submit", "submitted", at.Timesheeter.SubmitTimesheet)
+	},
+}
+
+var timesheetRecallCmd = &cobra.Command{
+	Use:   "recall",
+	Short: "Recalls the submitted timesheets of the weeks, so time can be entered again",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTimesheets(cmd.Context(), "recall", "recalled", at.Timesheeter.RecallTimesheet)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(timesheetCmd)
+
+	for _, c := range []*cobra.Command{timesheetStatusCmd, timesheetSubmitCmd, timesheetRecallCmd} {
+		timesheetCmd.AddCommand(c)
+		c.Flags().StringSliceVar(&timesheetWeeks, "week", nil, "a day of the week of the timesheet as yyyy-mm-dd, can be repeated")
+		c.MarkFlagRequired("week")
+	}
+}
+
+// runTimesheets submits or recalls the timesheet of each week, continuing with the next week
+// when one fails.
+func runTimesheets(ctx context.Context, action, done string, f func(at.Timesheeter, context.Context, time.Time, at.CaptureOptions) error) {
+	isConfigured()
+	opts := getLoadOptions()
+
+	weeks, err := parseWeeks(timesheetWeeks)
+	cobra.CheckErr(err)
+
+	tasker, err := getTimesheeter(opts)
+	cobra.CheckErr(err)
+
+	failed := 0
+	for _, week := range weeks {
+		err := f(tasker, ctx, week, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not %v the timesheet of the week of %v: %v\n", action, week.Format(time.DateOnly), err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("Timesheet of the week of %v %v\n", week.Format(time.DateOnly), done)
+	}
+
+	if failed > 0 {
+		cobra.CheckErr(fmt.Errorf("%d of %d timesheets failed", failed, len(weeks)))
+	}
+}
+
+// getTimesheeter returns the backend of the options if it can manage timesheets.
+func getTimesheeter(opts at.CaptureOptions) (at.Timesheeter, error) {
+	autoTasker, err := at.NewBackend(opts.Backend, viper.GetStringMap(opts.Backend))
+	if err != nil {
+		return nil, err
+	}
+
+	tasker, ok := autoTasker.(at.Timesheeter)
+	if !ok {
+		return nil, fmt.Errorf("the %v backend can't manage timesheets, set %v to %v", opts.Backend, settingImportBackend, at.DefaultBackend)
+	}
+
+	return tasker, nil
+}
+
+// parseWeeks parses days as yyyy-mm-dd into the Sundays of their weeks.
+func parseWeeks(days []string) ([]time.Time, error) {
+	var weeks []time.Time
+	for _, day := range days {
+		date, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid week %q, expected a date as yyyy-mm-dd: %v", day, err)
+		}
+
+		weeks = append(weeks, at.SundayOfTheWeek(date))
+	}
+
+	return weeks, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// validateCmd checks a file of time entries without importing it
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate a file of time entries",
+	Long:  `Validate a file of time entries against the validation rules, all violations are printed and the exit code is non-zero if any are found`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		isConfigured()
+		if len(args) > 0 {
+			importFile = args[0]
+		}
+		if importFrom != "" {
+			importFormat = importFrom
+		}
+
+		opts := getLoadOptions()
+
+		readOpts, err := getReadOptions(opts.DateFormat)
+		cobra.CheckErr(err)
+
+		entries, err := readFile(importFile, importFormat, readOpts)
+		cobra.CheckErr(err)
+
+		cobra.CheckErr(validate(entries))
+		slog.Info("Entries are valid", "entries", len(entries))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&importFile, "filename", "f", "/tmp/time.json", "name of the file that should be validated, use - to read from stdin")
+	validateCmd.Flags().StringVar(&importFormat, "format", "", "format of the file (json|jsonl|csv), by default it is derived from the file extension")
+	validateCmd.Flags().StringVar(&importFrom, "from", "", "validate the native export of a time tracker (toggl|clockify|harvest)")
+	validateCmd.Flags().StringVar(&mappingFile, "mapping", "", "mapping file translating time tracker projects, tags and descriptions to AutoTask IDs")
+	validateCmd.MarkFlagsMutuallyExclusive("format", "from")
+}
+
+// validate checks the entries against the configured rules and prints any violations.
+// The entries must still be in the order they were read in.
+func validate(entries at.TimeEntries) error {
+	violations := entries.Validate(getValidationRules())
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, v := range violations {
+		slog.Error("Invalid entry", "entry", v.Index, "id", v.Id, "field", v.Field, "error", v.Message)
+	}
+
+	return fmt.Errorf("%d validation errors found", len(violations))
+}
+
+// getValidationRules retrieves the validation rules from configuration, falling back to the defaults.
+func getValidationRules() at.ValidationRules {
+	d := at.DefaultValidationRules()
+	viper.SetDefault(settingValidationMinDuration, d.MinDuration)
+	viper.SetDefault(settingValidationMaxDuration, d.MaxDuration)
+	viper.SetDefault(settingValidationGranularity, d.Granularity)
+	viper.SetDefault(settingValidationMaxHoursPerDay, d.MaxHoursPerDay)
+	viper.SetDefault(settingValidationCheckOverlaps, d.CheckOverlaps)
+	viper.SetDefault(settingValidationMaxDaysInPast, d.MaxDaysInPast)
+	viper.SetDefault(settingValidationMaxDaysInFuture, d.MaxDaysInFuture)
+
+	return at.ValidationRules{
+		MinDuration:     float32(viper.GetFloat64(settingValidationMinDuration)),
+		MaxDuration:     float32(viper.GetFloat64(settingValidationMaxDuration)),
+		Granularity:     viper.GetInt(settingValidationGranularity),
+		MaxHoursPerDay:  float32(viper.GetFloat64(settingValidationMaxHoursPerDay)),
+		CheckOverlaps:   viper.GetBool(settingValidationCheckOverlaps),
+		MaxDaysInPast:   viper.GetInt(settingValidationMaxDaysInPast),
+		MaxDaysInFuture: viper.GetInt(settingValidationMaxDaysInFuture),
+		Allowed: at.AllowedValues{
+			Roles:          viper.GetStringSlice(settingValidationRoles),
+			WorkTypes:      viper.GetStringSlice(settingValidationWorkTypes),
+			BillingCodes:   viper.GetStringSlice(settingValidationBillingCodes),
+			TicketStatuses: viper.GetStringSlice(settingValidationTicketStatuses),
+		},
+	}
+}
@@ -3,10 +3,11 @@ module github.com/philipf/gt-at
 go 1.23.0
 
 require (
-	github.com/playwright-community/playwright-go v0.5200.1
 	github.com/olekukonko/tablewriter v1.1.0
+	github.com/playwright-community/playwright-go v0.5200.1
 	github.com/spf13/cobra v1.10.1
 	github.com/spf13/viper v1.21.0
+	go.yaml.in/yaml/v3 v3.0.4
 )
 
 require (
@@ -30,7 +31,6 @@ require (
 	github.com/spf13/cast v1.10.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
 )
@@ -2,21 +2,79 @@ package pwplugin
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 
 	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
 	"github.com/playwright-community/playwright-go"
 )
 
+// Logout ends the stored browser session in AutoTask and removes it, the next import requires a
+// full login. An expired session is only removed, without a stored session nothing is done.
+func Logout(opts at.CaptureOptions) error {
+	path, err := SessionPath(opts.Credentials.Username)
+	if err != nil {
+		return err
+	}
+
+	session, err := loadSession(path)
+	if err != nil {
+		slog.Warn("could not load stored session, removing it", "error", err)
+	}
+
+	if session != nil {
+		err = logoutSession(session, opts)
+		if err != nil {
+			slog.Warn("could not log out of AutoTask, only the stored session is removed", "error", err)
+		}
+	}
+
+	return ClearSession(opts.Credentials.Username)
+}
+
+// logoutSession opens a browser with the stored session and logs out, if it is still valid.
+func logoutSession(session *storedSession, opts at.CaptureOptions) error {
+	sel, err := common.LoadSelectors(opts.SelectorsFile)
+	if err != nil {
+		return err
+	}
+
+	pw, browser, err := common.InitPlaywright(true, opts.BrowserType, opts.Headless)
+	if err != nil {
+		return fmt.Errorf("could not init playwright: %v", err)
+	}
+	defer pw.Stop()
+	defer browser.Close()
+
+	ctx, err := browser.NewContext(playwright.BrowserNewContextOptions{StorageState: session.State.ToOptionalStorageState()})
+	if err != nil {
+		return fmt.Errorf("could not create context: %v", err)
+	}
+
+	page, err := ctx.NewPage()
+	if err != nil {
+		return fmt.Errorf("could not create page: %v", err)
+	}
+
+	if !resumeSession(page, session.BaseURL) {
+		return nil
+	}
+
+	at.BaseURL = at.GetBaseURL(page.URL())
+	logout(page, sel)
+
+	return nil
+}
+
 // logout logs the user out of the application and waits for the authentication page to appear.
-func logout(page playwright.Page) {
-	log.Println("Logging out")
+func logout(page playwright.Page, sel common.Selectors) {
+	slog.Info("Logging out")
 
 	// Navigate to the landing page
 	_, err := page.Goto(fmt.Sprintf(at.URI_LANDING, at.BaseURL))
 	if err != nil {
-		log.Printf("could not goto landing page: %v\n", err)
+		slog.Error("could not goto landing page", "error", err)
 	}
 
 	// Wait for the landing page to fully load
@@ -28,53 +86,53 @@ func logout(page playwright.Page) {
 	})
 
 	if err != nil {
-		log.Printf("could not wait for landing page: %v\n", err)
+		slog.Error("could not wait for landing page", "error", err)
 	}
 
-	log.Println("Landing page loaded")
+	slog.Debug("Landing page loaded")
 
 	// Hover over the profile section to make sub-elements accessible
-	err = page.Locator("[data-eii='05008GVH']").Hover()
+	err = page.Locator(sel.Get(common.SelectorProfile)).Hover()
 	if err != nil {
-		log.Printf("could not hover over profile: %v\n", err)
+		slog.Error("could not hover over profile", "error", err)
 	}
 
 	// Click the logout button in the profile section
-	err = page.Locator("[data-eii='0100014V']").Click()
+	err = page.Locator(sel.Get(common.SelectorProfileLogout)).Click()
 	if err != nil {
-		log.Printf("could not click profile logout: %v\n", err)
+		slog.Error("could not click profile logout", "error", err)
 	}
 
 	// Locate the close button for the status out dialog
-	setStatusOutLocatorCloseButton := page.Locator("div.Dialog1 div.DialogTitleBarIcon")
+	setStatusOutLocatorCloseButton := page.Locator(sel.Get(common.SelectorStatusOutClose))
 	setStatusOutLocatorCloseButton.WaitFor(playwright.LocatorWaitForOptions{
 		Timeout: playwright.Float(2 * 1000),
 	})
 	if err != nil {
-		log.Printf("could not find status out (WaitFor): %v\n", err)
+		slog.Debug("could not find status out (WaitFor)", "error", err)
 	}
 
 	// Check if the status out dialog is visible
 	setStatusOut, err := setStatusOutLocatorCloseButton.IsVisible()
 	if err != nil {
-		log.Printf("could not find status out (IsVisible): %v\n", err)
+		slog.Debug("could not find status out (IsVisible)", "error", err)
 	}
 
 	// If the status out dialog is visible, click the close button
 	if setStatusOut {
 		err = setStatusOutLocatorCloseButton.Click()
 		if err != nil {
-			log.Printf("could not click status out: %v\n", err)
+			slog.Error("could not click status out", "error", err)
 		}
 	}
 
 	// Wait for the logout process to complete and the authentication page to appear
-	log.Println("Waiting for logout to complete")
+	slog.Debug("Waiting for logout to complete")
 	urlRegEx = regexp.MustCompile(".*Authenticate")
 	err = page.WaitForURL(urlRegEx)
 	if err != nil {
-		log.Printf("could not wait for authentication page: %v\n", err)
+		slog.Error("could not wait for authentication page", "error", err)
 	}
 
-	log.Println("Logged out")
+	slog.Info("Logged out")
 }
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+// WithContext returns a driver that stops once the context is done. Every call first checks the
+// context, and a call failing because the context was cancelled while it was waiting, e.g. when
+// the browser is closed, returns the error of the context.
+func WithContext(ctx context.Context, d Driver) Driver {
+	return &contextDriver{ctx: ctx, d: d}
+}
+
+type contextDriver struct {
+	ctx context.Context
+	d   Driver
+}
+
+// do runs the call unless the context is done.
+func (c *contextDriver) do(call func() error) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	err := call()
+	if err != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+
+	return err
+}
+
+func (c *contextDriver) OpenTicket(id int) error {
+	return c.do(func() error { return c.d.OpenTicket(id) })
+}
+
+func (c *contextDriver) OpenTask(id int) error {
+	return c.do(func() error { return c.d.OpenTask(id) })
+}
+
+func (c *contextDriver) Conversations() ([]Conversation, error) {
+	var result []Conversation
+	err := c.do(func() (err error) {
+		result, err = c.d.Conversations()
+		return err
+	})
+
+	return result, err
+}
+
+func (c *contextDriver) NewTicketEntry() error {
+	return c.do(c.d.NewTicketEntry)
+}
+
+func (c *contextDriver) EditTicketEntry(conv Conversation) error {
+	return c.do(func() error { return c.d.EditTicketEntry(conv) })
+}
+
+func (c *contextDriver) FillTicketEntry(te *at.TimeEntry) error {
+	return c.do(func() error { return c.d.FillTicketEntry(te) })
+}
+
+func (c *contextDriver) SaveTicketEntry() error {
+	return c.do(c.d.SaveTicketEntry)
+}
+
+func (c *contextDriver) NewWeekEntry() error {
+	return c.do(c.d.NewWeekEntry)
+}
+
+func (c *contextDriver) EditWeekEntry(conv Conversation) error {
+	return c.do(func() error { return c.d.EditWeekEntry(conv) })
+}
+
+func (c *contextDriver) WeekStart() (string, error) {
+	var result string
+	err := c.do(func() (err error) {
+		result, err = c.d.WeekStart()
+		return err
+	})
+
+	return result, err
+}
+
+func (c *contextDriver) PreviousWeek() error {
+	return c.do(c.d.PreviousWeek)
+}
+
+func (c *contextDriver) NextWeek() error {
+	return c.do(c.d.NextWeek)
+}
+
+func (c *contextDriver) EditWeek() error {
+	return c.do(c.d.EditWeek)
+}
+
+func (c *contextDriver) FillDay(te *at.TimeEntry) error {
+	return c.do(func() error { return c.d.FillDay(te) })
+}
+
+func (c *contextDriver) NextDay() error {
+	return c.do(c.d.NextDay)
+}
+
+func (c *contextDriver) SaveWeek() error {
+	return c.do(c.d.SaveWeek)
+}
+
+func (c *contextDriver) OpenTimesheet(week time.Time) error {
+	return c.do(func() error { return c.d.OpenTimesheet(week) })
+}
+
+func (c *contextDriver) TimesheetStatus() (at.TimesheetStatus, error) {
+	var result at.TimesheetStatus
+	err := c.do(func() (err error) {
+		result, err = c.d.TimesheetStatus()
+		return err
+	})
+
+	return result, err
+}
+
+func (c *contextDriver) SubmitTimesheet() error {
+	return c.do(c.d.SubmitTimesheet)
+}
+
+func (c *contextDriver) RecallTimesheet() error {
+	return c.do(c.d.RecallTimesheet)
+}
+
+func (c *contextDriver) TimesheetItems() ([]TimesheetItem, error) {
+	var result []TimesheetItem
+	err := c.do(func() (err error) {
+		result, err = c.d.TimesheetItems()
+		return err
+	})
+
+	return result, err
+}
@@ -2,73 +2,140 @@ package common
 
 import (
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/philipf/gt-at/at"
-	"github.com/playwright-community/playwright-go"
 )
 
-// MarkExisiting goes through timeEntries and marks them as existing if they are found on the page.
-func MarkExisiting(page playwright.Page, userDisplayName string, timeEntries at.TimeEntries, dateFormat string) error {
-	detailsSelector := page.Locator("div > .ConversationChunk > .ConversationItem .Details")
-	convs, err := detailsSelector.All()
-
+// MarkExisiting goes through timeEntries and marks them as existing if they are found on the page,
+// conversations that might be the same entry are handled according to the duplicate policy.
+// It returns a conversation of the user for each week number, used to edit existing week entries,
+// and with the update policy the conversation of the existing entry each entry overwrites.
+func MarkExisiting(d Driver, userDisplayName string, timeEntries at.TimeEntries, dateFormat string, policy at.DuplicatePolicy) (map[int]Conversation, map[*at.TimeEntry]Conversation, error) {
+	convs, err := d.Conversations()
 	if err != nil {
-		return fmt.Errorf("markExistingEnties: could not find conversations: %v", err)
+		return nil, nil, fmt.Errorf("markExistingEnties: could not find conversations: %v", err)
 	}
 
-	log.Printf("Found %v conversations\n", len(convs))
+	peers := map[int]Conversation{}
+	var mine []Conversation
 
-	for _, te := range timeEntries {
-		for _, conv := range convs {
-			author := conv.Locator("div > .Author div.Text2")
-			authorName, err := author.TextContent()
+	for _, conv := range convs {
+		if conv.Author != userDisplayName {
+			continue
+		}
 
-			if err != nil {
-				te.SetError(fmt.Errorf("markExistingEnties: could not find author TextContent: %+v", err))
-				continue
-			}
+		peers[getConvWeekNo(conv.Title, dateFormat)] = conv
+		mine = append(mine, conv)
 
-			if authorName == userDisplayName {
-				timeDetail := conv.Locator("div.Title div.Text > span")
+		for _, te := range timeEntries {
+			te.MatchConversation(conv.Title, conv.Text, te.DateStr, policy)
+		}
+	}
 
-				t, err := timeDetail.TextContent()
-				if err != nil {
-					te.SetError(fmt.Errorf("markExistingEnties: could not find timeDetail TextContent: %+v", err))
-					continue
-				}
+	updates := map[*at.TimeEntry]Conversation{}
+	if policy == at.DuplicateUpdate {
+		for _, conv := range mine {
+			if te := matchExisting(conv, timeEntries, dateFormat); te != nil && te.Updates() {
+				updates[te] = conv
+			}
+		}
+	}
 
-				weekNo := getConvWeekNo(t, dateFormat)
+	return peers, updates, nil
+}
 
-				if te.WeekNo == weekNo {
-					te.WeekPeerLocator = conv
-				}
+// matchExisting returns the entry that matches the existing entry of a conversation, if any.
+// Conversations carrying the fingerprint marker of one of the entries already belong to it.
+func matchExisting(conv Conversation, timeEntries at.TimeEntries, dateFormat string) *at.TimeEntry {
+	if len(timeEntries) == 0 {
+		return nil
+	}
 
-				if strings.HasPrefix(t, te.DateStr) {
-					log.Printf("Found date: %v\n", te.DateStr)
-					te.Exists = true
-					continue
-				}
+	for _, m := range at.FindMarkers(conv.Text) {
+		for _, te := range timeEntries {
+			if m == te.Fingerprint {
+				return nil
 			}
 		}
 	}
 
+	existing, err := ParseConversation(conv, timeEntries[0].Id, timeEntries[0].IsTicket, dateFormat)
+	if err != nil {
+		slog.Debug("Could not read the existing entry", "error", err)
+		return nil
+	}
+
+	for _, te := range timeEntries {
+		if te.MatchExisting(existing) {
+			return te
+		}
+	}
+
 	return nil
 }
 
 // getConvWeekNo parses the date string to extract its week number.
 func getConvWeekNo(t, dateFormat string) int {
+	if len(t) < len(dateFormat) {
+		slog.Debug("Error parsing date, it is too short", "date", t)
+		return -1
+	}
+
 	dateStr := t[:len(dateFormat)]
 
 	date, err := time.Parse(dateFormat, dateStr)
 	if err != nil {
 		// Logging instead of silently ignoring, this might provide useful debugging info.
-		log.Printf("Error parsing date: %v\n", err)
+		slog.Debug("Error parsing date", "error", err)
 		return -1
 	}
 
 	weekNo := at.WeekNo(date)
 	return weekNo
 }
+
+var (
+	convStartTimeRegEx = regexp.MustCompile(`\b(\d{1,2}:\d{2})\b`)
+	convDurationRegEx  = regexp.MustCompile(`\((\d+(?:\.\d+)?) hours?\)`)
+)
+
+// ParseConversation reads the time entry of a conversation on a ticket or task. The title starts
+// with the date in the user's date format, followed by the start time of a ticket entry, and shows
+// the duration in hours. Fingerprint markers are removed from the summary notes.
+func ParseConversation(c Conversation, id int, isTicket bool, dateFormat string) (*at.TimeEntry, error) {
+	if len(c.Title) < len(dateFormat) {
+		return nil, fmt.Errorf("parseConversation: no date in %q", c.Title)
+	}
+
+	date, err := time.Parse(dateFormat, c.Title[:len(dateFormat)])
+	if err != nil {
+		return nil, fmt.Errorf("parseConversation: invalid date in %q: %v", c.Title, err)
+	}
+
+	rest := c.Title[len(dateFormat):]
+
+	m := convDurationRegEx.FindStringSubmatch(rest)
+	if m == nil {
+		return nil, fmt.Errorf("parseConversation: no duration in %q", c.Title)
+	}
+	duration, err := strconv.ParseFloat(m[1], 32)
+	if err != nil {
+		return nil, fmt.Errorf("parseConversation: invalid duration in %q: %v", c.Title, err)
+	}
+
+	startTime := ""
+	if isTicket {
+		if m := convStartTimeRegEx.FindStringSubmatch(rest); m != nil {
+			startTime = m[1]
+			if len(startTime) < len("15:04") {
+				startTime = "0" + startTime
+			}
+		}
+	}
+
+	return at.NewEntry(id, isTicket, date, startTime, float32(duration), at.StripMarkers(c.Body), "", dateFormat), nil
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestParseConversation(t *testing.T) {
+	c := Conversation{Title: "2023/09/15 9:30 (1.25 hours)", Body: "Fixed it\n[gt-at:0123456789ab]"}
+
+	te, err := ParseConversation(c, 100, true, "2006/01/02")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if te.DateStr != "2023/09/15" || te.StartTimeStr != "09:30" || te.Duration != 1.25 || te.Summary != "Fixed it" {
+		t.Errorf("unexpected entry: %+v", te)
+	}
+
+	te, err = ParseConversation(Conversation{Title: "2023/09/11 (0.5 hours)", Body: "Design"}, 200, false, "2006/01/02")
+	if err != nil || te.StartTimeStr != "" || te.Duration != 0.5 || te.IsTicket {
+		t.Errorf("unexpected task entry: %+v, %v", te, err)
+	}
+
+	for _, title := range []string{"Note", "2023/09/15 10:30", "15/09/2023 (1 hours)"} {
+		_, err := ParseConversation(Conversation{Title: title}, 100, true, "2006/01/02")
+		if err == nil {
+			t.Errorf("expected %q not to be a time entry", title)
+		}
+	}
+}
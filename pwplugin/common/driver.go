@@ -0,0 +1,73 @@
+package common
+
+import (
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+// Driver describes the AutoTask pages and dialogs used to capture time entries. The capture
+// logic only talks to AutoTask through a driver, so it can run against a browser or in memory.
+type Driver interface {
+	// OpenTicket navigates to the detail page of a ticket and waits for its conversations to load.
+	OpenTicket(id int) error
+	// OpenTask navigates to the detail page of a task and waits for its conversations to load.
+	OpenTask(id int) error
+	// Conversations lists the conversations on the current detail page.
+	Conversations() ([]Conversation, error)
+
+	// NewTicketEntry opens the new time entry dialog of the current ticket.
+	NewTicketEntry() error
+	// EditTicketEntry opens the time entry dialog of the ticket entry of an existing conversation,
+	// it fails if another conversation is now at its position.
+	EditTicketEntry(c Conversation) error
+	// FillTicketEntry fills in the date, start time, duration and summary notes of the open dialog.
+	FillTicketEntry(te *at.TimeEntry) error
+	// SaveTicketEntry saves the open dialog and waits for it to close.
+	SaveTicketEntry() error
+
+	// NewWeekEntry opens the week entry dialog of the current task on the current week.
+	NewWeekEntry() error
+	// EditWeekEntry opens the week entry dialog of the week of an existing conversation.
+	EditWeekEntry(c Conversation) error
+	// WeekStart returns the label of the first day of the week shown in the week entry dialog.
+	WeekStart() (string, error)
+	// PreviousWeek moves the week entry dialog to the previous week.
+	PreviousWeek() error
+	// NextWeek moves the week entry dialog to the next week.
+	NextWeek() error
+	// EditWeek starts editing the days of the week, on Sunday.
+	EditWeek() error
+	// FillDay fills in the duration and summary notes of the day being edited.
+	FillDay(te *at.TimeEntry) error
+	// NextDay moves on to edit the next day.
+	NextDay() error
+	// SaveWeek saves the week and waits for the week entry dialog to close.
+	SaveWeek() error
+
+	// OpenTimesheet navigates to the timesheet of the week starting on the Sunday.
+	OpenTimesheet(week time.Time) error
+	// TimesheetStatus returns the status of the open timesheet.
+	TimesheetStatus() (at.TimesheetStatus, error)
+	// SubmitTimesheet submits the open timesheet for approval and waits for it to be submitted.
+	SubmitTimesheet() error
+	// RecallTimesheet recalls the open timesheet and waits for it to be open again.
+	RecallTimesheet() error
+	// TimesheetItems lists the tickets and tasks with time on the open timesheet.
+	TimesheetItems() ([]TimesheetItem, error)
+}
+
+// Conversation is a conversation shown on the detail page of a ticket or task.
+type Conversation struct {
+	Index  int    // Position of the conversation on the page.
+	Author string // Display name of the resource who added it.
+	Title  string // Date and time details, starting with the date in the user's date format.
+	Text   string // Full text of the conversation, including the summary notes.
+	Body   string // Summary notes of the conversation.
+}
+
+// TimesheetItem is a ticket or task with time on a timesheet.
+type TimesheetItem struct {
+	Id       int
+	IsTicket bool
+}
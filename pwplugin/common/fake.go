@@ -0,0 +1,493 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+// FakeEntry is a time entry kept by the FakeDriver.
+type FakeEntry struct {
+	Id        int
+	IsTicket  bool
+	Date      time.Time
+	StartTime string
+	Duration  float32
+	Notes     string
+	Author    string
+
+	EndTime       string
+	Role          string
+	WorkType      string
+	BillingCode   string
+	InternalNotes string
+	ShowOnInvoice *bool
+	NonBillable   *bool
+	TicketStatus  string // Status the ticket was changed to when the entry was saved.
+}
+
+// fillOptional copies the optional fields that are set on the entry, like AutoTask the other
+// fields are kept.
+func (e *FakeEntry) fillOptional(te *at.TimeEntry) {
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+
+	set(&e.Role, te.Role)
+	set(&e.WorkType, te.WorkType)
+	set(&e.BillingCode, te.BillingCode)
+	set(&e.InternalNotes, te.InternalNotes)
+
+	if te.ShowOnInvoice != nil {
+		e.ShowOnInvoice = te.ShowOnInvoice
+	}
+	if te.NonBillable != nil {
+		e.NonBillable = te.NonBillable
+	}
+}
+
+// FakeDriver is an in-memory driver for testing the capture logic without a browser. It keeps
+// the saved time entries and follows the state of the ticket and week entry dialogs, returning
+// an error when an operation isn't possible in AutoTask, e.g. filling in a day of another week.
+type FakeDriver struct {
+	DisplayName string           // Author of the entries that are saved.
+	DateFormat  string           // Date format of the user's AutoTask profile.
+	DayFormat   string           // Day format shown in the week entry dialog.
+	Today       time.Time        // The new week entry dialog opens on the week of this date.
+	Entries     []FakeEntry      // Existing and saved time entries, dated at midnight UTC.
+	Fail        map[string]error // Errors to return by method name, e.g. "SaveTicketEntry".
+	Calls       []string         // Names of the methods that were called, in order.
+
+	// Timesheets is the status of the timesheets by the Sunday of their week, weeks that aren't
+	// in it are open. Time can't be saved in a submitted or approved week.
+	Timesheets map[time.Time]at.TimesheetStatus
+
+	id       int
+	isTicket bool
+	opened   bool
+	ticket   *FakeEntry    // Entry of the open ticket dialog.
+	edit     int           // Index in Entries of the entry edited in the ticket dialog, -1 for a new entry.
+	week     time.Time     // Sunday of the open week entry dialog, zero if it is closed.
+	days     [7]*FakeEntry // Days of the open week entry dialog.
+	day      int           // Day being edited, -1 if editing hasn't started.
+	sheet    time.Time     // Sunday of the open timesheet, zero if none is open.
+}
+
+// NewFakeDriver returns a fake driver with the default formats, opening new weeks on today.
+func NewFakeDriver(displayName string) *FakeDriver {
+	now := time.Now().UTC()
+
+	return &FakeDriver{
+		DisplayName: displayName,
+		DateFormat:  "2006/01/02",
+		DayFormat:   "Mon 01/02",
+		Today:       time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// call records a call and returns the configured error of the method.
+func (d *FakeDriver) call(name string) error {
+	d.Calls = append(d.Calls, name)
+	return d.Fail[name]
+}
+
+// Saved returns the entries ordered by ID and date.
+func (d *FakeDriver) Saved() []FakeEntry {
+	result := append([]FakeEntry(nil), d.Entries...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Id != result[j].Id {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+func (d *FakeDriver) OpenTicket(id int) error {
+	return d.open("OpenTicket", id, true)
+}
+
+func (d *FakeDriver) OpenTask(id int) error {
+	return d.open("OpenTask", id, false)
+}
+
+func (d *FakeDriver) open(name string, id int, isTicket bool) error {
+	if err := d.call(name); err != nil {
+		return err
+	}
+
+	d.id, d.isTicket, d.opened = id, isTicket, true
+	d.ticket, d.week, d.sheet = nil, time.Time{}, time.Time{}
+
+	return nil
+}
+
+// conversations returns the indexes of the entries of the open page, newest first like AutoTask.
+func (d *FakeDriver) conversations() []int {
+	var result []int
+	for i := len(d.Entries) - 1; i >= 0; i-- {
+		if e := d.Entries[i]; e.Id == d.id && e.IsTicket == d.isTicket {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+func (d *FakeDriver) Conversations() ([]Conversation, error) {
+	if err := d.call("Conversations"); err != nil {
+		return nil, err
+	}
+	if !d.opened {
+		return nil, fmt.Errorf("conversations: no ticket or task is open")
+	}
+
+	var result []Conversation
+	for i, index := range d.conversations() {
+		e := d.Entries[index]
+		title := d.title(e)
+		result = append(result, Conversation{Index: i, Author: e.Author, Title: title, Text: title + "\n" + e.Notes, Body: e.Notes})
+	}
+
+	return result, nil
+}
+
+// title returns the date and time details shown for the conversation of an entry.
+func (d *FakeDriver) title(e FakeEntry) string {
+	title := e.Date.Format(d.DateFormat)
+	if e.IsTicket {
+		title += " " + e.StartTime
+	}
+
+	return title + fmt.Sprintf(" (%.2f hours)", e.Duration)
+}
+
+func (d *FakeDriver) NewTicketEntry() error {
+	if err := d.call("NewTicketEntry"); err != nil {
+		return err
+	}
+	if !d.opened || !d.isTicket {
+		return fmt.Errorf("newTicketEntry: no ticket is open")
+	}
+
+	d.ticket = &FakeEntry{Id: d.id, IsTicket: true, Author: d.DisplayName}
+	d.edit = -1
+
+	return nil
+}
+
+func (d *FakeDriver) EditTicketEntry(c Conversation) error {
+	if err := d.call("EditTicketEntry"); err != nil {
+		return err
+	}
+	if !d.opened || !d.isTicket {
+		return fmt.Errorf("editTicketEntry: no ticket is open")
+	}
+
+	convs := d.conversations()
+	if c.Index < 0 || c.Index >= len(convs) {
+		return fmt.Errorf("editTicketEntry: no conversation %d", c.Index)
+	}
+
+	entry := d.Entries[convs[c.Index]]
+	if title := d.title(entry); title != c.Title {
+		return fmt.Errorf("editTicketEntry: expected conversation %q, found %q", c.Title, title)
+	}
+	if entry.Author != d.DisplayName {
+		return fmt.Errorf("editTicketEntry: the entry of %v can't be edited", entry.Author)
+	}
+
+	d.edit = convs[c.Index]
+	d.ticket = &entry
+
+	return nil
+}
+
+func (d *FakeDriver) FillTicketEntry(te *at.TimeEntry) error {
+	if err := d.call("FillTicketEntry"); err != nil {
+		return err
+	}
+	if d.ticket == nil {
+		return fmt.Errorf("fillTicketEntry: the time entry dialog is not open")
+	}
+
+	date, err := time.Parse(d.DateFormat, te.DateStr)
+	if err != nil {
+		return fmt.Errorf("fillTicketEntry: invalid date: %v", err)
+	}
+
+	d.ticket.Date = date
+	d.ticket.StartTime = te.StartTimeStr
+	d.ticket.Duration = float32(te.DurationHours) + te.DurationMinutes/60
+	d.ticket.Notes = te.Notes()
+	d.ticket.fillOptional(te)
+
+	if te.EndTimeStr != "" {
+		d.ticket.EndTime = te.EndTimeStr
+	}
+	if te.TicketStatus != "" {
+		d.ticket.TicketStatus = te.TicketStatus
+	}
+
+	return nil
+}
+
+func (d *FakeDriver) SaveTicketEntry() error {
+	if err := d.call("SaveTicketEntry"); err != nil {
+		return err
+	}
+	if d.ticket == nil {
+		return fmt.Errorf("saveTicketEntry: the time entry dialog is not open")
+	}
+	if status := d.timesheet(d.ticket.Date); status.Locked() {
+		return fmt.Errorf("saveTicketEntry: the timesheet of the week is %v", status)
+	}
+
+	if d.edit >= 0 {
+		d.Entries[d.edit] = *d.ticket
+	} else {
+		d.Entries = append(d.Entries, *d.ticket)
+	}
+	d.ticket = nil
+
+	return nil
+}
+
+func (d *FakeDriver) NewWeekEntry() error {
+	if err := d.call("NewWeekEntry"); err != nil {
+		return err
+	}
+	if !d.opened || d.isTicket {
+		return fmt.Errorf("newWeekEntry: no task is open")
+	}
+
+	d.openWeek(at.SundayOfTheWeek(d.Today))
+
+	return nil
+}
+
+func (d *FakeDriver) EditWeekEntry(c Conversation) error {
+	if err := d.call("EditWeekEntry"); err != nil {
+		return err
+	}
+
+	convs := d.conversations()
+	if c.Index < 0 || c.Index >= len(convs) {
+		return fmt.Errorf("editWeekEntry: no conversation %d", c.Index)
+	}
+
+	d.openWeek(at.SundayOfTheWeek(d.Entries[convs[c.Index]].Date))
+
+	return nil
+}
+
+// openWeek opens the week entry dialog, loading the user's existing entries of the week.
+func (d *FakeDriver) openWeek(sunday time.Time) {
+	d.week = sunday
+	d.day = -1
+	d.days = [7]*FakeEntry{}
+
+	for _, e := range d.Entries {
+		if e.Id == d.id && !e.IsTicket && e.Author == d.DisplayName {
+			if i := int(e.Date.Sub(sunday).Hours() / 24); i >= 0 && i < 7 {
+				day := e
+				d.days[i] = &day
+			}
+		}
+	}
+}
+
+func (d *FakeDriver) WeekStart() (string, error) {
+	if err := d.call("WeekStart"); err != nil {
+		return "", err
+	}
+	if d.week.IsZero() {
+		return "", fmt.Errorf("weekStart: the week entry dialog is not open")
+	}
+
+	return d.week.Format(d.DayFormat), nil
+}
+
+func (d *FakeDriver) PreviousWeek() error {
+	return d.moveWeek("PreviousWeek", -7)
+}
+
+func (d *FakeDriver) NextWeek() error {
+	return d.moveWeek("NextWeek", 7)
+}
+
+func (d *FakeDriver) moveWeek(name string, days int) error {
+	if err := d.call(name); err != nil {
+		return err
+	}
+	if d.week.IsZero() || d.day >= 0 {
+		return fmt.Errorf("%v: the week can't be changed", name)
+	}
+
+	d.openWeek(d.week.AddDate(0, 0, days))
+
+	return nil
+}
+
+func (d *FakeDriver) EditWeek() error {
+	if err := d.call("EditWeek"); err != nil {
+		return err
+	}
+	if d.week.IsZero() {
+		return fmt.Errorf("editWeek: the week entry dialog is not open")
+	}
+
+	d.day = 0
+
+	return nil
+}
+
+func (d *FakeDriver) FillDay(te *at.TimeEntry) error {
+	if err := d.call("FillDay"); err != nil {
+		return err
+	}
+	if d.day < 0 {
+		return fmt.Errorf("fillDay: no day is being edited")
+	}
+
+	date := d.week.AddDate(0, 0, d.day)
+	if te.Date.Year() != date.Year() || te.Date.YearDay() != date.YearDay() {
+		return fmt.Errorf("fillDay: entry of %v filled in on %v", te.Date.Format(time.DateOnly), date.Format(time.DateOnly))
+	}
+
+	d.days[d.day] = &FakeEntry{Id: d.id, Date: date, Duration: te.Duration, Notes: te.Notes(), Author: d.DisplayName}
+	d.days[d.day].fillOptional(te)
+
+	return nil
+}
+
+func (d *FakeDriver) NextDay() error {
+	if err := d.call("NextDay"); err != nil {
+		return err
+	}
+	if d.day < 0 || d.day >= 6 {
+		return fmt.Errorf("nextDay: there is no next day")
+	}
+
+	d.day++
+
+	return nil
+}
+
+func (d *FakeDriver) SaveWeek() error {
+	if err := d.call("SaveWeek"); err != nil {
+		return err
+	}
+	if d.day < 0 {
+		return fmt.Errorf("saveWeek: no day was edited")
+	}
+	if status := d.timesheet(d.week); status.Locked() {
+		return fmt.Errorf("saveWeek: the timesheet of the week is %v", status)
+	}
+
+	// Saving replaces the user's entries of the week
+	var kept []FakeEntry
+	for _, e := range d.Entries {
+		i := int(e.Date.Sub(d.week).Hours() / 24)
+		if e.Id != d.id || e.IsTicket || e.Author != d.DisplayName || i < 0 || i >= 7 {
+			kept = append(kept, e)
+		}
+	}
+
+	for _, day := range d.days {
+		if day != nil {
+			kept = append(kept, *day)
+		}
+	}
+
+	d.Entries = kept
+	d.week = time.Time{}
+
+	return nil
+}
+
+// timesheet returns the status of the timesheet of the week of the date.
+func (d *FakeDriver) timesheet(date time.Time) at.TimesheetStatus {
+	if status, ok := d.Timesheets[at.SundayOfTheWeek(date)]; ok {
+		return status
+	}
+
+	return at.TimesheetOpen
+}
+
+func (d *FakeDriver) OpenTimesheet(week time.Time) error {
+	if err := d.call("OpenTimesheet"); err != nil {
+		return err
+	}
+
+	d.opened, d.ticket, d.week = false, nil, time.Time{}
+	d.sheet = at.SundayOfTheWeek(week)
+
+	return nil
+}
+
+func (d *FakeDriver) TimesheetStatus() (at.TimesheetStatus, error) {
+	if err := d.call("TimesheetStatus"); err != nil {
+		return at.TimesheetUnknown, err
+	}
+	if d.sheet.IsZero() {
+		return at.TimesheetUnknown, fmt.Errorf("timesheetStatus: no timesheet is open")
+	}
+
+	return d.timesheet(d.sheet), nil
+}
+
+func (d *FakeDriver) SubmitTimesheet() error {
+	return d.setTimesheet("SubmitTimesheet", at.TimesheetOpen, at.TimesheetSubmitted)
+}
+
+func (d *FakeDriver) RecallTimesheet() error {
+	return d.setTimesheet("RecallTimesheet", at.TimesheetSubmitted, at.TimesheetOpen)
+}
+
+// setTimesheet changes the status of the open timesheet, which must have the status from.
+func (d *FakeDriver) setTimesheet(name string, from, to at.TimesheetStatus) error {
+	if err := d.call(name); err != nil {
+		return err
+	}
+	if d.sheet.IsZero() {
+		return fmt.Errorf("%v: no timesheet is open", name)
+	}
+	if status := d.timesheet(d.sheet); status != from {
+		return fmt.Errorf("%v: the timesheet is %v", name, status)
+	}
+
+	if d.Timesheets == nil {
+		d.Timesheets = map[time.Time]at.TimesheetStatus{}
+	}
+	d.Timesheets[d.sheet] = to
+
+	return nil
+}
+
+func (d *FakeDriver) TimesheetItems() ([]TimesheetItem, error) {
+	if err := d.call("TimesheetItems"); err != nil {
+		return nil, err
+	}
+	if d.sheet.IsZero() {
+		return nil, fmt.Errorf("timesheetItems: no timesheet is open")
+	}
+
+	seen := map[TimesheetItem]bool{}
+
+	var result []TimesheetItem
+	for _, e := range d.Saved() {
+		item := TimesheetItem{Id: e.Id, IsTicket: e.IsTicket}
+		if i := int(e.Date.Sub(d.sheet).Hours() / 24); e.Author == d.DisplayName && i >= 0 && i < 7 && !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
@@ -1,33 +1,35 @@
 package common
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-func InitPlaywright(install bool, useBrowserType string, headless bool) (playwright.Browser, error) {
+// InitPlaywright starts playwright and launches the browser, both must be stopped when no longer needed.
+func InitPlaywright(install bool, useBrowserType string, headless bool) (*playwright.Playwright, playwright.Browser, error) {
 
-	log.Println("Initiating playwright")
+	slog.Info("Initiating playwright", "browser", useBrowserType, "headless", headless)
 
 	runOpts := playwright.RunOptions{
 		Browsers: []string{useBrowserType},
-		Verbose:  true,
+		Verbose:  slog.Default().Enabled(context.Background(), slog.LevelDebug),
 	}
 
 	if install {
-		log.Println("Installing playwright")
+		slog.Debug("Installing playwright")
 		err := playwright.Install(&runOpts)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		log.Println("Installed playwright")
+		slog.Debug("Installed playwright")
 	}
 
 	pw, err := playwright.Run(&runOpts)
 	if err != nil {
-		return nil, fmt.Errorf("could not start playwright: %v", err)
+		return nil, nil, fmt.Errorf("could not start playwright: %v", err)
 	}
 
 	browserOpts := playwright.BrowserTypeLaunchOptions{
@@ -47,8 +49,9 @@ func InitPlaywright(install bool, useBrowserType string, headless bool) (playwri
 	browser, err := browserType.Launch(browserOpts)
 
 	if err != nil {
-		return nil, fmt.Errorf("could not launch browser: %v", err)
+		pw.Stop()
+		return nil, nil, fmt.Errorf("could not launch browser: %v", err)
 	}
 
-	return browser, nil
+	return pw, browser, nil
 }
@@ -0,0 +1,473 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/playwright-community/playwright-go"
+)
+
+// NewPlaywrightDriver returns a driver automating AutoTask in a browser page using the selectors.
+func NewPlaywrightDriver(page playwright.Page, selectors Selectors) Driver {
+	return &playwrightDriver{page: page, sel: selectors}
+}
+
+// timesheetTimeout is the time in milliseconds a timesheet is given to load, a timesheet that
+// can't be opened shouldn't hold up the capture for long.
+const timesheetTimeout = 10000
+
+type playwrightDriver struct {
+	page playwright.Page
+	sel  Selectors
+}
+
+// locator returns a locator for a selector of the catalogue.
+func (d *playwrightDriver) locator(name string) playwright.Locator {
+	return d.page.Locator(d.sel.Get(name))
+}
+
+func (d *playwrightDriver) OpenTicket(id int) error {
+	return d.openDetail(fmt.Sprintf(at.URI_TICKET_DETAIL, at.BaseURL, id))
+}
+
+func (d *playwrightDriver) OpenTask(id int) error {
+	return d.openDetail(fmt.Sprintf(at.URI_TASK_DETAIL, at.BaseURL, id))
+}
+
+// openDetail navigates to a detail page and waits for the conversations to load.
+func (d *playwrightDriver) openDetail(uri string) error {
+	_, err := d.page.Goto(uri)
+	if err != nil {
+		return fmt.Errorf("openDetail: could not goto %v: %v", uri, err)
+	}
+
+	slog.Debug("Waiting for conversation details to load")
+
+	err = d.page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+		State:   playwright.LoadStateNetworkidle,
+		Timeout: playwright.Float(5000),
+	})
+
+	if alertVisible, _ := d.locator(SelectorAlertDialog).IsVisible(); alertVisible {
+		d.locator(SelectorAlertOk).Click()
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "timeout") {
+			slog.Debug("Timeout waiting for first conversation details to load")
+		} else {
+			return fmt.Errorf("openDetail: could not find details: %v", err)
+		}
+	}
+
+	slog.Debug("Conversations Loaded")
+
+	return nil
+}
+
+func (d *playwrightDriver) Conversations() ([]Conversation, error) {
+	convs, err := d.locator(SelectorConversation).All()
+	if err != nil {
+		return nil, fmt.Errorf("conversations: could not find conversations: %v", err)
+	}
+
+	slog.Debug("Found conversations", "count", len(convs))
+
+	var result []Conversation
+	for i, conv := range convs {
+		author, err := conv.Locator(d.sel.Get(SelectorConvAuthor)).TextContent()
+		if err != nil {
+			slog.Warn("conversations: could not find author TextContent", "error", err)
+			continue
+		}
+
+		title, err := conv.Locator(d.sel.Get(SelectorConvTitle)).TextContent()
+		if err != nil {
+			slog.Warn("conversations: could not find timeDetail TextContent", "error", err)
+			continue
+		}
+
+		text, err := conv.TextContent()
+		if err != nil {
+			slog.Warn("conversations: could not find conversation TextContent", "error", err)
+			continue
+		}
+
+		// Not every conversation has a body, e.g. a note, don't wait for it
+		var body string
+		bodies := conv.Locator(d.sel.Get(SelectorConvBody))
+		if count, _ := bodies.Count(); count > 0 {
+			body, err = bodies.First().InnerText(playwright.LocatorInnerTextOptions{Timeout: playwright.Float(5000)})
+			if err != nil {
+				slog.Warn("conversations: could not read the body", "error", err)
+			}
+		}
+
+		result = append(result, Conversation{Index: i, Author: author, Title: title, Text: text, Body: body})
+	}
+
+	return result, nil
+}
+
+func (d *playwrightDriver) NewTicketEntry() error {
+	d.locator(SelectorTicketNewEntry).Click()
+	err := d.locator(SelectorActiveDialog).WaitFor()
+	if err != nil {
+		return fmt.Errorf("newTicketEntry: could not find dialog: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) EditTicketEntry(c Conversation) error {
+	conv := d.locator(SelectorConversation).Nth(c.Index)
+
+	// Conversations move down when an entry is saved, don't edit another entry than the one read
+	title, err := conv.Locator(d.sel.Get(SelectorConvTitle)).TextContent()
+	if err != nil {
+		return fmt.Errorf("editTicketEntry: could not find the conversation: %v", err)
+	}
+	if title != c.Title {
+		return fmt.Errorf("editTicketEntry: expected conversation %q, found %q", c.Title, title)
+	}
+
+	err = conv.Locator(d.sel.Get(SelectorConvActions)).Nth(3).Click()
+	if err != nil {
+		return fmt.Errorf("editTicketEntry: could not click edit button: %v", err)
+	}
+
+	err = d.locator(SelectorActiveDialog).WaitFor()
+	if err != nil {
+		return fmt.Errorf("editTicketEntry: could not find dialog: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) FillTicketEntry(te *at.TimeEntry) error {
+	if err := d.locator(SelectorTicketDate).Fill(te.DateStr); err != nil {
+		return fmt.Errorf("fillTicketEntry: could not fill date: %v", err)
+	}
+	if err := d.locator(SelectorTicketStartTime).Fill(te.StartTimeStr); err != nil {
+		return fmt.Errorf("fillTicketEntry: could not fill start time: %v", err)
+	}
+
+	inputs := d.locator(SelectorTicketDuration)
+
+	if err := inputs.First().Fill(te.DurationHoursStr); err != nil {
+		return fmt.Errorf("fillTicketEntry: could not fill hours: %v", err)
+	}
+
+	if err := inputs.Nth(1).Fill(te.DurationMinutesStr); err != nil {
+		return fmt.Errorf("fillTicketEntry: could not fill minutes: %v", err)
+	}
+
+	if err := d.locator(SelectorTicketSummary).Fill(te.Notes()); err != nil {
+		return fmt.Errorf("fillTicketEntry: could not fill summary: %v", err)
+	}
+
+	// AutoTask moves the end time when the duration changes, so it is filled in after the duration
+	if te.EndTimeStr != "" {
+		if err := d.locator(SelectorTicketEndTime).Fill(te.EndTimeStr); err != nil {
+			return fmt.Errorf("fillTicketEntry: could not fill end time: %v", err)
+		}
+	}
+
+	if err := d.fillOptional(te, ticketOptional); err != nil {
+		return fmt.Errorf("fillTicketEntry: %v", err)
+	}
+
+	if te.TicketStatus != "" {
+		if err := d.choose(SelectorTicketStatus, te.TicketStatus); err != nil {
+			return fmt.Errorf("fillTicketEntry: could not change ticket status: %v", err)
+		}
+	}
+
+	d.page.WaitForTimeout(1000) // Forced wait to allow the page to catch up
+
+	return nil
+}
+
+func (d *playwrightDriver) SaveTicketEntry() error {
+	err := d.locator(SelectorTicketSave).Click()
+	if err != nil {
+		return fmt.Errorf("saveTicketEntry: could not click save button: %v", err)
+	}
+
+	slog.Debug("clicked save button")
+
+	err = d.locator(SelectorActiveDialog).WaitFor(playwright.LocatorWaitForOptions{
+		State: playwright.WaitForSelectorStateDetached,
+	})
+	if err != nil {
+		return fmt.Errorf("saveTicketEntry: could not wait for dialog to close: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) NewWeekEntry() error {
+	if err := d.locator(SelectorTaskNewEntry).Click(); err != nil {
+		return fmt.Errorf("newWeekEntry: could not click new time entry button: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) EditWeekEntry(c Conversation) error {
+	conv := d.locator(SelectorConversation).Nth(c.Index)
+
+	err := conv.Locator(d.sel.Get(SelectorConvActions)).Nth(3).Click()
+	if err != nil {
+		return fmt.Errorf("editWeekEntry: could not click edit button: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) WeekStart() (string, error) {
+	s, err := d.locator(SelectorWeekLabel).First().TextContent()
+	if err != nil {
+		return "", fmt.Errorf("weekStart: could not find pageDateLabel: %v", err)
+	}
+
+	return s, nil
+}
+
+func (d *playwrightDriver) PreviousWeek() error {
+	d.locator(SelectorWeekPrevious).Click()
+	return d.locator(SelectorLoadingIndicator).WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateDetached})
+}
+
+func (d *playwrightDriver) NextWeek() error {
+	d.locator(SelectorWeekNext).Click()
+	return d.locator(SelectorLoadingIndicator).WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateDetached})
+}
+
+func (d *playwrightDriver) EditWeek() error {
+	err := d.locator(SelectorActiveDialog).WaitFor()
+	if err != nil {
+		return fmt.Errorf("editWeek: could not find weekEntryDialog: %v", err)
+	}
+
+	// Click Sunday's edit button
+	err = d.locator(SelectorWeekEditDay).First().Click()
+	if err != nil {
+		return fmt.Errorf("editWeek: could not click Sunday's edit button: %v", err)
+	}
+
+	err = d.locator(SelectorDayNext).WaitFor()
+	if err != nil {
+		return fmt.Errorf("editWeek: could not find timeEntryDialog: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) FillDay(te *at.TimeEntry) error {
+	err := d.locator(SelectorDayDuration).Fill(strconv.FormatFloat(float64(te.Duration), 'f', -1, 32))
+	if err != nil {
+		return fmt.Errorf("fillDay: could not fill in duration: %v", err)
+	}
+
+	err = d.locator(SelectorDaySummary).Fill(te.Notes())
+	if err != nil {
+		return fmt.Errorf("fillDay: could not fill in summary notes: %v", err)
+	}
+
+	err = d.fillOptional(te, dayOptional)
+	if err != nil {
+		return fmt.Errorf("fillDay: %v", err)
+	}
+
+	d.page.WaitForTimeout(1000)
+
+	return nil
+}
+
+// optionalSelectors names the selectors of the optional fields in a dialog.
+type optionalSelectors struct {
+	role, workType, billingCode, internalNotes, showOnInvoice, nonBillable string
+}
+
+var (
+	ticketOptional = optionalSelectors{SelectorTicketRole, SelectorTicketWorkType, SelectorTicketBilling, SelectorTicketInternal, SelectorTicketInvoice, SelectorTicketBillable}
+	dayOptional    = optionalSelectors{SelectorDayRole, SelectorDayWorkType, SelectorDayBilling, SelectorDayInternal, SelectorDayInvoice, SelectorDayBillable}
+)
+
+// fillOptional fills in the optional fields that are set on the entry, the other fields keep
+// the defaults of AutoTask.
+func (d *playwrightDriver) fillOptional(te *at.TimeEntry, s optionalSelectors) error {
+	choices := []struct{ selector, name, value string }{
+		{s.role, "role", te.Role},
+		{s.workType, "work type", te.WorkType},
+		{s.billingCode, "billing code", te.BillingCode},
+	}
+	for _, c := range choices {
+		if c.value == "" {
+			continue
+		}
+		if err := d.choose(c.selector, c.value); err != nil {
+			return fmt.Errorf("could not fill %v: %v", c.name, err)
+		}
+	}
+
+	if te.InternalNotes != "" {
+		if err := d.locator(s.internalNotes).Fill(te.InternalNotes); err != nil {
+			return fmt.Errorf("could not fill internal notes: %v", err)
+		}
+	}
+
+	flags := []struct {
+		selector, name string
+		value          *bool
+	}{
+		{s.showOnInvoice, "show on invoice", te.ShowOnInvoice},
+		{s.nonBillable, "non-billable", te.NonBillable},
+	}
+	for _, f := range flags {
+		if f.value == nil {
+			continue
+		}
+		if err := d.locator(f.selector).SetChecked(*f.value); err != nil {
+			return fmt.Errorf("could not set %v: %v", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// choose picks a value of a drop-down by typing it and confirming the match with Enter.
+func (d *playwrightDriver) choose(name, value string) error {
+	l := d.locator(name)
+	if err := l.Fill(value); err != nil {
+		return err
+	}
+
+	return l.Press("Enter")
+}
+
+func (d *playwrightDriver) NextDay() error {
+	err := d.locator(SelectorDayNext).Click()
+	if err != nil {
+		return fmt.Errorf("nextDay: could not click next day button: %v", err)
+	}
+
+	err = d.locator(SelectorDayNext).WaitFor()
+	if err != nil {
+		return fmt.Errorf("nextDay: could not find next day button: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) SaveWeek() error {
+	err := d.locator(SelectorDayOk).Click()
+	if err != nil {
+		return fmt.Errorf("saveWeek: could not click ok button: %v", err)
+	}
+
+	weekEntryDialog := d.locator(SelectorActiveDialog).First()
+
+	err = d.locator(SelectorWeekSave).Click()
+	if err != nil {
+		return fmt.Errorf("saveWeek: could not click save and close button: %v", err)
+	}
+
+	err = weekEntryDialog.WaitFor(playwright.LocatorWaitForOptions{
+		State: playwright.WaitForSelectorStateDetached,
+	})
+	if err != nil {
+		return fmt.Errorf("saveWeek: could not wait for dialog to close: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) OpenTimesheet(week time.Time) error {
+	uri := fmt.Sprintf(at.URI_TIMESHEET, at.BaseURL, week.Format(time.DateOnly))
+
+	_, err := d.page.Goto(uri, playwright.PageGotoOptions{Timeout: playwright.Float(timesheetTimeout)})
+	if err != nil {
+		return fmt.Errorf("openTimesheet: could not goto %v: %v", uri, err)
+	}
+
+	err = d.locator(SelectorTimesheetStatus).WaitFor(playwright.LocatorWaitForOptions{Timeout: playwright.Float(timesheetTimeout)})
+	if err != nil {
+		return fmt.Errorf("openTimesheet: could not find the timesheet status: %v", err)
+	}
+
+	return nil
+}
+
+func (d *playwrightDriver) TimesheetStatus() (at.TimesheetStatus, error) {
+	text, err := d.locator(SelectorTimesheetStatus).TextContent()
+	if err != nil {
+		return at.TimesheetUnknown, fmt.Errorf("timesheetStatus: could not find the status: %v", err)
+	}
+
+	status := at.ParseTimesheetStatus(text)
+	if status == at.TimesheetUnknown {
+		return status, fmt.Errorf("timesheetStatus: unknown status %q", strings.TrimSpace(text))
+	}
+
+	return status, nil
+}
+
+func (d *playwrightDriver) SubmitTimesheet() error {
+	return d.clickAndWait("submitTimesheet", SelectorTimesheetSubmit, SelectorTimesheetRecall)
+}
+
+func (d *playwrightDriver) RecallTimesheet() error {
+	return d.clickAndWait("recallTimesheet", SelectorTimesheetRecall, SelectorTimesheetSubmit)
+}
+
+// clickAndWait clicks a button of the timesheet and waits for the button of the next state to show.
+func (d *playwrightDriver) clickAndWait(name, button, next string) error {
+	err := d.locator(button).Click()
+	if err != nil {
+		return fmt.Errorf("%v: could not click %v: %v", name, button, err)
+	}
+
+	err = d.locator(next).WaitFor()
+	if err != nil {
+		return fmt.Errorf("%v: could not find %v: %v", name, next, err)
+	}
+
+	return nil
+}
+
+// itemRegEx finds the ticket or task ID in the address of a detail page.
+var itemRegEx = regexp.MustCompile(`(ticketID|taskID)=(\d+)`)
+
+func (d *playwrightDriver) TimesheetItems() ([]TimesheetItem, error) {
+	links, err := d.locator(SelectorTimesheetItems).All()
+	if err != nil {
+		return nil, fmt.Errorf("timesheetItems: could not find items: %v", err)
+	}
+
+	var result []TimesheetItem
+	for _, link := range links {
+		href, err := link.GetAttribute("href")
+		if err != nil {
+			slog.Warn("timesheetItems: could not get link", "error", err)
+			continue
+		}
+
+		m := itemRegEx.FindStringSubmatch(href)
+		if m == nil {
+			slog.Debug("timesheetItems: not a ticket or task", "href", href)
+			continue
+		}
+
+		id, _ := strconv.Atoi(m[2])
+		result = append(result, TimesheetItem{Id: id, IsTicket: m[1] == "ticketID"})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,229 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Names of the selectors used to automate AutoTask.
+const (
+	SelectorLoginUsername    = "login.username"
+	SelectorEntraUsername    = "entra.username"
+	SelectorEntraPassword    = "entra.password"
+	SelectorEntraNext        = "entra.next"
+	SelectorLandingRecall    = "landing.timesheet-recall"
+	SelectorProfile          = "landing.profile"
+	SelectorProfileLogout    = "landing.logout"
+	SelectorStatusOutClose   = "landing.status-out-close"
+	SelectorAlertDialog      = "detail.alert-dialog"
+	SelectorAlertOk          = "detail.alert-ok"
+	SelectorConversation     = "detail.conversation"
+	SelectorConvAuthor       = "detail.conversation-author"
+	SelectorConvTitle        = "detail.conversation-title"
+	SelectorConvActions      = "detail.conversation-actions"
+	SelectorConvBody         = "detail.conversation-body"
+	SelectorActiveDialog     = "dialog.active"
+	SelectorLoadingIndicator = "dialog.loading-indicator"
+	SelectorTicketNewEntry   = "ticket.new-entry"
+	SelectorTicketDate       = "ticket.date"
+	SelectorTicketStartTime  = "ticket.start-time"
+	SelectorTicketDuration   = "ticket.duration"
+	SelectorTicketSummary    = "ticket.summary"
+	SelectorTicketSave       = "ticket.save"
+	SelectorTicketEndTime    = "ticket.end-time"
+	SelectorTicketRole       = "ticket.role"
+	SelectorTicketWorkType   = "ticket.work-type"
+	SelectorTicketBilling    = "ticket.billing-code"
+	SelectorTicketInternal   = "ticket.internal-notes"
+	SelectorTicketInvoice    = "ticket.show-on-invoice"
+	SelectorTicketBillable   = "ticket.non-billable"
+	SelectorTicketStatus     = "ticket.status"
+	SelectorTaskNewEntry     = "task.new-entry"
+	SelectorWeekLabel        = "week.label"
+	SelectorWeekPrevious     = "week.previous"
+	SelectorWeekNext         = "week.next"
+	SelectorWeekEditDay      = "week.edit-day"
+	SelectorWeekSave         = "week.save"
+	SelectorDayDuration      = "day.duration"
+	SelectorDaySummary       = "day.summary"
+	SelectorDayNext          = "day.next"
+	SelectorDayOk            = "day.ok"
+	SelectorDayRole          = "day.role"
+	SelectorDayWorkType      = "day.work-type"
+	SelectorDayBilling       = "day.billing-code"
+	SelectorDayInternal      = "day.internal-notes"
+	SelectorDayInvoice       = "day.show-on-invoice"
+	SelectorDayBillable      = "day.non-billable"
+	SelectorTimesheetStatus  = "timesheet.status"
+	SelectorTimesheetSubmit  = "timesheet.submit"
+	SelectorTimesheetRecall  = "timesheet.recall"
+	SelectorTimesheetItems   = "timesheet.items"
+)
+
+// Pages on which the selectors are found.
+const (
+	PageLogin       = "login"
+	PageEntra       = "entra"
+	PageLanding     = "landing"
+	PageDetail      = "ticket or task"
+	PageTicket      = "ticket"
+	PageTask        = "task"
+	PageTicketEntry = "ticket entry dialog"
+	PageWeekEntry   = "week entry dialog"
+	PageDayEntry    = "day entry"
+	PageTimesheet   = "timesheet"
+)
+
+// SelectorDef describes a selector in the catalogue.
+type SelectorDef struct {
+	Name        string
+	Page        string // Page or dialog the selector is found on.
+	Default     string
+	Description string
+	Optional    bool // True if the element is only shown in some situations, e.g. when a dialog is open.
+}
+
+// selectorDefs is the catalogue of selectors with their built-in defaults.
+var selectorDefs = []SelectorDef{
+	{SelectorLoginUsername, PageLogin, "internal:role=textbox", "Username on the AutoTask login page", false},
+	{SelectorEntraUsername, PageEntra, "#i0116", "Username", false},
+	{SelectorEntraPassword, PageEntra, "#i0118", "Password", false},
+	{SelectorEntraNext, PageEntra, "#idSIButton9", "Next and Sign in button", false},
+	{SelectorLandingRecall, PageLanding, "text=Recall (Un-submit)", "Recall button, shown when the timesheet of the current week is submitted", true},
+	{SelectorProfile, PageLanding, "[data-eii='05008GVH']", "Profile menu", false},
+	{SelectorProfileLogout, PageLanding, "[data-eii='0100014V']", "Logout in the profile menu", true},
+	{SelectorStatusOutClose, PageLanding, "div.Dialog1 div.DialogTitleBarIcon", "Close button of the status out dialog shown when logging out", true},
+	{SelectorAlertDialog, PageDetail, "#AlertDialog.Active", "Alert shown when opening a ticket or task", true},
+	{SelectorAlertOk, PageDetail, "#AlertDialogOkayButton", "Okay button of the alert", true},
+	{SelectorConversation, PageDetail, "div > .ConversationChunk > .ConversationItem .Details", "Conversations of a ticket or task", false},
+	{SelectorConvAuthor, PageDetail, "div > .Author div.Text2", "Author of a conversation, within the conversation", false},
+	{SelectorConvTitle, PageDetail, "div.Title div.Text > span", "Date and time of a conversation, within the conversation", false},
+	{SelectorConvActions, PageDetail, "div.FooterActions div.LinkButton2", "Actions of a conversation, the fourth edits the time entry of a ticket or the week entry of a task", false},
+	{SelectorConvBody, PageDetail, "div.Body", "Summary notes of a conversation, within the conversation", false},
+	{SelectorActiveDialog, PageTicketEntry, "body > div.Dialog1.Dialog2.Normal.Active", "Active dialog", false},
+	{SelectorLoadingIndicator, PageWeekEntry, "#LoadingIndicator.Active", "Loading indicator shown while changing the week", true},
+	{SelectorTicketNewEntry, PageTicket, "[data-eii='000001Bb']", "New Time Entry button of a ticket", false},
+	{SelectorTicketDate, PageTicketEntry, "[data-eii='010000xs'] > input[type=text]", "Date", false},
+	{SelectorTicketStartTime, PageTicketEntry, "[data-eii='010000xt'] > input[type=text]", "Start time", false},
+	{SelectorTicketDuration, PageTicketEntry, "[data-eii='000001GH'] input[type='text']", "Duration, the hours and minutes inputs", false},
+	{SelectorTicketSummary, PageTicketEntry, "[data-eii='000001GK']  > div.Content2 > div.InputWrapper2 > div.ContentEditable2.Small", "Summary notes", false},
+	{SelectorTicketSave, PageTicketEntry, "[data-eii='010000xo']", "Save button", false},
+	{SelectorTicketEndTime, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="End Time"i`, "End time, only filled in when the entry has one", true},
+	{SelectorTicketRole, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Role"i`, "Role, only filled in when the entry has one", true},
+	{SelectorTicketWorkType, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Work Type"i`, "Work type, only filled in when the entry has one", true},
+	{SelectorTicketBilling, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Billing Code"i`, "Billing code, only filled in when the entry has one", true},
+	{SelectorTicketInternal, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Internal Notes"i`, "Internal notes, only filled in when the entry has them", true},
+	{SelectorTicketInvoice, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Show on Invoice"i`, "Show on Invoice checkbox, only set when the entry sets it", true},
+	{SelectorTicketBillable, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Non-Billable"i`, "Non-Billable checkbox, only set when the entry sets it", true},
+	{SelectorTicketStatus, PageTicketEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Status"i`, "Status of the ticket, only changed when the entry sets it", true},
+	{SelectorTaskNewEntry, PageTask, "[data-eii='00000135']", "New Time Entry button of a task", false},
+	{SelectorWeekLabel, PageWeekEntry, "body > div.Dialog1.Dialog2.Normal.Active tr.Heading > td.TextCell div.Label", "Day labels, the first is the start of the week", false},
+	{SelectorWeekPrevious, PageWeekEntry, "body > div.Dialog1.Dialog2.Normal.Active .MoveLeft", "Previous week", false},
+	{SelectorWeekNext, PageWeekEntry, "body > div.Dialog1.Dialog2.Normal.Active .MoveRight", "Next week", false},
+	{SelectorWeekEditDay, PageWeekEntry, "div.Body > div.Scrolling > table > tbody div.Icon", "Edit buttons of the days, the first is Sunday", false},
+	{SelectorWeekSave, PageWeekEntry, "[data-eii='010000p7']", "Save and Close button", false},
+	{SelectorDayDuration, PageDayEntry, "[data-eii='0100014M']", "Duration", false},
+	{SelectorDaySummary, PageDayEntry, "[data-eii='0100014N']  > div.Content2 > div.InputWrapper2 > div.ContentEditable2.Small", "Summary notes", false},
+	{SelectorDayNext, PageDayEntry, "[data-eii='0100014L']", "Next Day button", false},
+	{SelectorDayOk, PageDayEntry, "[data-eii='0100014J']", "OK button", false},
+	{SelectorDayRole, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Role"i`, "Role, only filled in when the entry has one", true},
+	{SelectorDayWorkType, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Work Type"i`, "Work type, only filled in when the entry has one", true},
+	{SelectorDayBilling, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Billing Code"i`, "Billing code, only filled in when the entry has one", true},
+	{SelectorDayInternal, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Internal Notes"i`, "Internal notes, only filled in when the entry has them", true},
+	{SelectorDayInvoice, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Show on Invoice"i`, "Show on Invoice checkbox, only set when the entry sets it", true},
+	{SelectorDayBillable, PageDayEntry, `body > div.Dialog1.Dialog2.Normal.Active >> internal:label="Non-Billable"i`, "Non-Billable checkbox, only set when the entry sets it", true},
+	{SelectorTimesheetStatus, PageTimesheet, "div.TimesheetStatus", "Status of the timesheet, e.g. Open, Submitted for Approval or Approved", false},
+	{SelectorTimesheetSubmit, PageTimesheet, "text=Submit for Approval", "Submit button, shown when the timesheet is open", true},
+	{SelectorTimesheetRecall, PageTimesheet, "text=Recall (Un-submit)", "Recall button, shown when the timesheet is submitted", true},
+	{SelectorTimesheetItems, PageTimesheet, "table.TimesheetGrid a[href*='Detail.mvc']", "Links to the tickets and tasks with time in the week", true},
+}
+
+// SelectorDefs returns the catalogue of selectors.
+func SelectorDefs() []SelectorDef {
+	return append([]SelectorDef(nil), selectorDefs...)
+}
+
+// Selectors maps selector names to the selectors in use.
+type Selectors map[string]string
+
+// DefaultSelectors returns the built-in selectors.
+func DefaultSelectors() Selectors {
+	s := Selectors{}
+	for _, def := range selectorDefs {
+		s[def.Name] = def.Default
+	}
+
+	return s
+}
+
+// LoadSelectors returns the built-in selectors overridden by those in a YAML file, which maps
+// selector names to selectors. Without a file the built-in selectors are returned.
+func LoadSelectors(filename string) (Selectors, error) {
+	s := DefaultSelectors()
+	if filename == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read selectors file: %v", err)
+	}
+
+	var overrides map[string]string
+	err = yaml.Unmarshal(data, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse selectors file: %v", err)
+	}
+
+	err = s.Override(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("selectors file %v: %v", filename, err)
+	}
+
+	return s, nil
+}
+
+// Override replaces selectors by name, unknown names and empty selectors are an error.
+func (s Selectors) Override(overrides map[string]string) error {
+	var unknown []string
+	for name, selector := range overrides {
+		if _, ok := s[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		if strings.TrimSpace(selector) == "" {
+			return fmt.Errorf("selector %v is empty", name)
+		}
+
+		s[name] = selector
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown selectors: %v", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// Get returns a selector by name, falling back to the built-in default. Names are the Selector
+// constants, which TestSelectorConstants checks against the catalogue, so an unknown name is a
+// programming error and panics.
+func (s Selectors) Get(name string) string {
+	if selector, ok := s[name]; ok {
+		return selector
+	}
+
+	for _, def := range selectorDefs {
+		if def.Name == name {
+			return def.Default
+		}
+	}
+
+	panic("unknown selector: " + name)
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoadSelectors(t *testing.T) {
+	sel, err := LoadSelectors("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel.Get(SelectorTicketSave) != "[data-eii='010000xo']" {
+		t.Errorf("expected the default selector, got %v", sel.Get(SelectorTicketSave))
+	}
+
+	file := filepath.Join(t.TempDir(), "selectors.yaml")
+	os.WriteFile(file, []byte("ticket.save: \"[data-eii='010000zz']\"\n"), 0600)
+
+	sel, err = LoadSelectors(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sel.Get(SelectorTicketSave) != "[data-eii='010000zz']" || sel.Get(SelectorTicketDate) != DefaultSelectors()[SelectorTicketDate] {
+		t.Errorf("expected only ticket.save to be overridden, got %v", sel)
+	}
+
+	os.WriteFile(file, []byte("ticket.saev: \"#save\"\n"), 0600)
+	_, err = LoadSelectors(file)
+	if err == nil || !strings.Contains(err.Error(), "unknown selectors: ticket.saev") {
+		t.Errorf("expected an unknown selector error, got: %v", err)
+	}
+}
+
+// TestSelectorConstants checks that every Selector constant is in the catalogue, Get panics on
+// an unknown name.
+func TestSelectorConstants(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "selectors.go", nil, 0)
+	if err != nil {
+		t.Fatalf("could not parse selectors.go: %v", err)
+	}
+
+	defs := map[string]bool{}
+	for _, def := range SelectorDefs() {
+		defs[def.Name] = true
+	}
+
+	count := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 || !strings.HasPrefix(spec.Names[0].Name, "Selector") {
+			return true
+		}
+
+		lit, ok := spec.Values[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+
+		count++
+		name, _ := strconv.Unquote(lit.Value)
+		if !defs[name] {
+			t.Errorf("%v = %q is not in the catalogue", spec.Names[0].Name, name)
+		}
+		return true
+	})
+
+	if count != len(defs) {
+		t.Errorf("expected a constant for each of the %d selectors, found %d", len(defs), count)
+	}
+}
+
+func TestSelectorDefs(t *testing.T) {
+	names := map[string]bool{}
+	for _, def := range SelectorDefs() {
+		if names[def.Name] {
+			t.Errorf("duplicate selector: %v", def.Name)
+		}
+		names[def.Name] = true
+
+		if def.Default == "" || def.Page == "" {
+			t.Errorf("selector %v needs a default and a page", def.Name)
+		}
+	}
+}
@@ -1,17 +1,31 @@
 package pwplugin
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"regexp"
+	"sync"
 
 	"github.com/philipf/gt-at/at"
 	"github.com/philipf/gt-at/pwplugin/common"
 	"github.com/philipf/gt-at/pwplugin/projects"
 	"github.com/philipf/gt-at/pwplugin/servicedesk"
+	"github.com/philipf/gt-at/pwplugin/timesheets"
 	"github.com/playwright-community/playwright-go"
 )
 
+// BackendName is the name the Playwright backend is registered with.
+const BackendName = "playwright"
+
+func init() {
+	at.RegisterBackend(BackendName, func(config at.BackendConfig) (at.AutoTasker, error) {
+		return NewAutoTaskPlaywright(), nil
+	})
+}
+
 // NewAutoTaskPlaywright initializes and returns an instance of the autoTaskPlaywright.
 func NewAutoTaskPlaywright() at.AutoTasker {
 	return &autoTaskPlaywright{}
@@ -21,44 +35,237 @@ type autoTaskPlaywright struct{}
 
 // CaptureTimes captures time entries in AutoTask using playwright.
 func (atp *autoTaskPlaywright) CaptureTimes(entries at.TimeEntries, opts at.CaptureOptions) error {
-	log.Printf("Capture entries for a total of %v time entries\n", len(entries))
+	return atp.CaptureTimesContext(context.Background(), entries, opts)
+}
+
+// CaptureTimesContext captures time entries in AutoTask using playwright until the context is
+// done. Cancelling closes the browser, which aborts the page the capture is waiting for.
+func (atp *autoTaskPlaywright) CaptureTimesContext(ctx context.Context, entries at.TimeEntries, opts at.CaptureOptions) error {
+	slog.Info("Capture entries", "backend", BackendName, "entries", len(entries))
+
+	sel, err := common.LoadSelectors(opts.SelectorsFile)
+	if err != nil {
+		return err
+	}
+
+	s, err := openBrowser(ctx, opts, sel)
+	if err != nil {
+		return entries.Cancel(ctx, opts.Observer, err)
+	}
+
+	defer s.close()
+
+	if opts.EmbedMarkers {
+		entries.EmbedMarkers()
+	}
+
+	// Save the page of failed entries, the observer is passed on to the capture
+	if opts.DiagnosticsDir != "" {
+		diag, err := newDiagnostics(opts.DiagnosticsDir, s.page, opts.Observer)
+		if err != nil {
+			slog.Warn("diagnostics are disabled", "error", err)
+		} else {
+			opts.Observer = diag
+		}
+	}
+
+	// Checked before leaving the landing page, in case the timesheets of the weeks can't be opened
+	submitted := isSubmitted(s.page, sel)
+
+	d := common.WithContext(ctx, common.NewPlaywrightDriver(s.page, sel))
+
+	// Entries of submitted or approved weeks can't be captured, the other weeks still are. A dry
+	// run doesn't save anything, so the timesheets aren't opened.
+	open := entries
+	var sheets []at.Timesheet
+	if !opts.DryRun {
+		open, sheets = timesheets.Preflight(d, entries, opts.Observer)
+		slog.Debug("Checked timesheets", "weeks", len(sheets), "capturing", len(open))
+	}
+
+	// Without the status of any week, fall back to the recall button of the landing page
+	if submitted && !timesheets.Checked(sheets) {
+		slog.Warn("Timesheet already submitted, skipping")
+		for _, te := range open {
+			te.SetError(errors.New("the timesheet is submitted, recall it to capture the entry"))
+			at.NotifyEntry(opts.Observer, te)
+		}
+		return nil
+	}
+
+	// Capture the entries, the session is kept alive for the next run, use `gt-at logout` to end it
+	captureEntries(open, opts.DryRun, d, opts.UserDisplayName, opts.DateFormat, opts.DayFormat, opts.DuplicatePolicy, opts.SameDayTasks, opts.ConfirmUpdate, opts.Observer)
+
+	if ctx.Err() != nil {
+		return entries.Cancel(ctx, opts.Observer, ctx.Err())
+	}
+
+	if opts.SubmitAfterImport && !opts.DryRun {
+		timesheets.SubmitCaptured(d, entries, opts.Observer)
+	}
+
+	// Persist the session again, cookies might have been refreshed while capturing
+	s.save()
+
+	slog.Debug("End of CaptureTimes")
+
+	return nil
+}
+
+// browserSession is a browser page logged in to AutoTask.
+type browserSession struct {
+	pw       *playwright.Playwright
+	browser  playwright.Browser
+	ctx      playwright.BrowserContext
+	page     playwright.Page
+	path     string
+	username string
+	resumed  bool   // True if a stored session was reused instead of logging in.
+	traceDir string // Directory the trace is saved in when the session is closed, if tracing.
+
+	stop      func() bool // Stops closing the session when the context is done.
+	closeOnce sync.Once
+}
+
+// openBrowser starts a browser and logs in to AutoTask, reusing a stored session unless a fresh
+// login was requested. The browser is closed as soon as the context is done.
+func openBrowser(ctx context.Context, opts at.CaptureOptions, sel common.Selectors) (*browserSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Initialize playwright
-	browser, err := common.InitPlaywright(true, opts.BrowserType, opts.Headless)
+	pw, browser, err := common.InitPlaywright(true, opts.BrowserType, opts.Headless)
 	if err != nil {
-		return fmt.Errorf("could not init playwright: %v", err)
+		return nil, fmt.Errorf("could not init playwright: %v", err)
 	}
 
-	defer browser.Close()
+	s := &browserSession{pw: pw, browser: browser, username: opts.Credentials.Username}
+	s.stop = context.AfterFunc(ctx, s.close)
+
+	err = s.login(opts, sel)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *browserSession) login(opts at.CaptureOptions, sel common.Selectors) error {
+	// Reuse a stored session unless a fresh login was requested
+	var err error
+	s.path, err = SessionPath(s.username)
+	if err != nil {
+		return err
+	}
+
+	var session *storedSession
+	if !opts.FreshLogin {
+		session, err = loadSession(s.path)
+		if err != nil {
+			slog.Warn("could not load stored session, ignoring it", "error", err)
+		}
+	}
 
 	// Create new browser context
-	ctx, err := browser.NewContext(playwright.BrowserNewContextOptions{})
+	ctxOpts := playwright.BrowserNewContextOptions{}
+	if session != nil {
+		ctxOpts.StorageState = session.State.ToOptionalStorageState()
+	}
+
+	s.ctx, err = s.browser.NewContext(ctxOpts)
 	if err != nil {
 		return fmt.Errorf("could not create context: %v", err)
 	}
 
 	// Open a new page in the browser
-	page, err := ctx.NewPage()
+	s.page, err = s.ctx.NewPage()
 	if err != nil {
 		return fmt.Errorf("could not create page: %v", err)
 	}
 
+	at.Notify(opts.Observer, at.Event{Type: at.EventLoginStarted})
+
+	s.resumed = session != nil && resumeSession(s.page, session.BaseURL)
+	if !s.resumed {
+		err = login(s.page, sel, opts.StartURL, opts.Credentials, opts.Observer)
+		if err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Logged in", "resumed", s.resumed)
+	at.Notify(opts.Observer, at.Event{Type: at.EventLoggedIn})
+	at.BaseURL = at.GetBaseURL(s.page.URL())
+
+	// Started after logging in, the trace would otherwise hold the password
+	if opts.Trace && opts.DiagnosticsDir != "" {
+		err = os.MkdirAll(opts.DiagnosticsDir, 0700)
+		if err == nil {
+			err = startTrace(s.ctx)
+		}
+
+		if err != nil {
+			slog.Warn("could not start the trace", "error", err)
+		} else {
+			s.traceDir = opts.DiagnosticsDir
+		}
+	}
+
+	s.save()
+
+	return nil
+}
+
+// save stores the session for the next run, failing to do so only means logging in again.
+func (s *browserSession) save() {
+	err := saveSession(s.path, s.username, s.ctx)
+	if err != nil {
+		slog.Warn("could not save session", "error", err)
+	}
+}
+
+// close closes the browser and stops playwright, it is safe to call more than once.
+func (s *browserSession) close() {
+	s.closeOnce.Do(func() {
+		if s.stop != nil {
+			s.stop()
+		}
+
+		if s.traceDir != "" {
+			stopTrace(s.ctx, s.traceDir)
+		}
+
+		s.browser.Close()
+		s.pw.Stop()
+	})
+}
+
+// login performs the full login, navigating to AutoTask, signing in with Entra and waiting
+// for the landing page to load.
+func login(page playwright.Page, sel common.Selectors, startURL string, credentials at.Credentials, obs at.Observer) error {
+	if startURL == "" {
+		startURL = at.URI_AUTOTASK
+	}
+
 	// Navigate to AutoTask
-	err = gotoAutoTask(page, opts.Credentials.Username)
+	err := gotoAutoTask(page, sel, startURL, credentials.Username)
 	if err != nil {
 		return fmt.Errorf("could not goto autotask: %v", err)
 	}
 
 	// Log in to Entra
-	log.Printf("Login to Entra\n")
+	slog.Info("Login to Entra")
 
-	err = loginToEntra(page, opts.Credentials.Username, opts.Credentials.Password)
+	err = loginToEntra(page, sel, credentials.Username, credentials.Password)
 	if err != nil {
 		return fmt.Errorf("could not login to entra: %v", err)
 	}
 
 	// Wait for landing page after logging in (MFA might be required)
-	log.Println("Login progress, MFA might be required, waiting for AT Landing Page to load")
+	slog.Info("Login progress, MFA might be required, waiting for AT Landing Page to load")
+	at.Notify(obs, at.Event{Type: at.EventMFAWaiting})
 	urlRegEx := regexp.MustCompile(".*LandingPage")
 	err = page.WaitForURL(urlRegEx, playwright.PageWaitForURLOptions{
 		Timeout: playwright.Float(120 * 1000),
@@ -67,37 +274,23 @@ func (atp *autoTaskPlaywright) CaptureTimes(entries at.TimeEntries, opts at.Capt
 		return fmt.Errorf("could not wait for url: %v", err)
 	}
 
-	log.Println("Logged in")
-	at.BaseURL = at.GetBaseURL(page.URL())
-
-	// If timesheet is already submitted, skip capturing entries
-	if isSubmitted(page) {
-		log.Println("Timesheet already submitted, skipping")
-		return nil
-	}
-
-	// Capture the entries and then log out
-	captureEntries(entries, opts.DryRun, page, opts.UserDisplayName, opts.DateFormat, opts.DayFormat)
-	logout(page)
-
-	log.Println("End of CaptureTimes")
-
 	return nil
 }
 
-// isSubmitted checks if the timesheet is already submitted.
-func isSubmitted(page playwright.Page) bool {
-	count, err := page.GetByText("Recall (Un-submit)").Count()
+// isSubmitted checks if the landing page shows the recall button of a submitted timesheet.
+func isSubmitted(page playwright.Page, sel common.Selectors) bool {
+	recall := page.Locator(sel.Get(common.SelectorLandingRecall))
+	count, err := recall.Count()
 
 	if err != nil {
-		log.Printf("could not get count: %v\n", err)
+		slog.Error("could not get count", "error", err)
 		return false
 	}
 
-	isVisble, err := page.GetByText("Recall (Un-submit)").IsVisible()
+	isVisble, err := recall.IsVisible()
 
 	if err != nil {
-		log.Printf("could not visible: %v\n", err)
+		slog.Error("could not visible", "error", err)
 		return false
 	}
 
@@ -109,8 +302,8 @@ func isSubmitted(page playwright.Page) bool {
 }
 
 // gotoAutoTask navigates the browser to the AutoTask URI.
-func gotoAutoTask(page playwright.Page, username string) error {
-	_, err := page.Goto(at.URI_AUTOTASK)
+func gotoAutoTask(page playwright.Page, sel common.Selectors, startURL, username string) error {
+	_, err := page.Goto(startURL)
 	if err != nil {
 		return err
 	}
@@ -122,7 +315,7 @@ func gotoAutoTask(page playwright.Page, username string) error {
 	}
 
 	// Fill in the username and proceed
-	usernameInput := page.GetByRole("textbox")
+	usernameInput := page.Locator(sel.Get(common.SelectorLoginUsername))
 	usernameInput.Fill(username)
 	usernameInput.Press("Enter")
 
@@ -132,22 +325,26 @@ func gotoAutoTask(page playwright.Page, username string) error {
 // captureEntries handles the capturing of both tickets and tasks.
 func captureEntries(entries at.TimeEntries,
 	dryRun bool,
-	page playwright.Page,
-	userDisplayName, dateFormat, dayFormat string) {
+	d common.Driver,
+	userDisplayName, dateFormat, dayFormat string,
+	policy at.DuplicatePolicy,
+	consolidation at.ConsolidationPolicy,
+	confirm func(te *at.TimeEntry) bool,
+	obs at.Observer) {
 	tickets, tasks := entries.SplitEntries()
 
 	// Only proceed if it's not a dry run
 	if !dryRun {
-		err := servicedesk.Capture(page, userDisplayName, tickets, dateFormat)
+		err := servicedesk.Capture(d, userDisplayName, tickets, dateFormat, policy, confirm, obs)
 		if err != nil {
-			log.Printf("could not capture tickets: %v\n", err)
+			slog.Error("could not capture tickets", "error", err)
 		}
 
-		err = projects.Capture(page, userDisplayName, tasks, dateFormat, dayFormat)
+		err = projects.Capture(d, userDisplayName, tasks, dateFormat, dayFormat, policy, consolidation, confirm, obs)
 		if err != nil {
-			log.Printf("could not capture tasks: %v\n", err)
+			slog.Error("could not capture tasks", "error", err)
 		}
 	} else {
-		log.Println("Dry run, skipping")
+		slog.Info("Dry run, skipping")
 	}
 }
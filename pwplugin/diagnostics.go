@@ -0,0 +1,119 @@
+package pwplugin
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/playwright-community/playwright-go"
+)
+
+// traceFile is the name of the Playwright trace in the diagnostics directory.
+const traceFile = "trace.zip"
+
+// diagnostics is an Observer saving a screenshot and the HTML of the page when an entry fails,
+// the files are added to the diagnostics of the entry. Events are passed on to the next observer.
+type diagnostics struct {
+	dir  string
+	page playwright.Page
+	next at.Observer
+
+	mu    sync.Mutex
+	count int
+	last  [sha256.Size]byte // Hash of the HTML saved last.
+	files []string          // Files saved last.
+}
+
+// newDiagnostics creates the diagnostics directory and returns the observer.
+func newDiagnostics(dir string, page playwright.Page, next at.Observer) (*diagnostics, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create diagnostics directory: %v", err)
+	}
+
+	return &diagnostics{dir: dir, page: page, next: next}, nil
+}
+
+func (d *diagnostics) OnEvent(e at.Event) {
+	if e.Type == at.EventEntryFailed && e.Entry != nil {
+		d.save(e.Entry)
+	}
+
+	at.Notify(d.next, e)
+}
+
+// save saves the page for a failed entry. When several entries fail on the same page, e.g.
+// because a ticket could not be opened, the files are saved once and shared.
+func (d *diagnostics) save(te *at.TimeEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	html, err := d.page.Content()
+	if err != nil {
+		slog.Warn("could not get the page content for diagnostics", "error", err)
+		return
+	}
+
+	hash := sha256.Sum256([]byte(html))
+	if d.files != nil && hash == d.last {
+		te.Diagnostics = d.files
+		return
+	}
+
+	d.count++
+	kind := "task"
+	if te.IsTicket {
+		kind = "ticket"
+	}
+	name := filepath.Join(d.dir, fmt.Sprintf("%03d-%v-%d", d.count, kind, te.Id))
+
+	var files []string
+
+	err = os.WriteFile(name+".html", []byte(html), 0600)
+	if err != nil {
+		slog.Warn("could not save the page content", "error", err)
+	} else {
+		files = append(files, name+".html")
+	}
+
+	_, err = d.page.Screenshot(playwright.PageScreenshotOptions{
+		Path:     playwright.String(name + ".png"),
+		FullPage: playwright.Bool(true),
+	})
+	if err != nil {
+		slog.Warn("could not save a screenshot", "error", err)
+	} else {
+		files = append(files, name+".png")
+	}
+
+	slog.Info("Saved diagnostics", "id", te.Id, "date", te.DateStr, "files", files)
+
+	d.last, d.files = hash, files
+	te.Diagnostics = files
+}
+
+// startTrace starts recording a Playwright trace of the browser context.
+func startTrace(ctx playwright.BrowserContext) error {
+	return ctx.Tracing().Start(playwright.TracingStartOptions{
+		Screenshots: playwright.Bool(true),
+		Snapshots:   playwright.Bool(true),
+		Sources:     playwright.Bool(true),
+	})
+}
+
+// stopTrace saves the Playwright trace of the browser context in the directory.
+func stopTrace(ctx playwright.BrowserContext, dir string) {
+	path := filepath.Join(dir, traceFile)
+
+	err := ctx.Tracing().Stop(path)
+	if err != nil {
+		slog.Warn("could not save the trace", "error", err)
+		return
+	}
+
+	slog.Info("Saved trace, open it with playwright show-trace", "file", path)
+}
@@ -0,0 +1,341 @@
+package pwplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/fakeat"
+)
+
+// The end-to-end tests drive a browser against the fake AutoTask server, they are skipped
+// unless GT_AT_E2E is set because Playwright and its browsers have to be installed.
+func newE2E(t *testing.T) (*fakeat.Server, at.CaptureOptions) {
+	if os.Getenv("GT_AT_E2E") == "" {
+		t.Skip("set GT_AT_E2E=1 to run the end-to-end tests")
+	}
+
+	// Keep the stored browser sessions out of the user's configuration directory
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s := fakeat.NewServer()
+	t.Cleanup(s.Close)
+	s.Username = "jo@example.com"
+	s.Password = "secret"
+
+	opts := at.CaptureOptions{
+		Credentials:     at.Credentials{Username: s.Username, Password: s.Password},
+		UserDisplayName: s.DisplayName,
+		BrowserType:     "chromium",
+		Headless:        true,
+		DateFormat:      s.DateFormat,
+		DayFormat:       s.DayFormat,
+		StartURL:        s.URL,
+	}
+
+	return s, opts
+}
+
+// thisWeek returns a day of the current week, the week entry dialog opens on the current week.
+func thisWeek(weekday time.Weekday) time.Time {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return at.SundayOfTheWeek(today).AddDate(0, 0, int(weekday))
+}
+
+func newEntries(opts at.CaptureOptions, entries ...at.RequestEntry) at.TimeEntries {
+	return at.ToTimeEntries(entries, opts.DateFormat)
+}
+
+func capture(t *testing.T, entries at.TimeEntries, opts at.CaptureOptions) {
+	t.Helper()
+
+	err := NewAutoTaskPlaywright().CaptureTimes(entries, opts)
+	if err != nil {
+		t.Fatalf("could not capture times: %v", err)
+	}
+}
+
+func assertSaved(t *testing.T, s *fakeat.Server, expected int) []fakeat.Entry {
+	t.Helper()
+
+	saved := s.Entries()
+	if len(saved) != expected {
+		t.Fatalf("expected %d entries in AutoTask but got %d: %+v", expected, len(saved), saved)
+	}
+
+	return saved
+}
+
+func TestE2ECaptureTickets(t *testing.T) {
+	s, opts := newE2E(t)
+
+	monday := thisWeek(time.Monday)
+	s.AddEntry(fakeat.Entry{Id: 100, IsTicket: true, Date: monday, StartTime: "08:00", Duration: 1, Notes: "Existing"})
+
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday, StartTime: "10:30", Duration: 0.5, Summary: "Same day"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday.AddDate(0, 0, 1), StartTime: "09:00", Duration: 1.25, Summary: "Next day"},
+		at.RequestEntry{Id: 101, IsTicket: true, Date: monday, StartTime: "13:00", Duration: 2, Summary: "Other ticket"},
+	)
+
+	capture(t, entries, opts)
+
+	if !entries[0].Exists || entries[0].Submitted {
+		t.Errorf("expected the entry on the same day to be skipped, got %v", entries[0].Status())
+	}
+	for _, e := range entries[1:] {
+		if !e.Submitted || e.Error != nil {
+			t.Errorf("expected entry %v on %v to be saved, got %v: %v", e.Id, e.DateStr, e.Status(), e.Error)
+		}
+	}
+
+	saved := assertSaved(t, s, 3)
+	if saved[1].Duration != 1.25 || saved[1].StartTime != "09:00" || saved[1].Notes != "Next day" {
+		t.Errorf("unexpected entry saved: %+v", saved[1])
+	}
+	if saved[2].Id != 101 || saved[2].Duration != 2 {
+		t.Errorf("unexpected entry saved: %+v", saved[2])
+	}
+}
+
+func TestE2ECaptureTasks(t *testing.T) {
+	s, opts := newE2E(t)
+
+	monday := thisWeek(time.Monday)
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 200, Date: monday, Duration: 0.75, Summary: "Design"},
+		at.RequestEntry{Id: 200, Date: monday.AddDate(0, 0, 2), Duration: 1.5, Summary: "Build"},
+		at.RequestEntry{Id: 201, Date: monday.AddDate(0, 0, -7), Duration: 1, Summary: "Previous week"},
+	)
+
+	capture(t, entries, opts)
+
+	for _, e := range entries {
+		if !e.Submitted || e.Error != nil {
+			t.Errorf("expected entry %v on %v to be saved, got %v: %v", e.Id, e.DateStr, e.Status(), e.Error)
+		}
+	}
+
+	saved := assertSaved(t, s, 3)
+	if !saved[0].Date.Equal(monday) || saved[0].Duration != 0.75 || saved[0].Notes != "Design" {
+		t.Errorf("unexpected entry saved: %+v", saved[0])
+	}
+	if !saved[2].Date.Equal(monday.AddDate(0, 0, -7)) {
+		t.Errorf("expected the entry to be saved in the previous week, got %+v", saved[2])
+	}
+
+	// A second import of the same week edits the existing week, reusing the stored session
+	entries = newEntries(opts, at.RequestEntry{Id: 200, Date: monday.AddDate(0, 0, 1), Duration: 2, Summary: "Test"})
+	capture(t, entries, opts)
+
+	saved = assertSaved(t, s, 4)
+	if saved[1].Duration != 2 || saved[2].Duration != 1.5 {
+		t.Errorf("expected the existing week to be extended, got %+v", saved)
+	}
+
+	if s.Logins() != 1 {
+		t.Errorf("expected the stored session to be reused, got %d logins", s.Logins())
+	}
+}
+
+func TestE2EOptionalFields(t *testing.T) {
+	s, opts := newE2E(t)
+
+	monday := thisWeek(time.Monday)
+	yes := true
+
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday, StartTime: "09:00", Duration: 1, Summary: "Fixed it",
+			EndTime: "10:15", Role: "Engineer", WorkType: "Remote", InternalNotes: "Reboot needed", NonBillable: &yes, TicketStatus: "Complete"},
+		at.RequestEntry{Id: 200, Date: monday, Duration: 2, Summary: "Design", Role: "Consultant", BillingCode: "Project", ShowOnInvoice: &yes},
+	)
+
+	capture(t, entries, opts)
+
+	// The fake moves the end time when the duration is filled in, so it is only kept if it is filled in last
+	saved := assertSaved(t, s, 2)
+	ticket, task := saved[0], saved[1]
+	if ticket.EndTime != "10:15" || ticket.Role != "Engineer" || ticket.WorkType != "Remote" || ticket.InternalNotes != "Reboot needed" ||
+		!ticket.NonBillable || ticket.TicketStatus != "Complete" {
+		t.Errorf("expected the optional fields of the ticket entry, got %+v", ticket)
+	}
+	if task.Role != "Consultant" || task.BillingCode != "Project" || !task.ShowOnInvoice || task.NonBillable {
+		t.Errorf("expected the optional fields of the task entry, got %+v", task)
+	}
+}
+
+func TestE2EFingerprints(t *testing.T) {
+	s, opts := newE2E(t)
+	opts.EmbedMarkers = true
+	opts.DuplicatePolicy = at.DuplicateWarn
+
+	tuesday := thisWeek(time.Tuesday)
+	newImport := func() at.TimeEntries {
+		return newEntries(opts,
+			at.RequestEntry{Id: 100, IsTicket: true, Date: tuesday, StartTime: "09:00", Duration: 1, Summary: "Morning"},
+			at.RequestEntry{Id: 100, IsTicket: true, Date: tuesday, StartTime: "14:00", Duration: 1, Summary: "Afternoon"},
+		)
+	}
+
+	entries := newImport()
+	capture(t, entries, opts)
+	saved := assertSaved(t, s, 2)
+	if !strings.Contains(saved[0].Notes, "[gt-at:") {
+		t.Errorf("expected the fingerprint marker in the notes, got %q", saved[0].Notes)
+	}
+
+	// Importing the same file again recognises both entries by their markers
+	entries = newImport()
+	capture(t, entries, opts)
+	assertSaved(t, s, 2)
+	for _, e := range entries {
+		if !e.Exists {
+			t.Errorf("expected entry at %v to exist", e.StartTimeStr)
+		}
+	}
+}
+
+func TestE2EUpdate(t *testing.T) {
+	s, opts := newE2E(t)
+	opts.DuplicatePolicy = at.DuplicateUpdate
+
+	monday := thisWeek(time.Monday)
+	s.AddEntry(fakeat.Entry{Id: 100, IsTicket: true, Date: monday, StartTime: "09:00", Duration: 1, Notes: "Fixing"})
+	s.AddEntry(fakeat.Entry{Id: 200, Date: monday, Duration: 2, Notes: "Design"})
+	s.AddEntry(fakeat.Entry{Id: 200, Date: monday.AddDate(0, 0, 1), Duration: 1, Notes: "Build"})
+
+	var asked int
+	opts.ConfirmUpdate = func(te *at.TimeEntry) bool {
+		asked++
+		return true
+	}
+
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday, StartTime: "09:00", Duration: 1.5, Summary: "Fixed it"},
+		at.RequestEntry{Id: 200, Date: monday, Duration: 2.5, Summary: "Design review"},
+	)
+
+	capture(t, entries, opts)
+
+	if asked != 2 {
+		t.Errorf("expected both updates to be confirmed, asked %d times", asked)
+	}
+	for _, e := range entries {
+		if !e.Updated() || e.Error != nil {
+			t.Errorf("expected entry %v to be updated, got %v: %v", e.Id, e.Status(), e.Error)
+		}
+	}
+
+	saved := assertSaved(t, s, 3)
+	if saved[0].Duration != 1.5 || saved[0].Notes != "Fixed it" || saved[0].StartTime != "09:00" {
+		t.Errorf("unexpected ticket entry: %+v", saved[0])
+	}
+	if saved[1].Duration != 2.5 || saved[1].Notes != "Design review" || saved[2].Notes != "Build" {
+		t.Errorf("unexpected task entries: %+v", saved[1:])
+	}
+}
+
+func TestE2ESubmittedTimesheet(t *testing.T) {
+	s, opts := newE2E(t)
+
+	// Only the entries of the submitted week are skipped
+	monday := thisWeek(time.Monday)
+	s.SetTimesheet(monday, at.TimesheetSubmitted)
+
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday, StartTime: "09:00", Duration: 1, Summary: "Late"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: monday.AddDate(0, 0, -7), StartTime: "09:00", Duration: 1, Summary: "Previous week"},
+	)
+	capture(t, entries, opts)
+
+	saved := assertSaved(t, s, 1)
+	if entries[0].Error == nil || entries[0].Submitted || saved[0].Notes != "Previous week" {
+		t.Errorf("expected only the entry of the open week to be captured, got %v: %v", entries[0].Status(), entries[0].Error)
+	}
+}
+
+func TestE2ESubmitTimesheet(t *testing.T) {
+	s, opts := newE2E(t)
+	opts.SubmitAfterImport = true
+
+	monday := thisWeek(time.Monday)
+	entries := newEntries(opts, at.RequestEntry{Id: 100, IsTicket: true, Date: monday, StartTime: "09:00", Duration: 1, Summary: "Done"})
+	capture(t, entries, opts)
+
+	assertSaved(t, s, 1)
+	if s.Timesheet(monday) != at.TimesheetSubmitted {
+		t.Fatalf("expected the timesheet to be submitted after the import, got %v", s.Timesheet(monday))
+	}
+
+	tasker := NewAutoTaskPlaywright().(at.Timesheeter)
+	err := tasker.RecallTimesheet(context.Background(), monday, opts)
+	if err != nil || s.Timesheet(monday) != at.TimesheetOpen {
+		t.Fatalf("expected the timesheet to be recalled, got %v: %v", s.Timesheet(monday), err)
+	}
+
+	sheets, err := tasker.Timesheets(context.Background(), []time.Time{monday}, opts)
+	if err != nil || len(sheets) != 1 || sheets[0].Status != at.TimesheetOpen {
+		t.Errorf("expected the timesheet to be open, got %+v: %v", sheets, err)
+	}
+}
+
+func TestE2EFetchTimes(t *testing.T) {
+	s, opts := newE2E(t)
+
+	monday := thisWeek(time.Monday)
+	s.AddEntry(fakeat.Entry{Id: 100, IsTicket: true, Date: monday, StartTime: "10:30", Duration: 1.5, Notes: "Fixed it"})
+	s.AddEntry(fakeat.Entry{Id: 100, IsTicket: true, Date: monday, StartTime: "08:00", Duration: 1, Notes: "Not mine", Author: "Other"})
+	s.AddEntry(fakeat.Entry{Id: 200, Date: monday.AddDate(0, 0, 1), Duration: 0.75, Notes: "Design"})
+	s.AddEntry(fakeat.Entry{Id: 300, Date: monday.AddDate(0, 0, -7), Duration: 2, Notes: "Last week"})
+
+	fetcher := NewAutoTaskPlaywright().(at.Fetcher)
+	entries, err := fetcher.FetchTimes(context.Background(), at.FetchQuery{From: monday, To: monday.AddDate(0, 0, 4)}, opts)
+	if err != nil {
+		t.Fatalf("could not fetch times: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 entries of the user in the week, got %+v", entries)
+	}
+	if entries[0].Id != 100 || entries[0].StartTimeStr != "10:30" || entries[0].Duration != 1.5 || entries[0].Summary != "Fixed it" {
+		t.Errorf("unexpected ticket entry: %+v", entries[0])
+	}
+	if entries[1].Id != 200 || entries[1].Duration != 0.75 || entries[1].Summary != "Design" {
+		t.Errorf("unexpected task entry: %+v", entries[1])
+	}
+}
+
+func TestE2EDiagnostics(t *testing.T) {
+	s, opts := newE2E(t)
+	opts.DiagnosticsDir = t.TempDir()
+	opts.Trace = true
+
+	// Two entries on the same day of a task can't be captured
+	monday := thisWeek(time.Monday)
+	entries := newEntries(opts,
+		at.RequestEntry{Id: 200, Date: monday, Duration: 1, Summary: "First"},
+		at.RequestEntry{Id: 200, Date: monday, Duration: 2, Summary: "Same day"},
+	)
+
+	capture(t, entries, opts)
+	assertSaved(t, s, 0)
+
+	for _, e := range entries {
+		if e.Error == nil || len(e.Diagnostics) != 2 {
+			t.Errorf("expected %v to fail with a screenshot and the page, got: %v, %v", e.Summary, e.Error, e.Diagnostics)
+		}
+	}
+
+	files := append(entries[0].Diagnostics, filepath.Join(opts.DiagnosticsDir, traceFile))
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("expected diagnostics file: %v", err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package fakeat
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+)
+
+// render writes a page, errors are logged as the response has already started.
+func render(w http.ResponseWriter, t *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := t.Execute(w, data)
+	if err != nil {
+		slog.Error("fakeat: could not render", "template", t.Name(), "error", err)
+	}
+}
+
+const style = `<style>
+	.Hidden { display: none; }
+	.Dialog1 { position: fixed; top: 10%; left: 10%; padding: 1em; background: #fff; border: 1px solid #999; }
+	.Button, .LinkButton2, .Icon, .MoveLeft, .MoveRight { display: inline-block; min-width: 1em; min-height: 1em; padding: 0.2em; cursor: pointer; border: 1px solid #ccc; }
+	.ContentEditable2 { min-height: 2em; min-width: 10em; border: 1px solid #ccc; }
+</style>`
+
+var authenticatePage = template.Must(template.New("authenticate").Parse(`<!DOCTYPE html>
+<html><head><title>Autotask - Authenticate</title>` + style + `</head>
+<body>
+	<form action="/login" method="get">
+		<label>Username <input type="text" name="username"></label>
+	</form>
+</body></html>`))
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><title>Sign in to your account</title>` + style + `</head>
+<body>
+	<form id="LoginForm" action="/login" method="post">
+		{{if .Error}}<div id="passwordError">{{.Error}}</div>{{end}}
+		<div id="UsernameSection">
+			<input type="email" id="i0116" name="username" value="{{.Username}}">
+		</div>
+		<div id="PasswordSection" class="Hidden">
+			<input type="password" id="i0118" name="password">
+		</div>
+		<input type="button" id="idSIButton9" value="Next" onclick="next()">
+	</form>
+	<script>
+		function next() {
+			const password = document.getElementById('PasswordSection');
+			if (password.classList.contains('Hidden')) {
+				document.getElementById('UsernameSection').classList.add('Hidden');
+				password.classList.remove('Hidden');
+				document.getElementById('idSIButton9').value = 'Sign in';
+				return;
+			}
+			document.getElementById('LoginForm').submit();
+		}
+	</script>
+</body></html>`))
+
+var landingPage = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html><head><title>Autotask - Home</title>` + style + `</head>
+<body>
+	<div data-eii="05008GVH">{{.DisplayName}}</div>
+</body></html>`))
+
+var timesheetPage = template.Must(template.New("timesheet").Parse(`<!DOCTYPE html>
+<html><head><title>Timesheet {{.Week}}</title>` + style + `</head>
+<body>
+	<div class="TimesheetStatus">{{.Label}}</div>
+	<table class="TimesheetGrid">
+		{{range .Items}}
+		<tr>{{if .IsTicket}}<td><a href="/Mvc/ServiceDesk/TicketDetail.mvc?ticketID={{.Id}}">Ticket {{.Id}}</a></td>{{else}}<td><a href="/Mvc/Projects/TaskDetail.mvc?taskID={{.Id}}">Task {{.Id}}</a></td>{{end}}<td>{{printf "%.2f" .Hours}}</td></tr>
+		{{end}}
+	</table>
+	<div class="Toolbar">
+		{{if eq .Status "open"}}<div class="Button" onclick="act('submit')">Submit for Approval</div>{{end}}
+		{{if eq .Status "submitted"}}<div class="Button" onclick="act('recall')">Recall (Un-submit)</div>{{end}}
+	</div>
+	<script>
+		async function act(action) {
+			const response = await fetch('/fake/timesheet', {
+				method: 'POST',
+				body: JSON.stringify({week: '{{.Week}}', action: action}),
+			});
+			if (response.ok) {
+				location.reload();
+			}
+		}
+	</script>
+</body></html>`))
+
+const conversations = `<div class="Conversations">
+	{{range .Conversations}}
+	<div class="ConversationChunk">
+		<div class="ConversationItem">
+			<div class="Details">
+				<div class="Author"><div class="Text2">{{.Author}}</div></div>
+				<div class="Title"><div class="Text"><span>{{.Title}}</span></div></div>
+				<div class="Body">{{.Notes}}</div>
+				<div class="FooterActions">
+					<div class="LinkButton2">Reply</div>
+					<div class="LinkButton2">Forward</div>
+					<div class="LinkButton2">Copy</div>
+					{{if .Week}}<div class="LinkButton2" onclick="openWeek('{{.Week}}')">Edit</div>{{else}}<div class="LinkButton2" onclick="openTimeEntry({{.Entry}})">Edit</div>{{end}}
+				</div>
+			</div>
+		</div>
+	</div>
+	{{end}}
+</div>`
+
+// detailInputs are the optional fields of the ticket time entry dialog and the day editor.
+const detailInputs = `<label>Role <input type="text" name="role"></label>
+	<label>Work Type <input type="text" name="workType"></label>
+	<label>Billing Code <input type="text" name="billingCode"></label>
+	<label>Internal Notes <textarea name="internalNotes"></textarea></label>
+	<label><input type="checkbox" name="showOnInvoice"> Show on Invoice</label>
+	<label><input type="checkbox" name="nonBillable"> Non-Billable</label>`
+
+var ticketPage = template.Must(template.New("ticket").Parse(`<!DOCTYPE html>
+<html><head><title>Ticket {{.Id}}</title>` + style + `</head>
+<body>
+	<div class="Toolbar"><div class="Button" data-eii="000001Bb" onclick="openTimeEntry()">New Time Entry</div></div>
+	` + conversations + `
+	<template id="TimeEntryTemplate">
+		<div class="Dialog1 Dialog2 Normal Active">
+			<div data-eii="010000xs"><input type="text" name="date"></div>
+			<div data-eii="010000xt"><input type="text" name="startTime"></div>
+			<div data-eii="000001GH"><input type="text" name="hours" oninput="recalculateEndTime(this)"><input type="text" name="minutes" oninput="recalculateEndTime(this)"></div>
+			<div data-eii="000001GK"><div class="Content2"><div class="InputWrapper2"><div class="ContentEditable2 Small" contenteditable="true"></div></div></div></div>
+			<label>End Time <input type="text" name="endTime"></label>
+			` + detailInputs + `
+			<label>Status <input type="text" name="status"></label>
+			<div class="Button" data-eii="010000xo" onclick="saveTimeEntry(this)">Save &amp; Close</div>
+		</div>
+	</template>
+	<script>
+		const id = {{.Id}};
+
+		let entry = null;
+
+		function openTimeEntry(edit) {
+			entry = edit === undefined ? null : edit;
+			document.body.appendChild(document.getElementById('TimeEntryTemplate').content.cloneNode(true));
+		}
+
+		// Like AutoTask, changing the duration moves the end time to the start time plus the duration
+		function recalculateEndTime(input) {
+			const dialog = input.closest('.Dialog1');
+			const value = name => dialog.querySelector('input[name=' + name + ']').value;
+			const [hour, minute] = value('startTime').split(':').map(Number);
+			const end = hour * 60 + minute + Number(value('hours')) * 60 + Number(value('minutes'));
+			if (isNaN(end)) {
+				return;
+			}
+			const pad = n => String(n).padStart(2, '0');
+			dialog.querySelector('input[name=endTime]').value = pad(Math.floor(end / 60) % 24) + ':' + pad(end % 60);
+		}
+
+		async function saveTimeEntry(button) {
+			const dialog = button.closest('.Dialog1');
+			const value = name => dialog.querySelector('input[name=' + name + ']').value;
+			const response = await fetch('/fake/ticket', {
+				method: 'POST',
+				body: JSON.stringify({
+					id: id,
+					entry: entry,
+					date: value('date'),
+					startTime: value('startTime'),
+					hours: value('hours'),
+					minutes: value('minutes'),
+					notes: dialog.querySelector('.ContentEditable2').innerText,
+					endTime: value('endTime'),
+					status: value('status'),
+					role: value('role'),
+					workType: value('workType'),
+					billingCode: value('billingCode'),
+					internalNotes: dialog.querySelector('textarea[name=internalNotes]').value,
+					showOnInvoice: dialog.querySelector('input[name=showOnInvoice]').checked,
+					nonBillable: dialog.querySelector('input[name=nonBillable]').checked,
+				}),
+			});
+			if (response.ok) {
+				dialog.remove();
+			}
+		}
+	</script>
+</body></html>`))
+
+var taskPage = template.Must(template.New("task").Parse(`<!DOCTYPE html>
+<html><head><title>Task {{.Id}}</title>` + style + `</head>
+<body>
+	<div class="Toolbar"><div class="Button" data-eii="00000135" onclick="openWeek('')">New Time Entry</div></div>
+	` + conversations + `
+	<script>
+		const id = {{.Id}};
+		let dialog, days, day;
+
+		const detailFields = ['role', 'workType', 'billingCode', 'internalNotes'];
+		const detailFlags = ['showOnInvoice', 'nonBillable'];
+
+		async function openWeek(week) {
+			dialog = document.createElement('div');
+			dialog.className = 'Dialog1 Dialog2 Normal Active';
+			document.body.appendChild(dialog);
+			await loadWeek(week);
+		}
+
+		async function loadWeek(week) {
+			const indicator = document.createElement('div');
+			indicator.id = 'LoadingIndicator';
+			indicator.className = 'Active';
+			indicator.innerText = 'Loading...';
+			document.body.appendChild(indicator);
+
+			const response = await fetch('/fake/week?taskID=' + id + '&week=' + week);
+			dialog.innerHTML = await response.text();
+			days = Array.from(dialog.querySelectorAll('td.Day')).map(td => ({
+				hours: td.dataset.hours,
+				notes: td.dataset.notes,
+				role: td.dataset.role,
+				workType: td.dataset.workType,
+				billingCode: td.dataset.billingCode,
+				internalNotes: td.dataset.internalNotes,
+				showOnInvoice: td.dataset.showOnInvoice === 'true',
+				nonBillable: td.dataset.nonBillable === 'true',
+			}));
+
+			indicator.remove();
+		}
+
+		function moveWeek(offset) {
+			const sunday = new Date(dialog.querySelector('[data-week]').dataset.week + 'T00:00:00Z');
+			sunday.setUTCDate(sunday.getUTCDate() + offset);
+			loadWeek(sunday.toISOString().substring(0, 10));
+		}
+
+		function editor() {
+			return dialog.querySelector('.DayEditor');
+		}
+
+		function showDay() {
+			editor().querySelector('.DayLabel').innerText = dialog.querySelectorAll('div.Label')[day].innerText;
+			editor().querySelector('[data-eii="0100014M"]').value = days[day].hours;
+			editor().querySelector('.ContentEditable2').innerText = days[day].notes;
+			for (const name of detailFields) {
+				editor().querySelector('[name=' + name + ']').value = days[day][name] || '';
+			}
+			for (const name of detailFlags) {
+				editor().querySelector('[name=' + name + ']').checked = days[day][name];
+			}
+		}
+
+		function storeDay() {
+			days[day] = {
+				hours: editor().querySelector('[data-eii="0100014M"]').value,
+				notes: editor().querySelector('.ContentEditable2').innerText,
+			};
+			for (const name of detailFields) {
+				days[day][name] = editor().querySelector('[name=' + name + ']').value;
+			}
+			for (const name of detailFlags) {
+				days[day][name] = editor().querySelector('[name=' + name + ']').checked;
+			}
+		}
+
+		function editDay(i) {
+			day = i;
+			showDay();
+			editor().classList.remove('Hidden');
+		}
+
+		function nextDay() {
+			storeDay();
+			day = Math.min(day + 1, 6);
+			showDay();
+		}
+
+		function closeDay() {
+			storeDay();
+			editor().classList.add('Hidden');
+		}
+
+		async function saveWeek() {
+			const response = await fetch('/fake/week', {
+				method: 'POST',
+				body: JSON.stringify({id: id, week: dialog.querySelector('[data-week]').dataset.week, days: days}),
+			});
+			if (response.ok) {
+				dialog.remove();
+			}
+		}
+	</script>
+</body></html>`))
+
+var weekDialog = template.Must(template.New("week").Parse(`<div class="TitleBar" data-week="{{.Week}}">
+	<div class="MoveLeft" onclick="moveWeek(-7)">&lt;</div>
+	<div class="MoveRight" onclick="moveWeek(7)">&gt;</div>
+</div>
+<div class="Body">
+	<div class="Scrolling">
+		<table>
+			<tbody>
+				<tr class="Heading">{{range .Days}}<td class="TextCell"><div class="Label">{{.Label}}</div></td>{{end}}</tr>
+				<tr>{{range $i, $d := .Days}}<td class="Day" data-hours="{{$d.Hours}}" data-notes="{{$d.Notes}}" data-role="{{$d.Role}}" data-work-type="{{$d.WorkType}}" data-billing-code="{{$d.BillingCode}}" data-internal-notes="{{$d.InternalNotes}}" data-show-on-invoice="{{$d.ShowOnInvoice}}" data-non-billable="{{$d.NonBillable}}"><div class="Icon" onclick="editDay({{$i}})">&#9998;</div> {{$d.Hours}}</td>{{end}}</tr>
+			</tbody>
+		</table>
+	</div>
+</div>
+<div class="DayEditor Hidden">
+	<div class="DayLabel"></div>
+	<input type="text" data-eii="0100014M">
+	<div data-eii="0100014N"><div class="Content2"><div class="InputWrapper2"><div class="ContentEditable2 Small" contenteditable="true"></div></div></div></div>
+	` + detailInputs + `
+	<div class="Button" data-eii="0100014L" onclick="nextDay()">Next Day</div>
+	<div class="Button" data-eii="0100014J" onclick="closeDay()">OK</div>
+</div>
+<div class="Button" data-eii="010000p7" onclick="saveWeek()">Save &amp; Close</div>`))
@@ -0,0 +1,538 @@
+// Package fakeat provides a fake AutoTask web server for testing the Playwright automation
+// without network access. It serves a fake Entra login, the landing page, the ticket and task
+// detail pages and the weekly timesheets using the same markup and data-eii attributes as
+// AutoTask, and keeps the time entries that are saved in memory.
+package fakeat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+const (
+	sessionCookie = "fakeat-session"
+
+	uriAuthenticate = "/Authenticate"
+	uriLogin        = "/login"
+	uriLandingPage  = "/Mvc/Framework/Navigation.mvc/LandingPage"
+)
+
+// Entry is a time entry saved in the fake AutoTask.
+type Entry struct {
+	Id        int       // Ticket or task ID.
+	IsTicket  bool      // True if the entry was saved on a ticket, false for a task.
+	Date      time.Time // Date of the entry, at midnight UTC.
+	StartTime string    // Start time as HH:MM, only set for tickets.
+	Duration  float32   // Duration in hours.
+	Notes     string    // Summary notes.
+	Author    string    // Display name of the resource who saved the entry.
+
+	Details
+	EndTime      string // End time as HH:MM, only set for tickets.
+	TicketStatus string // Status the ticket was changed to when the entry was saved, only set for tickets.
+}
+
+// Details are the optional fields of a time entry, filled in on both tickets and tasks.
+type Details struct {
+	Role          string `json:"role"`
+	WorkType      string `json:"workType"`
+	BillingCode   string `json:"billingCode"`
+	InternalNotes string `json:"internalNotes"`
+	ShowOnInvoice bool   `json:"showOnInvoice"`
+	NonBillable   bool   `json:"nonBillable"`
+}
+
+// Server is a fake AutoTask web server. The exported fields must be set before the first request.
+type Server struct {
+	*httptest.Server
+
+	Username    string // Username accepted by the fake Entra login, any username if empty.
+	Password    string // Password accepted by the fake Entra login, any password if empty.
+	DisplayName string // Display name of the user that logs in, used as the author of saved entries.
+	DateFormat  string // Date format of the user's AutoTask profile, defaults to 2006/01/02.
+	DayFormat   string // Day format shown in the week entry grid, defaults to Mon 01/02.
+
+	mu         sync.Mutex
+	entries    []Entry
+	sessions   map[string]bool
+	logins     int
+	timesheets map[time.Time]at.TimesheetStatus
+}
+
+// NewServer starts a fake AutoTask server, it must be closed when no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		DisplayName: "Jo Bloggs",
+		DateFormat:  "2006/01/02",
+		DayFormat:   "Mon 01/02",
+		sessions:    map[string]bool{},
+		timesheets:  map[time.Time]at.TimesheetStatus{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleRoot)
+	mux.HandleFunc(uriAuthenticate, s.handleAuthenticate)
+	mux.HandleFunc(uriLogin, s.handleLogin)
+	mux.HandleFunc("/Mvc/Framework/Navigation.mvc/", s.authenticated(s.handleLanding))
+	mux.HandleFunc("/Mvc/ServiceDesk/TicketDetail.mvc", s.authenticated(s.handleTicket))
+	mux.HandleFunc("/Mvc/Projects/TaskDetail.mvc", s.authenticated(s.handleTask))
+	mux.HandleFunc("/fake/ticket", s.authenticated(s.handleSaveTicketEntry))
+	mux.HandleFunc("/fake/week", s.authenticated(s.handleWeek))
+	mux.HandleFunc("/Mvc/Timesheets/TimesheetEntry.mvc", s.authenticated(s.handleTimesheet))
+	mux.HandleFunc("/fake/timesheet", s.authenticated(s.handleSubmitTimesheet))
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// AddEntry adds an existing time entry, e.g. to test duplicate detection.
+func (s *Server) AddEntry(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Author == "" {
+		e.Author = s.DisplayName
+	}
+	s.entries = append(s.entries, e)
+}
+
+// Entries returns the time entries ordered by ID and date.
+func (s *Server) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := append([]Entry(nil), s.entries...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Id != result[j].Id {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+// SetTimesheet sets the status of the timesheet of the week of the date. Time can't be saved in
+// a submitted or approved week.
+func (s *Server) SetTimesheet(date time.Time, status at.TimesheetStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timesheets[at.SundayOfTheWeek(date)] = status
+}
+
+// Timesheet returns the status of the timesheet of the week of the date, open unless it was changed.
+func (s *Server) Timesheet(date time.Time) at.TimesheetStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.timesheet(date)
+}
+
+// timesheet returns the status of the timesheet of the week of the date, s.mu must be held.
+func (s *Server) timesheet(date time.Time) at.TimesheetStatus {
+	if status, ok := s.timesheets[at.SundayOfTheWeek(date)]; ok {
+		return status
+	}
+
+	return at.TimesheetOpen
+}
+
+// Logins returns the number of times a user logged in with the fake Entra login.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logins
+}
+
+// authenticated redirects requests without a session to the login.
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie(sessionCookie)
+
+		s.mu.Lock()
+		ok := err == nil && s.sessions[c.Value]
+		s.mu.Unlock()
+
+		if !ok {
+			http.Redirect(w, r, uriAuthenticate, http.StatusFound)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, uriAuthenticate, http.StatusFound)
+}
+
+func (s *Server) handleAuthenticate(w http.ResponseWriter, r *http.Request) {
+	render(w, authenticatePage, nil)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		render(w, loginPage, map[string]string{"Username": r.URL.Query().Get("username")})
+		return
+	}
+
+	username, password := r.FormValue("username"), r.FormValue("password")
+	if (s.Username != "" && username != s.Username) || (s.Password != "" && password != s.Password) {
+		w.WriteHeader(http.StatusUnauthorized)
+		render(w, loginPage, map[string]string{"Username": username, "Error": "Your account or password is incorrect."})
+		return
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+	value := hex.EncodeToString(token)
+
+	s.mu.Lock()
+	s.sessions[value] = true
+	s.logins++
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: value, Path: "/", HttpOnly: true})
+	http.Redirect(w, r, uriLandingPage, http.StatusFound)
+}
+
+func (s *Server) handleLanding(w http.ResponseWriter, r *http.Request) {
+	render(w, landingPage, map[string]interface{}{"DisplayName": s.DisplayName})
+}
+
+// conversation is a time entry as it is shown in the conversations of a ticket or task.
+type conversation struct {
+	Author string
+	Title  string
+	Notes  string
+	Week   string // Sunday of the week of a task entry, used by the edit link.
+	Entry  int    // Index of a ticket entry, used by the edit link.
+}
+
+// conversations returns the entries of a ticket or task, newest first like AutoTask.
+func (s *Server) conversations(id int, isTicket bool) []conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var indexes []int
+	for i, e := range s.entries {
+		if e.Id == id && e.IsTicket == isTicket {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return s.entries[indexes[i]].Date.Before(s.entries[indexes[j]].Date) })
+
+	var result []conversation
+	for i := len(indexes) - 1; i >= 0; i-- {
+		e := s.entries[indexes[i]]
+
+		c := conversation{Author: e.Author, Notes: e.Notes, Entry: indexes[i]}
+		if isTicket {
+			c.Title = fmt.Sprintf("%v %v (%.2f hours)", e.Date.Format(s.DateFormat), e.StartTime, e.Duration)
+		} else {
+			c.Title = fmt.Sprintf("%v (%.2f hours)", e.Date.Format(s.DateFormat), e.Duration)
+			c.Week = at.SundayOfTheWeek(e.Date).Format(time.DateOnly)
+		}
+		result = append(result, c)
+	}
+
+	return result
+}
+
+func (s *Server) handleTicket(w http.ResponseWriter, r *http.Request) {
+	s.handleDetail(w, r, "ticketID", true)
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	s.handleDetail(w, r, "taskID", false)
+}
+
+func (s *Server) handleDetail(w http.ResponseWriter, r *http.Request, param string, isTicket bool) {
+	id, err := strconv.Atoi(r.URL.Query().Get(param))
+	if err != nil {
+		http.Error(w, "invalid "+param, http.StatusBadRequest)
+		return
+	}
+
+	page := taskPage
+	if isTicket {
+		page = ticketPage
+	}
+
+	render(w, page, map[string]interface{}{"Id": id, "Conversations": s.conversations(id, isTicket)})
+}
+
+// ticketEntry is posted by the time entry dialog of a ticket.
+type ticketEntry struct {
+	Id        int    `json:"id"`
+	Entry     *int   `json:"entry"` // Index of the edited entry, nil for a new entry.
+	Date      string `json:"date"`
+	StartTime string `json:"startTime"`
+	Hours     string `json:"hours"`
+	Minutes   string `json:"minutes"`
+	Notes     string `json:"notes"`
+	EndTime   string `json:"endTime"`
+	Status    string `json:"status"`
+	Details
+}
+
+func (s *Server) handleSaveTicketEntry(w http.ResponseWriter, r *http.Request) {
+	var te ticketEntry
+	err := json.NewDecoder(r.Body).Decode(&te)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse(s.DateFormat, te.Date)
+	if err != nil {
+		http.Error(w, "invalid date: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hours, _ := strconv.Atoi(te.Hours)
+	minutes, _ := strconv.Atoi(te.Minutes)
+	if hours*60+minutes <= 0 {
+		http.Error(w, "duration is required", http.StatusBadRequest)
+		return
+	}
+
+	if status := s.Timesheet(date); status.Locked() {
+		http.Error(w, "the timesheet is "+string(status), http.StatusConflict)
+		return
+	}
+
+	entry := Entry{
+		Id:        te.Id,
+		IsTicket:  true,
+		Date:      date,
+		StartTime: te.StartTime,
+		Duration:  float32(hours) + float32(minutes)/60,
+		Notes:     te.Notes,
+		Details:   te.Details,
+		EndTime:   te.EndTime,
+
+		TicketStatus: te.Status,
+	}
+
+	if te.Entry == nil {
+		s.AddEntry(entry)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := *te.Entry
+	if i < 0 || i >= len(s.entries) || s.entries[i].Id != te.Id || !s.entries[i].IsTicket || s.entries[i].Author != s.DisplayName {
+		http.Error(w, "the entry can't be edited", http.StatusBadRequest)
+		return
+	}
+
+	entry.Author = s.DisplayName
+	s.entries[i] = entry
+}
+
+// weekDay is a day in the week entry grid of a task.
+type weekDay struct {
+	Label string `json:"-"`
+	Hours string `json:"hours"`
+	Notes string `json:"notes"`
+	Details
+}
+
+// weekEntry is posted by the week entry dialog of a task.
+type weekEntry struct {
+	Id   int       `json:"id"`
+	Week string    `json:"week"`
+	Days []weekDay `json:"days"`
+}
+
+func (s *Server) handleWeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.handleSaveWeek(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("taskID"))
+	if err != nil {
+		http.Error(w, "invalid taskID", http.StatusBadRequest)
+		return
+	}
+
+	sunday := at.SundayOfTheWeek(time.Now().UTC().Truncate(24 * time.Hour))
+	if week := r.URL.Query().Get("week"); week != "" {
+		sunday, err = time.Parse(time.DateOnly, week)
+		if err != nil {
+			http.Error(w, "invalid week", http.StatusBadRequest)
+			return
+		}
+	}
+
+	days := make([]weekDay, 7)
+	for i := range days {
+		date := sunday.AddDate(0, 0, i)
+		days[i].Label = date.Format(s.DayFormat)
+
+		for _, e := range s.Entries() {
+			if e.Id == id && !e.IsTicket && e.Author == s.DisplayName && e.Date.Equal(date) {
+				days[i].Hours = strconv.FormatFloat(float64(e.Duration), 'f', -1, 32)
+				days[i].Notes = e.Notes
+				days[i].Details = e.Details
+			}
+		}
+	}
+
+	render(w, weekDialog, map[string]interface{}{"Week": sunday.Format(time.DateOnly), "Days": days})
+}
+
+func (s *Server) handleSaveWeek(w http.ResponseWriter, r *http.Request) {
+	var we weekEntry
+	err := json.NewDecoder(r.Body).Decode(&we)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sunday, err := time.Parse(time.DateOnly, we.Week)
+	if err != nil || len(we.Days) != 7 {
+		http.Error(w, "invalid week", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status := s.timesheet(sunday); status.Locked() {
+		http.Error(w, "the timesheet is "+string(status), http.StatusConflict)
+		return
+	}
+
+	for i, d := range we.Days {
+		date := sunday.AddDate(0, 0, i)
+
+		// Saving a week replaces the user's entries of each day
+		kept := s.entries[:0]
+		for _, e := range s.entries {
+			if e.Id != we.Id || e.IsTicket || e.Author != s.DisplayName || !e.Date.Equal(date) {
+				kept = append(kept, e)
+			}
+		}
+		s.entries = kept
+
+		hours, err := strconv.ParseFloat(d.Hours, 32)
+		if err != nil || hours <= 0 {
+			continue
+		}
+
+		s.entries = append(s.entries, Entry{Id: we.Id, Date: date, Duration: float32(hours), Notes: d.Notes, Author: s.DisplayName, Details: d.Details})
+	}
+}
+
+// timesheetLabels are the statuses as they are shown on the timesheet.
+var timesheetLabels = map[at.TimesheetStatus]string{
+	at.TimesheetOpen:      "Open",
+	at.TimesheetSubmitted: "Submitted for Approval",
+	at.TimesheetApproved:  "Approved",
+}
+
+func (s *Server) handleTimesheet(w http.ResponseWriter, r *http.Request) {
+	week, err := time.Parse(time.DateOnly, r.URL.Query().Get("weekStart"))
+	if err != nil {
+		http.Error(w, "invalid weekStart", http.StatusBadRequest)
+		return
+	}
+
+	status := s.Timesheet(week)
+	render(w, timesheetPage, map[string]interface{}{
+		"Week":   at.SundayOfTheWeek(week).Format(time.DateOnly),
+		"Status": string(status),
+		"Label":  timesheetLabels[status],
+		"Items":  s.timesheetItems(at.SundayOfTheWeek(week)),
+	})
+}
+
+// timesheetItem is a row of a timesheet, the time of the user on a ticket or task in the week.
+type timesheetItem struct {
+	Id       int
+	IsTicket bool
+	Hours    float32
+}
+
+// timesheetItems returns the rows of the timesheet of the week starting on the Sunday.
+func (s *Server) timesheetItems(sunday time.Time) []*timesheetItem {
+	var items []*timesheetItem
+	for _, e := range s.Entries() {
+		if e.Author != s.DisplayName || e.Date.Before(sunday) || !e.Date.Before(sunday.AddDate(0, 0, 7)) {
+			continue
+		}
+
+		var item *timesheetItem
+		for _, i := range items {
+			if i.Id == e.Id && i.IsTicket == e.IsTicket {
+				item = i
+			}
+		}
+		if item == nil {
+			item = &timesheetItem{Id: e.Id, IsTicket: e.IsTicket}
+			items = append(items, item)
+		}
+		item.Hours += e.Duration
+	}
+
+	return items
+}
+
+// timesheetAction is posted by the submit and recall buttons of a timesheet.
+type timesheetAction struct {
+	Week   string `json:"week"`
+	Action string `json:"action"`
+}
+
+func (s *Server) handleSubmitTimesheet(w http.ResponseWriter, r *http.Request) {
+	var ta timesheetAction
+	err := json.NewDecoder(r.Body).Decode(&ta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	week, err := time.Parse(time.DateOnly, ta.Week)
+	if err != nil {
+		http.Error(w, "invalid week", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, to := at.TimesheetOpen, at.TimesheetSubmitted
+	if ta.Action == "recall" {
+		from, to = at.TimesheetSubmitted, at.TimesheetOpen
+	}
+
+	if status := s.timesheet(week); status != from {
+		http.Error(w, "the timesheet is "+string(status), http.StatusConflict)
+		return
+	}
+
+	s.timesheets[at.SundayOfTheWeek(week)] = to
+}
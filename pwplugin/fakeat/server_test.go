@@ -0,0 +1,162 @@
+package fakeat
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+)
+
+// newClient returns a client that keeps the session cookie.
+func newClient(t *testing.T) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("could not create cookie jar: %v", err)
+	}
+
+	return &http.Client{Jar: jar}
+}
+
+func login(t *testing.T, s *Server, c *http.Client, password string) *http.Response {
+	t.Helper()
+
+	resp, err := c.PostForm(s.URL+uriLogin, url.Values{"username": {"jo@example.com"}, "password": {password}})
+	if err != nil {
+		t.Fatalf("could not login: %v", err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestLogin(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Password = "secret"
+	c := newClient(t)
+
+	resp, err := c.Get(s.URL + "/Mvc/ServiceDesk/TicketDetail.mvc?ticketID=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Request.URL.Path != uriAuthenticate {
+		t.Errorf("expected a redirect to the login but got %v", resp.Request.URL)
+	}
+
+	resp = login(t, s, c, "wrong")
+	if resp.StatusCode != http.StatusUnauthorized || s.Logins() != 0 {
+		t.Errorf("expected the login to fail, got %v", resp.Status)
+	}
+
+	resp = login(t, s, c, "secret")
+	if resp.Request.URL.Path != uriLandingPage || s.Logins() != 1 {
+		t.Errorf("expected the landing page after logging in but got %v", resp.Request.URL)
+	}
+}
+
+func TestSaveEntries(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t)
+	login(t, s, c, "")
+
+	post := func(path, body string) {
+		t.Helper()
+		resp, err := c.Post(s.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected %v to succeed but got %v", path, resp.Status)
+		}
+	}
+
+	post("/fake/ticket", `{"id":100,"date":"2023/09/15","startTime":"10:30","hours":"1","minutes":"15","notes":"Fixed it","endTime":"11:45","role":"Engineer","nonBillable":true,"status":"Complete"}`)
+	post("/fake/week", `{"id":200,"week":"2023-09-10","days":[{},{"hours":"0.5","notes":"Design"},{},{},{},{},{}]}`)
+	post("/fake/week", `{"id":200,"week":"2023-09-10","days":[{},{"hours":"0.75","notes":"Design","role":"Consultant"},{"hours":"2"},{},{},{},{}]}`)
+
+	entries := s.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries but got %+v", entries)
+	}
+
+	ticket := entries[0]
+	if !ticket.IsTicket || ticket.Duration != 1.25 || ticket.StartTime != "10:30" || !ticket.Date.Equal(time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected ticket entry: %+v", ticket)
+	}
+	if ticket.EndTime != "11:45" || ticket.Role != "Engineer" || !ticket.NonBillable || ticket.ShowOnInvoice || ticket.TicketStatus != "Complete" {
+		t.Errorf("expected the optional fields of the ticket entry, got %+v", ticket)
+	}
+
+	if entries[1].Duration != 0.75 || entries[2].Duration != 2 {
+		t.Errorf("expected saving the week to replace the days, got %+v", entries[1:])
+	}
+	if entries[1].Role != "Consultant" || entries[2].Role != "" {
+		t.Errorf("expected the role of the day, got %+v", entries[1:])
+	}
+
+	post("/fake/ticket", `{"id":100,"entry":0,"date":"2023/09/15","startTime":"10:30","hours":"2","minutes":"0","notes":"Fixed it properly"}`)
+
+	entries = s.Entries()
+	if len(entries) != 3 || entries[0].Duration != 2 || entries[0].Notes != "Fixed it properly" {
+		t.Errorf("expected editing the ticket entry to replace it, got %+v", entries)
+	}
+
+	resp, err := c.Get(s.URL + "/Mvc/Projects/TaskDetail.mvc?taskID=200")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "2023/09/11 (0.75 hours)") || !strings.Contains(string(body), "openWeek('2023-09-10')") {
+		t.Errorf("expected the conversations of the task, got:\n%s", body)
+	}
+}
+
+func TestTimesheets(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t)
+	login(t, s, c, "")
+
+	post := func(path, body string) int {
+		t.Helper()
+		resp, err := c.Post(s.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	week := time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC)
+	if code := post("/fake/timesheet", `{"week":"2023-09-10","action":"submit"}`); code != http.StatusOK || s.Timesheet(week) != at.TimesheetSubmitted {
+		t.Fatalf("expected the timesheet to be submitted, got %v", code)
+	}
+
+	resp, err := c.Get(s.URL + "/Mvc/Timesheets/TimesheetEntry.mvc?weekStart=2023-09-10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Submitted for Approval") || !strings.Contains(string(body), "Recall (Un-submit)") {
+		t.Errorf("expected the submitted timesheet, got:\n%s", body)
+	}
+
+	if code := post("/fake/ticket", `{"id":100,"date":"2023/09/15","startTime":"10:30","hours":"1","minutes":"0","notes":"Late"}`); code != http.StatusConflict {
+		t.Errorf("expected saving in a submitted week to fail, got %v", code)
+	}
+
+	if code := post("/fake/timesheet", `{"week":"2023-09-10","action":"recall"}`); code != http.StatusOK || s.Timesheet(week.AddDate(0, 0, 3)) != at.TimesheetOpen {
+		t.Errorf("expected the timesheet to be recalled, got %v", code)
+	}
+}
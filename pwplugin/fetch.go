@@ -0,0 +1,20 @@
+package pwplugin
+
+import (
+	"context"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+	"github.com/philipf/gt-at/pwplugin/fetch"
+)
+
+// FetchTimes reads the user's time entries back from the conversations of the tickets and tasks.
+func (atp *autoTaskPlaywright) FetchTimes(ctx context.Context, query at.FetchQuery, opts at.CaptureOptions) (at.TimeEntries, error) {
+	var result at.TimeEntries
+	err := withDriver(ctx, opts, func(d common.Driver) (err error) {
+		result, err = fetch.Fetch(d, opts.UserDisplayName, opts.DateFormat, query)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,129 @@
+package fetch
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+// Fetch reads the user's time entries of the query from the conversations of the tickets and
+// tasks. Without IDs in the query, the tickets and tasks are found on the timesheets of the weeks
+// of the date range.
+func Fetch(d common.Driver, userDisplayName, dateFormat string, q at.FetchQuery) (at.TimeEntries, error) {
+	if q.To.Before(q.From) {
+		return nil, fmt.Errorf("fetch: the date range ends before it starts")
+	}
+
+	items := queryItems(q)
+	if len(items) == 0 {
+		var err error
+		items, err = timesheetItems(d, q)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Info("Fetch entries", "items", len(items), "from", q.From.Format(time.DateOnly), "to", q.To.Format(time.DateOnly))
+
+	var result at.TimeEntries
+	for _, item := range items {
+		entries, err := fetchItem(d, item, userDisplayName, dateFormat, q)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, entries...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Date.Equal(result[j].Date) {
+			return result[i].Date.Before(result[j].Date)
+		}
+		return result[i].StartTimeStr < result[j].StartTimeStr
+	})
+
+	return result, nil
+}
+
+// queryItems returns the tickets and tasks given in the query.
+func queryItems(q at.FetchQuery) []common.TimesheetItem {
+	var items []common.TimesheetItem
+	for _, id := range q.TicketIds {
+		items = append(items, common.TimesheetItem{Id: id, IsTicket: true})
+	}
+	for _, id := range q.TaskIds {
+		items = append(items, common.TimesheetItem{Id: id})
+	}
+
+	return items
+}
+
+// timesheetItems returns the tickets and tasks with time on the timesheets of the date range.
+func timesheetItems(d common.Driver, q at.FetchQuery) ([]common.TimesheetItem, error) {
+	seen := map[common.TimesheetItem]bool{}
+
+	var items []common.TimesheetItem
+	for week := at.SundayOfTheWeek(q.From); !week.After(q.To); week = week.AddDate(0, 0, 7) {
+		err := d.OpenTimesheet(week)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: could not open the timesheet of %v: %v", week.Format(time.DateOnly), err)
+		}
+
+		weekItems, err := d.TimesheetItems()
+		if err != nil {
+			return nil, fmt.Errorf("fetch: could not list the timesheet of %v: %v", week.Format(time.DateOnly), err)
+		}
+
+		for _, item := range weekItems {
+			if !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// fetchItem reads the user's entries in the date range from the conversations of a ticket or task.
+func fetchItem(d common.Driver, item common.TimesheetItem, userDisplayName, dateFormat string, q at.FetchQuery) (at.TimeEntries, error) {
+	var err error
+	if item.IsTicket {
+		err = d.OpenTicket(item.Id)
+	} else {
+		err = d.OpenTask(item.Id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch: could not open %d: %v", item.Id, err)
+	}
+
+	convs, err := d.Conversations()
+	if err != nil {
+		return nil, fmt.Errorf("fetch: could not find the conversations of %d: %v", item.Id, err)
+	}
+
+	var result at.TimeEntries
+	for _, conv := range convs {
+		if conv.Author != userDisplayName {
+			continue
+		}
+
+		te, err := common.ParseConversation(conv, item.Id, item.IsTicket, dateFormat)
+		if err != nil {
+			slog.Warn("skipping a conversation that isn't a time entry", "id", item.Id, "error", err)
+			continue
+		}
+
+		if q.Contains(te.Date) {
+			result = append(result, te)
+		}
+	}
+
+	slog.Debug("Fetched entries", "id", item.Id, "entries", len(result))
+
+	return result, nil
+}
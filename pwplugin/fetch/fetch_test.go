@@ -0,0 +1,73 @@
+package fetch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+const displayName = "Jo Bloggs"
+
+var monday = time.Date(2023, 9, 11, 0, 0, 0, 0, time.UTC)
+
+func newDriver() *common.FakeDriver {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: monday, StartTime: "10:30", Duration: 1.5, Notes: "Fixed it", Author: displayName},
+		{Id: 100, IsTicket: true, Date: monday, StartTime: "08:00", Duration: 1, Notes: "Someone else", Author: "Other"},
+		{Id: 200, Date: monday.AddDate(0, 0, 1), Duration: 0.75, Notes: "Design", Author: displayName},
+		{Id: 200, Date: monday.AddDate(0, 0, 7), Duration: 2, Notes: "Next week", Author: displayName},
+		{Id: 300, IsTicket: true, Date: monday.AddDate(0, 0, -7), StartTime: "09:00", Duration: 1, Notes: "Last week", Author: displayName},
+	}
+
+	return d
+}
+
+func TestFetchFromTimesheets(t *testing.T) {
+	d := newDriver()
+
+	entries, err := Fetch(d, displayName, d.DateFormat, at.FetchQuery{From: monday, To: monday.AddDate(0, 0, 4)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 entries of the user in the week, got %+v", entries)
+	}
+
+	ticket, task := entries[0], entries[1]
+	if ticket.Id != 100 || !ticket.IsTicket || ticket.StartTimeStr != "10:30" || ticket.Duration != 1.5 || ticket.Summary != "Fixed it" {
+		t.Errorf("unexpected ticket entry: %+v", ticket)
+	}
+	if task.Id != 200 || task.IsTicket || !task.Date.Equal(monday.AddDate(0, 0, 1)) || task.Duration != 0.75 {
+		t.Errorf("unexpected task entry: %+v", task)
+	}
+}
+
+func TestFetchByIds(t *testing.T) {
+	d := newDriver()
+
+	entries, err := Fetch(d, displayName, d.DateFormat, at.FetchQuery{TaskIds: []int{200}, From: monday.AddDate(0, 0, -7), To: monday.AddDate(0, 0, 13)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 2 || entries[0].Summary != "Design" || entries[1].Summary != "Next week" {
+		t.Errorf("expected only the entries of the task, got %+v", entries)
+	}
+
+	for _, call := range d.Calls {
+		if call == "OpenTimesheet" {
+			t.Error("expected the timesheets not to be opened when IDs are given")
+		}
+	}
+}
+
+func TestFetchInvalidRange(t *testing.T) {
+	_, err := Fetch(newDriver(), displayName, "2006/01/02", at.FetchQuery{From: monday, To: monday.AddDate(0, 0, -1)})
+	if err == nil {
+		t.Error("expected an error for a date range ending before it starts")
+	}
+}
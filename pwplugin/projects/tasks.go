@@ -3,121 +3,156 @@ package projects
 import (
 	"errors"
 	"fmt"
-	"log"
-	"strconv"
-	"strings"
+	"log/slog"
 	"time"
 
 	"github.com/philipf/gt-at/at"
 	"github.com/philipf/gt-at/pwplugin/common"
-	"github.com/playwright-community/playwright-go"
 )
 
-func Capture(page playwright.Page, userDisplayName string, entries at.TimeEntries, dateFormat, dayFormat string) error {
-	log.Printf("Capture entries for a total of %v tasks\n", len(entries))
+// now returns the reference date used to infer the year of the week labels.
+var now = time.Now
+
+func Capture(d common.Driver, userDisplayName string, entries at.TimeEntries, dateFormat, dayFormat string, policy at.DuplicatePolicy, consolidation at.ConsolidationPolicy, confirm func(te *at.TimeEntry) bool, obs at.Observer) error {
+	slog.Info("Capture task entries", "entries", len(entries))
 
 	taskIds := entries.DistinctIds()
 
 	for _, id := range taskIds {
-		err := captureByTaskId(page, id, entries, userDisplayName, dateFormat, dayFormat)
+		err := captureByTaskId(d, id, entries, userDisplayName, dateFormat, dayFormat, policy, consolidation, confirm, obs)
 		if err != nil {
-			fmt.Printf("Capture: could not log time entries for taskId: %v, error: %v\n", id, err)
+			slog.Error("Capture: could not log time entries", "task", id, "error", err)
+			for _, te := range entries.ById(id).SetUnprocessedError(err) {
+				at.NotifyEntry(obs, te)
+			}
 		}
 	}
 
 	return nil
 }
 
-func captureByTaskId(page playwright.Page, taskId int, entries at.TimeEntries, userDisplayName, dateFormat, dayFormat string) error {
-	_, err := page.Goto(fmt.Sprintf(at.URI_TASK_DETAIL, at.BaseURL, taskId))
-
+func captureByTaskId(d common.Driver, taskId int, entries at.TimeEntries, userDisplayName, dateFormat, dayFormat string, policy at.DuplicatePolicy, consolidation at.ConsolidationPolicy, confirm func(te *at.TimeEntry) bool, obs at.Observer) error {
+	err := d.OpenTask(taskId)
 	if err != nil {
-		log.Fatalf("captureByTaskId: could not goto taskDetailUri: %v", err)
-	}
-
-	log.Println("Waiting for first conversation details to load")
-
-	err = page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
-		State:   playwright.LoadStateNetworkidle,
-		Timeout: playwright.Float(5000),
-	})
-
-	if alertVisible, _ := page.Locator("#AlertDialog.Active").IsVisible(); alertVisible {
-		page.Locator("#AlertDialogOkayButton").Click()
-	}
-
-	if err != nil {
-		if strings.Contains(err.Error(), "timeout") {
-			log.Println("Timeout waiting for first conversation details to load")
-		} else {
-			return fmt.Errorf("captureByTaskId: could not find details: %v", err)
-		}
+		return fmt.Errorf("captureByTaskId: could not open task: %v", err)
 	}
 
-	log.Println("Conversations Loaded")
+	at.Notify(obs, at.Event{Type: at.EventTaskOpened, Id: taskId})
 
 	// Build an array of ticket entries for a given taskId
 	// Doing this to be a little more efficient and reduce the number of page loads
 	entriesById := entries.ById(taskId)
 
-	err = common.MarkExisiting(page, userDisplayName, entriesById, dateFormat)
+	// Entries on the same day are captured as one, their outcome is copied back when notified
+	var merges map[*at.TimeEntry]at.TimeEntries
+	if consolidation == at.ConsolidateMerge {
+		entriesById, merges = entriesById.MergeSameDay(dateFormat)
+		for merged, day := range merges {
+			slog.Info("Merged entries on the same day", "task", taskId, "date", merged.DateStr, "entries", len(day), "duration", merged.Duration)
+		}
+	}
+
+	peers, _, err := common.MarkExisiting(d, userDisplayName, entriesById, dateFormat, policy)
 	if err != nil {
 		return fmt.Errorf("captureByTaskId: could not mark existing entries: %v", err)
 	}
 
+	// Updated days are overwritten in the week entry of the existing conversation
+	entriesById.ConfirmUpdates(confirm)
+
 	weekGroups := entriesById.GroupByWeekNo()
 
 	// Loop through each week group and create a new time entry for each week
-	for _, weekEntries := range weekGroups {
-		err = captureByWeek(page, weekEntries, dayFormat)
+	for weekNo, weekEntries := range weekGroups {
+		var peer *common.Conversation
+		if conv, ok := peers[weekNo]; ok {
+			peer = &conv
+		}
+
+		err = captureByWeek(d, weekEntries, peer, dayFormat)
 		if err != nil {
-			return fmt.Errorf("captureByTaskId: could not log time entries for week: %v, error: %v", weekEntries[0].WeekNo, err)
+			err = fmt.Errorf("captureByTaskId: could not log time entries for week: %v, error: %v", weekNo, err)
+			weekEntries.SetUnprocessedError(err)
+			for _, te := range weekEntries {
+				notifyEntry(obs, te, merges)
+			}
+			return err
+		}
+
+		slog.Info("Saved week", "task", taskId, "week", weekNo, "entries", len(weekEntries))
+		at.Notify(obs, at.Event{Type: at.EventWeekSaved, Id: taskId, Entry: weekEntries[0]})
+		for _, te := range weekEntries {
+			notifyEntry(obs, te, merges)
 		}
 	}
 
-	log.Println("Done loading")
+	slog.Debug("Done loading", "task", taskId)
 
 	return nil
 }
 
-func captureByWeek(page playwright.Page, weekEntries at.TimeEntries, dayFormat string) error {
-	peer := findWeekEntryPeer(weekEntries, weekEntries[0])
+// notifyEntry sends the outcome of an entry, or of each entry a merged entry replaced.
+func notifyEntry(obs at.Observer, te *at.TimeEntry, merges map[*at.TimeEntry]at.TimeEntries) {
+	day, ok := merges[te]
+	if !ok {
+		at.NotifyEntry(obs, te)
+		return
+	}
+
+	for _, e := range day {
+		e.SetMerged(te, len(day))
+		at.NotifyEntry(obs, e)
+	}
+}
+
+// captureByWeek captures the entries of a week, editing the existing week entry if the user
+// already has a conversation in that week.
+func captureByWeek(d common.Driver, weekEntries at.TimeEntries, peer *common.Conversation, dayFormat string) error {
+	for _, te := range weekEntries {
+		te.SetStarted()
+	}
+
+	defer func() {
+		for _, te := range weekEntries {
+			te.SetFinished()
+		}
+	}()
 
 	if peer == nil {
-		return captureNewWeek(page, weekEntries, dayFormat)
+		return captureNewWeek(d, weekEntries, dayFormat)
 
 	} else {
-		return captureExistingWeek(page, weekEntries, peer)
+		return captureExistingWeek(d, weekEntries, *peer)
 	}
 }
 
-func captureNewWeek(page playwright.Page, weekEntries at.TimeEntries, dayFormat string) error {
-	if err := page.Locator("[data-eii='00000135']").Click(); err != nil {
-		return fmt.Errorf("newWeekEntries: could not click new time entry button: %v", err)
+func captureNewWeek(d common.Driver, weekEntries at.TimeEntries, dayFormat string) error {
+	if err := d.NewWeekEntry(); err != nil {
+		return fmt.Errorf("newWeekEntries: %v", err)
 	}
 
-	navigateToWeek(page, weekEntries[0].Date, dayFormat)
+	if err := navigateToWeek(d, weekEntries[0].Date, dayFormat); err != nil {
+		return err
+	}
 
-	return captureWeek(page, weekEntries)
+	return captureWeek(d, weekEntries)
 }
 
-func navigateToWeek(page playwright.Page, entryTime time.Time, dayFormat string) error {
+func navigateToWeek(d common.Driver, entryTime time.Time, dayFormat string) error {
 	entryWeekStart := at.SundayOfTheWeek(entryTime)
 
 	for i := 0; i <= 3; i++ {
-
-		s, err := page.Locator("body > div.Dialog1.Dialog2.Normal.Active tr.Heading > td.TextCell div.Label").First().TextContent()
-
+		s, err := d.WeekStart()
 		if err != nil {
-			return fmt.Errorf("navigateToWeek: could not find pageDateLabel: %v", err)
+			return fmt.Errorf("navigateToWeek: %v", err)
 		}
 
-		firstParse, _ := time.Parse(dayFormat, s)
+		firstParse, err := time.Parse(dayFormat, s)
 		if err != nil {
 			return fmt.Errorf("navigateToWeek: could not parse pageWeekStart: %v", err)
 		}
 
-		inferredYear := at.InferYear(firstParse.Month(), 3, time.Now())
+		inferredYear := at.InferYear(firstParse.Month(), 3, now())
 		pageWeekStart := time.Date(inferredYear, firstParse.Month(), firstParse.Day(), 0, 0, 0, 0, time.Local)
 
 		if pageWeekStart.Year() == entryWeekStart.Year() &&
@@ -126,12 +161,12 @@ func navigateToWeek(page playwright.Page, entryTime time.Time, dayFormat string)
 			return nil
 
 		} else if entryWeekStart.Before(pageWeekStart) {
-			err = gotoPrevWeek(page)
+			err = d.PreviousWeek()
 			if err != nil {
 				return fmt.Errorf("navigateToNextDay: could not find load indicator: %v", err)
 			}
 		} else {
-			err = gotoNextWeek(page)
+			err = d.NextWeek()
 			if err != nil {
 				return fmt.Errorf("navigateToNextDay: could not find load indicator: %v", err)
 			}
@@ -141,49 +176,19 @@ func navigateToWeek(page playwright.Page, entryTime time.Time, dayFormat string)
 	return errors.New("navigateToWeek: could not find week")
 }
 
-func gotoNextWeek(page playwright.Page) error {
-	loadIndicator := "#LoadingIndicator.Active"
-	page.Locator("body > div.Dialog1.Dialog2.Normal.Active .MoveRight").Click()
-	return page.Locator(loadIndicator).WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateDetached})
-}
-
-func gotoPrevWeek(page playwright.Page) error {
-	loadIndicator := "#LoadingIndicator.Active"
-	page.Locator("body > div.Dialog1.Dialog2.Normal.Active .MoveLeft").Click()
-	return page.Locator(loadIndicator).WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateDetached})
-}
-
-func captureExistingWeek(page playwright.Page, weekEntries at.TimeEntries, peer *at.TimeEntry) error {
-	convLocator := peer.WeekPeerLocator.(playwright.Locator)
-
-	err := convLocator.Locator("div.FooterActions div.LinkButton2").Nth(3).Click()
-
+func captureExistingWeek(d common.Driver, weekEntries at.TimeEntries, peer common.Conversation) error {
+	err := d.EditWeekEntry(peer)
 	if err != nil {
-		return fmt.Errorf("editWeekEntries: could not click edit button: %v", err)
+		return fmt.Errorf("editWeekEntries: %v", err)
 	}
 
-	return captureWeek(page, weekEntries)
+	return captureWeek(d, weekEntries)
 }
 
-func captureWeek(page playwright.Page, weekEntries at.TimeEntries) error {
-	weekEntryDialog := page.Locator("body > div.Dialog1.Dialog2.Normal.Active")
-	err := weekEntryDialog.WaitFor()
+func captureWeek(d common.Driver, weekEntries at.TimeEntries) error {
+	err := d.EditWeek()
 	if err != nil {
-		return fmt.Errorf("captureWeek: could not find weekEntryDialog: %v", err)
-	}
-
-	// Click Sunday's edit button
-	timeEntryDialogSelector := page.Locator("div.Body > div.Scrolling > table > tbody div.Icon").First()
-
-	err = timeEntryDialogSelector.Click()
-	if err != nil {
-		return fmt.Errorf("captureWeek: could not click Sunday's edit button: %v", err)
-	}
-
-	nextDayButton := page.Locator("[data-eii='0100014L']") // Next Day button
-	err = nextDayButton.WaitFor()
-	if err != nil {
-		return fmt.Errorf("captureWeek: could not find timeEntryDialog: %v", err)
+		return fmt.Errorf("captureWeek: %v", err)
 	}
 
 	// Capture each week day's time if it exists
@@ -197,13 +202,16 @@ func captureWeek(page playwright.Page, weekEntries at.TimeEntries) error {
 
 		if len(entry) > 1 {
 			for _, e := range entry {
-				e.SetError(fmt.Errorf("captureWeek: more than one entry for a given day: %v", sunday))
+				e.SetError(fmt.Errorf("captureWeek: more than one entry for a given day: %v, merge them with the merge policy", sunday))
 			}
 		} else if len(entry) == 0 {
 			// No time entry for this day, skip to the next day
+		} else if te := entry[0]; declined(te) {
+			// The day keeps the existing entry
+			entriesCaptured++
 		} else {
-			te := entry[0]
-			err = captureDay(page, te)
+			slog.Debug("Capture time entry", "task", te.Id, "date", te.DateStr, "duration", te.Duration)
+			err = d.FillDay(te)
 			if err != nil {
 				te.SetError(fmt.Errorf("captureWeek: could not capture day: %v", err))
 			}
@@ -211,13 +219,13 @@ func captureWeek(page playwright.Page, weekEntries at.TimeEntries) error {
 		}
 
 		if i >= 6 || entriesCaptured >= len(weekEntries) {
-			err := saveWeek(page)
+			err := d.SaveWeek()
 			if err != nil {
 				return fmt.Errorf("captureWeek: could not save week: %v", err)
 			}
 			break
 		} else {
-			err := navigateToNextDay(page)
+			err := d.NextDay()
 			if err != nil {
 				return fmt.Errorf("captureWeek: could not navigate to next day: %v", err)
 			}
@@ -226,7 +234,7 @@ func captureWeek(page playwright.Page, weekEntries at.TimeEntries) error {
 
 	// Mark all entries as submitted
 	for _, te := range weekEntries {
-		if te.Error == nil {
+		if te.Error == nil && !declined(te) {
 			te.Submitted = true
 		}
 	}
@@ -234,78 +242,7 @@ func captureWeek(page playwright.Page, weekEntries at.TimeEntries) error {
 	return nil
 }
 
-func captureDay(page playwright.Page, te *at.TimeEntry) error {
-	log.Printf("Capture time entry: %+v\n", te)
-	err := page.Locator("[data-eii='0100014M']").Fill(strconv.FormatFloat(float64(te.Duration), 'f', -1, 32))
-	if err != nil {
-		return fmt.Errorf("newWeekEntries: could not fill in duration: %v", err)
-	}
-
-	summaryNotes := page.Locator("[data-eii='0100014N']  > div.Content2 > div.InputWrapper2 > div.ContentEditable2.Small")
-	err = summaryNotes.Fill(te.Summary)
-	if err != nil {
-		return fmt.Errorf("newWeekEntries: could not fill in summary notes: %v", err)
-	}
-
-	page.WaitForTimeout(1000)
-
-	return nil
-}
-
-func saveWeek(page playwright.Page) error {
-	// click save button
-	okButton := page.Locator("[data-eii='0100014J']") // OK button to save
-	err := okButton.Click()
-	if err != nil {
-		return fmt.Errorf("saveWeek: could not click ok button: %v", err)
-	}
-
-	weekEntryDialog := page.Locator("body > div.Dialog1.Dialog2.Normal.Active").First()
-
-	saveAndCloseButton := page.Locator("[data-eii='010000p7']") // Save and Close button
-	err = saveAndCloseButton.Click()
-	if err != nil {
-		return fmt.Errorf("saveWeek: could not click save and close button: %v", err)
-	}
-
-	// wait for dialog to close
-	err = weekEntryDialog.WaitFor(playwright.LocatorWaitForOptions{
-		State: playwright.WaitForSelectorStateDetached,
-	})
-
-	if err != nil {
-		return fmt.Errorf("saveWeek: could not wait for dialog to close: %v", err)
-	}
-
-	return nil
-}
-
-func navigateToNextDay(page playwright.Page) error {
-	nextDayButtonSelector := "[data-eii='0100014L']"
-
-	nextDayButton := page.Locator(nextDayButtonSelector)
-	err := nextDayButton.Click()
-	if err != nil {
-		return fmt.Errorf("navigateToNextDay: could not click next day button: %v", err)
-	}
-
-	err = page.Locator(nextDayButtonSelector).WaitFor()
-	if err != nil {
-		return fmt.Errorf("navigateToNextDay: could not find next day button: %v", err)
-	}
-
-	return nil
-
-}
-
-func findWeekEntryPeer(entriesById at.TimeEntries, te *at.TimeEntry) *at.TimeEntry {
-	weekNo := te.WeekNo
-
-	for _, e := range entriesById {
-		if e.WeekNo == weekNo && e.WeekPeerLocator != nil {
-			return te
-		}
-	}
-
-	return nil
+// declined returns true if the update of an existing entry was declined.
+func declined(te *at.TimeEntry) bool {
+	return te.Existing != nil && te.Exists
 }
@@ -0,0 +1,271 @@
+package projects
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+const displayName = "Jo Bloggs"
+
+// wednesday is the date the week entry dialog of the fake driver opens on.
+var wednesday = time.Date(2023, 9, 20, 0, 0, 0, 0, time.UTC)
+
+func newDriver(t *testing.T) *common.FakeDriver {
+	d := common.NewFakeDriver(displayName)
+	d.Today = wednesday
+
+	now = func() time.Time { return wednesday }
+	t.Cleanup(func() { now = time.Now })
+
+	return d
+}
+
+func newEntries(d *common.FakeDriver, entries ...at.RequestEntry) at.TimeEntries {
+	return at.ToTimeEntries(entries, d.DateFormat)
+}
+
+func capture(t *testing.T, d *common.FakeDriver, entries at.TimeEntries) {
+	t.Helper()
+
+	err := Capture(d, displayName, entries, d.DateFormat, d.DayFormat, at.DuplicateSkip, at.ConsolidateFail, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func called(d *common.FakeDriver, name string) int {
+	count := 0
+	for _, c := range d.Calls {
+		if c == name {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestCaptureNewWeek(t *testing.T) {
+	d := newDriver(t)
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday.AddDate(0, 0, -2), Duration: 0.75, Summary: "Design"},
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 1.5, Summary: "Build"},
+	)
+
+	capture(t, d, entries)
+
+	for _, e := range entries {
+		if !e.Submitted || e.Error != nil {
+			t.Errorf("expected entry on %v to be saved, got: %v", e.DateStr, e.Error)
+		}
+	}
+
+	saved := d.Saved()
+	if len(saved) != 2 || saved[0].Duration != 0.75 || saved[0].Notes != "Design" || !saved[1].Date.Equal(wednesday) {
+		t.Errorf("unexpected entries saved: %+v", saved)
+	}
+
+	if called(d, "NewWeekEntry") != 1 || called(d, "SaveWeek") != 1 || called(d, "PreviousWeek") != 0 {
+		t.Errorf("expected a single new week to be saved, calls: %v", d.Calls)
+	}
+
+	// Saving stops after the last entry of the week, Monday to Wednesday
+	if called(d, "NextDay") != 3 {
+		t.Errorf("expected to move on 3 days, calls: %v", d.Calls)
+	}
+}
+
+func TestCaptureNavigatesWeeks(t *testing.T) {
+	d := newDriver(t)
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday.AddDate(0, 0, -14), Duration: 1, Summary: "Earlier"},
+		at.RequestEntry{Id: 201, Date: wednesday.AddDate(0, 0, 7), Duration: 1, Summary: "Later"},
+	)
+
+	capture(t, d, entries)
+
+	if called(d, "PreviousWeek") != 2 || called(d, "NextWeek") != 1 {
+		t.Errorf("expected to navigate 2 weeks back and 1 forward, calls: %v", d.Calls)
+	}
+
+	if saved := d.Saved(); len(saved) != 2 {
+		t.Errorf("expected 2 entries saved, got: %+v", saved)
+	}
+}
+
+func TestCaptureExistingWeek(t *testing.T) {
+	d := newDriver(t)
+	monday := wednesday.AddDate(0, 0, -2)
+	d.Entries = []common.FakeEntry{
+		{Id: 200, Date: monday, Duration: 2, Notes: "Existing", Author: displayName},
+		{Id: 200, Date: wednesday, Duration: 4, Notes: "Someone else", Author: "Other"},
+	}
+
+	entries := newEntries(d, at.RequestEntry{Id: 200, Date: wednesday, Duration: 1, Summary: "Build"})
+	capture(t, d, entries)
+
+	if called(d, "EditWeekEntry") != 1 || called(d, "NewWeekEntry") != 0 {
+		t.Errorf("expected the existing week to be edited, calls: %v", d.Calls)
+	}
+
+	saved := d.Saved()
+	if len(saved) != 3 {
+		t.Fatalf("expected the existing entries to be kept, got: %+v", saved)
+	}
+	if saved[0].Notes != "Existing" || saved[2].Notes != "Build" || saved[2].Author != displayName {
+		t.Errorf("unexpected entries saved: %+v", saved)
+	}
+}
+
+func TestCaptureUpdate(t *testing.T) {
+	d := newDriver(t)
+	monday := wednesday.AddDate(0, 0, -2)
+	d.Entries = []common.FakeEntry{
+		{Id: 200, Date: monday, Duration: 2, Notes: "Design", Author: displayName},
+		{Id: 200, Date: wednesday, Duration: 4, Notes: "Build", Author: displayName},
+		{Id: 200, Date: wednesday.AddDate(0, 0, 1), Duration: 1, Notes: "Test", Author: displayName},
+	}
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: monday, Duration: 1.5, Summary: "Design review"},
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 3, Summary: "Build"},
+	)
+
+	// The update of Wednesday is declined
+	confirm := func(te *at.TimeEntry) bool { return !te.Date.Equal(wednesday) }
+
+	err := Capture(d, displayName, entries, d.DateFormat, d.DayFormat, at.DuplicateUpdate, at.ConsolidateFail, confirm, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if called(d, "EditWeekEntry") != 1 || called(d, "NewWeekEntry") != 0 {
+		t.Errorf("expected the existing week to be edited, calls: %v", d.Calls)
+	}
+	if !entries[0].Updated() || !entries[1].Exists || entries[1].Submitted {
+		t.Errorf("expected only the confirmed entry to be updated, got %v and %v", entries[0].Status(), entries[1].Status())
+	}
+
+	saved := d.Saved()
+	if len(saved) != 3 || saved[0].Duration != 1.5 || saved[0].Notes != "Design review" || saved[1].Duration != 4 || saved[2].Notes != "Test" {
+		t.Errorf("expected only Monday to be overwritten, got %+v", saved)
+	}
+}
+
+func TestCaptureSameDay(t *testing.T) {
+	d := newDriver(t)
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 1, Summary: "Morning"},
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 2, Summary: "Afternoon"},
+		at.RequestEntry{Id: 200, Date: wednesday.AddDate(0, 0, 1), Duration: 1, Summary: "Next day"},
+	)
+
+	capture(t, d, entries)
+
+	for _, e := range entries[:2] {
+		if e.Error == nil || !strings.Contains(e.Error.Error(), "more than one entry") {
+			t.Errorf("expected an error for entries on the same day, got: %v", e.Error)
+		}
+	}
+	if !entries[2].Submitted {
+		t.Errorf("expected the entry on the next day to be saved, got: %v", entries[2].Error)
+	}
+}
+
+func TestCaptureSameDayMerged(t *testing.T) {
+	d := newDriver(t)
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday, StartTime: "13:00", Duration: 2, Summary: "Afternoon"},
+		at.RequestEntry{Id: 200, Date: wednesday, StartTime: "09:00", Duration: 1, Summary: "Morning"},
+		at.RequestEntry{Id: 200, Date: wednesday.AddDate(0, 0, 1), Duration: 1, Summary: "Next day"},
+	)
+
+	var events []at.Event
+	obs := at.ObserverFunc(func(e at.Event) { events = append(events, e) })
+
+	err := Capture(d, displayName, entries, d.DateFormat, d.DayFormat, at.DuplicateSkip, at.ConsolidateMerge, nil, obs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, e := range entries {
+		if !e.Submitted || e.Error != nil {
+			t.Errorf("expected entry %v on %v to be saved, got: %v", e.StartTimeStr, e.DateStr, e.Error)
+		}
+	}
+	if entries[0].Merged != 2 || entries[1].Merged != 2 || entries[2].Merged != 0 {
+		t.Errorf("expected the entries of Wednesday to be merged, got %d, %d, %d", entries[0].Merged, entries[1].Merged, entries[2].Merged)
+	}
+
+	saved := d.Saved()
+	if len(saved) != 2 || saved[0].Duration != 3 || saved[0].Notes != "09:00 Morning\n13:00 Afternoon" {
+		t.Errorf("expected a single entry on Wednesday, got: %+v", saved)
+	}
+
+	// Each entry of the file is reported, after the week is saved
+	saves := 0
+	for _, e := range events {
+		if e.Type == at.EventEntrySaved {
+			saves++
+		}
+	}
+	if saves != 3 {
+		t.Errorf("expected an event per entry, got %+v", events)
+	}
+}
+
+func TestCaptureOptionalFields(t *testing.T) {
+	d := newDriver(t)
+	invoice := true
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 1, Summary: "Design", Role: "Consultant", BillingCode: "Project", InternalNotes: "Phase 2", ShowOnInvoice: &invoice},
+		at.RequestEntry{Id: 200, Date: wednesday.AddDate(0, 0, 1), Duration: 1, Summary: "Build"},
+	)
+
+	capture(t, d, entries)
+
+	saved := d.Saved()
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 entries but got: %+v", saved)
+	}
+
+	e := saved[0]
+	if e.Role != "Consultant" || e.BillingCode != "Project" || e.InternalNotes != "Phase 2" || e.ShowOnInvoice == nil || !*e.ShowOnInvoice {
+		t.Errorf("expected the optional fields to be filled in on the day, got: %+v", e)
+	}
+	if e := saved[1]; e.Role != "" || e.InternalNotes != "" || e.ShowOnInvoice != nil {
+		t.Errorf("expected the fields of the other day to keep their default, got: %+v", e)
+	}
+}
+
+func TestCaptureErrors(t *testing.T) {
+	d := newDriver(t)
+	d.Fail = map[string]error{"FillDay": errors.New("duration not found")}
+
+	entries := newEntries(d, at.RequestEntry{Id: 200, Date: wednesday, Duration: 1, Summary: "Build"})
+	capture(t, d, entries)
+
+	if entries[0].Submitted || entries[0].Error == nil || !strings.Contains(entries[0].Error.Error(), "duration not found") {
+		t.Errorf("expected the entry to fail, got: %v", entries[0].Error)
+	}
+
+	d = newDriver(t)
+	d.Fail = map[string]error{"OpenTask": errors.New("page not found")}
+
+	entries = newEntries(d,
+		at.RequestEntry{Id: 200, Date: wednesday, Duration: 1, Summary: "Build"},
+		at.RequestEntry{Id: 201, Date: wednesday, Duration: 1, Summary: "Test"},
+	)
+	capture(t, d, entries)
+
+	for _, e := range entries {
+		if e.Status() != at.StatusFailed {
+			t.Errorf("expected entry %v to fail, got: %v", e.Id, e.Status())
+		}
+	}
+}
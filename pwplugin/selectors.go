@@ -0,0 +1,186 @@
+package pwplugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+	"github.com/playwright-community/playwright-go"
+)
+
+// Statuses of a selector check.
+const (
+	SelectorFound      = "found"
+	SelectorMissing    = "missing"
+	SelectorAbsent     = "absent"        // An optional element that isn't shown
+	SelectorUsed       = "used to login" // Login selectors are checked by logging in
+	SelectorNotChecked = "not checked"
+)
+
+// SelectorCheck is the result of checking a selector on the live AutoTask pages.
+type SelectorCheck struct {
+	common.SelectorDef
+	Selector string // Selector in use, the default or its override.
+	Matches  int    // Number of elements found.
+	Status   string
+}
+
+// relativeSelectors are found within a conversation.
+var relativeSelectors = map[string]bool{
+	common.SelectorConvAuthor:  true,
+	common.SelectorConvTitle:   true,
+	common.SelectorConvActions: true,
+	common.SelectorConvBody:    true,
+}
+
+// CheckSelectors logs in to AutoTask and counts the elements each selector finds on the pages it
+// belongs to, including the timesheet of the current week. Ticket and task pages are only checked
+// when an ID is given, pick ones with your own time entries. The time entry dialogs are opened but
+// never saved.
+func CheckSelectors(ctx context.Context, opts at.CaptureOptions, ticketId, taskId int) ([]SelectorCheck, error) {
+	sel, err := common.LoadSelectors(opts.SelectorsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Always login, the login selectors can only be checked while logging in
+	opts.FreshLogin = true
+
+	s, err := openBrowser(ctx, opts, sel)
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	var result []SelectorCheck
+	for _, def := range common.SelectorDefs() {
+		result = append(result, SelectorCheck{SelectorDef: def, Selector: sel.Get(def.Name), Status: SelectorNotChecked})
+	}
+
+	check := func(pages ...string) {
+		for i := range result {
+			c := &result[i]
+			for _, page := range pages {
+				if c.Page == page {
+					c.check(s.page, sel)
+				}
+			}
+		}
+	}
+
+	for i := range result {
+		if p := result[i].Page; p == common.PageLogin || p == common.PageEntra {
+			result[i].Status = SelectorUsed
+		}
+	}
+
+	check(common.PageLanding)
+
+	d := common.NewPlaywrightDriver(s.page, sel)
+
+	week := at.SundayOfTheWeek(time.Now())
+	err = d.OpenTimesheet(week)
+	if err != nil {
+		slog.Warn("could not open the timesheet", "week", week.Format(time.DateOnly), "error", err)
+	}
+	check(common.PageTimesheet)
+
+	if ticketId > 0 {
+		err = d.OpenTicket(ticketId)
+		if err != nil {
+			return nil, err
+		}
+		check(common.PageDetail, common.PageTicket)
+
+		err = d.NewTicketEntry()
+		if err != nil {
+			slog.Warn("could not open the time entry dialog", "ticket", ticketId, "error", err)
+		}
+		check(common.PageTicketEntry)
+	}
+
+	if taskId > 0 {
+		err = d.OpenTask(taskId)
+		if err != nil {
+			return nil, err
+		}
+		if ticketId <= 0 {
+			check(common.PageDetail)
+		}
+		check(common.PageTask)
+
+		err = d.NewWeekEntry()
+		if err == nil {
+			_, err = d.WeekStart()
+		}
+		if err != nil {
+			slog.Warn("could not open the week entry dialog", "task", taskId, "error", err)
+		}
+		check(common.PageWeekEntry)
+
+		err = d.EditWeek()
+		if err != nil {
+			slog.Warn("could not edit the days of the week", "task", taskId, "error", err)
+		}
+		check(common.PageDayEntry)
+	}
+
+	return result, nil
+}
+
+// check counts the elements of the selector on the page.
+func (c *SelectorCheck) check(page playwright.Page, sel common.Selectors) {
+	locator := page.Locator(c.Selector)
+	if relativeSelectors[c.Name] {
+		locator = page.Locator(sel.Get(common.SelectorConversation)).Locator(c.Selector)
+	}
+
+	count, err := locator.Count()
+	if err != nil {
+		slog.Warn("could not check selector", "selector", c.Name, "error", err)
+	}
+
+	c.Matches = count
+	switch {
+	case count > 0:
+		c.Status = SelectorFound
+	case c.Optional:
+		c.Status = SelectorAbsent
+	default:
+		c.Status = SelectorMissing
+	}
+}
+
+// PrintSelectors prints a table of the selectors and the results of checking them.
+func PrintSelectors(w io.Writer, checks []SelectorCheck) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Name", "Page", "Selector", "Found", "Status"})
+
+	for _, c := range checks {
+		table.Append([]string{c.Name, c.Page, c.Selector, fmt.Sprintf("%d", c.Matches), c.Status})
+	}
+
+	table.Render()
+}
+
+// PrintSelectorCatalogue prints a table of the selectors in use, marking the overridden ones.
+func PrintSelectorCatalogue(w io.Writer, sel common.Selectors) {
+	table := tablewriter.NewWriter(w)
+	table.Header([]string{"Name", "Page", "Selector", "Overridden", "Description"})
+
+	for _, def := range common.SelectorDefs() {
+		overridden := ""
+		if sel.Get(def.Name) != def.Default {
+			overridden = "yes"
+		}
+
+		table.Append([]string{def.Name, def.Page, sel.Get(def.Name), overridden, def.Description})
+	}
+
+	table.Render()
+}
@@ -2,140 +2,134 @@ package servicedesk
 
 import (
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 
 	"github.com/philipf/gt-at/at"
 	"github.com/philipf/gt-at/pwplugin/common"
-	"github.com/playwright-community/playwright-go"
 )
 
-func Capture(page playwright.Page, userDisplayName string, entries at.TimeEntries, dateFormat string) error {
-	log.Printf("Capture entries for a total of %v tickets\n", len(entries))
+func Capture(d common.Driver, userDisplayName string, entries at.TimeEntries, dateFormat string, policy at.DuplicatePolicy, confirm func(te *at.TimeEntry) bool, obs at.Observer) error {
+	slog.Info("Capture ticket entries", "entries", len(entries))
 	ticketIds := entries.DistinctIds()
 
 	for _, ticketId := range ticketIds {
-		err := captureByTicketId(page, ticketId, entries, userDisplayName, dateFormat)
+		err := captureByTicketId(d, ticketId, entries, userDisplayName, dateFormat, policy, confirm, obs)
 		if err != nil {
-			fmt.Printf("Capture: could not log time entries for ticketId: %v, error: %v\n", ticketId, err)
+			slog.Error("Capture: could not log time entries", "ticket", ticketId, "error", err)
+			for _, te := range entries.ById(ticketId).SetUnprocessedError(err) {
+				at.NotifyEntry(obs, te)
+			}
 		}
 	}
 
 	return nil
 }
 
-func captureByTicketId(page playwright.Page, ticketId int, entries at.TimeEntries, userDisplayName, dateFormat string) error {
-	_, err := page.Goto(fmt.Sprintf(at.URI_TICKET_DETAIL, at.BaseURL, ticketId))
-
+func captureByTicketId(d common.Driver, ticketId int, entries at.TimeEntries, userDisplayName, dateFormat string, policy at.DuplicatePolicy, confirm func(te *at.TimeEntry) bool, obs at.Observer) error {
+	err := d.OpenTicket(ticketId)
 	if err != nil {
-		log.Fatalf("logTimeEntries: could not goto ticketDetailUri: %v", err)
+		return fmt.Errorf("logTimeEntries: could not open ticket: %v", err)
 	}
 
-	log.Println("Waiting for conversation details to load")
-
-	err = page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
-		State:   playwright.LoadStateNetworkidle,
-		Timeout: playwright.Float(5000),
-	})
-
-	if alertVisible, _ := page.Locator("#AlertDialog.Active").IsVisible(); alertVisible {
-		page.Locator("#AlertDialogOkayButton").Click()
-	}
-
-	if err != nil {
-		if strings.Contains(err.Error(), "timeout") {
-			log.Println("Timeout waiting for first conversation details to load")
-		} else {
-			return fmt.Errorf("logTimeEntries: could not find details: %v", err)
-		}
-	}
-	log.Println("Conversations Loaded")
+	at.Notify(obs, at.Event{Type: at.EventTicketOpened, Id: ticketId})
 
 	// Build an array of ticket entries for a given ticketId
 	// Doing this to be a little more efficient and reduce the number of page loads
 	entriesById := entries.ById(ticketId)
 
-	err = common.MarkExisiting(page, userDisplayName, entriesById, dateFormat)
+	_, updates, err := common.MarkExisiting(d, userDisplayName, entriesById, dateFormat, policy)
 	if err != nil {
 		return fmt.Errorf("logTimeEntries: could not mark existing entries: %v", err)
 	}
 
+	entriesById.ConfirmUpdates(confirm)
+
+	// The conversations were read before saving anything, a new entry would move them down, so the
+	// existing entries are updated first
 	for _, te := range entriesById {
-		err := captureEntry(page, te)
-		if err != nil {
+		if !te.Updates() {
+			continue
+		}
+		if err := updateEntry(d, te, updates[te]); err != nil {
+			te.SetError(err)
+		}
+		at.NotifyEntry(obs, te)
+	}
+
+	for _, te := range entriesById {
+		if te.Updates() {
+			continue
+		}
+		if err := captureEntry(d, te); err != nil {
 			te.SetError(err)
 		}
+		at.NotifyEntry(obs, te)
 	}
 
-	log.Println("Done loading")
+	slog.Debug("Done loading", "ticket", ticketId)
 
 	return nil
 }
 
-func captureEntry(page playwright.Page, te *at.TimeEntry) error {
-	log.Printf("Capture time entry: %+v\n", te)
+func captureEntry(d common.Driver, te *at.TimeEntry) error {
+	te.SetStarted()
+	defer te.SetFinished()
+
+	slog.Debug("Capture time entry", "ticket", te.Id, "date", te.DateStr, "start", te.StartTimeStr, "duration", te.Duration)
 	if !te.IsTicket {
 		return fmt.Errorf("captureEntry: only ticket time entries are supported")
 	}
 
 	if te.Exists {
-		log.Printf("Skipping entry as it already exists: %+v\n", te)
+		slog.Info("Skipping entry as it already exists", "ticket", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
 		return nil
 	}
 
-	page.Locator("[data-eii='000001Bb']").Click() // New Time Entry button
-	timeEntryDialog := page.Locator("body > div.Dialog1.Dialog2.Normal.Active")
-	err := timeEntryDialog.WaitFor()
+	err := d.NewTicketEntry()
 	if err != nil {
-		return fmt.Errorf("captureEntry: could not find dialog: %v", err)
+		return fmt.Errorf("captureEntry: %v", err)
 	}
 
-	if err := page.Locator("[data-eii='010000xs'] > input[type=text]").Fill(te.DateStr); err != nil {
-		return fmt.Errorf("captureEntry: could not fill date: %v", err)
-	}
-	if err := page.Locator("[data-eii='010000xt'] > input[type=text]").Fill(te.StartTimeStr); err != nil {
-		return fmt.Errorf("captureEntry: could not fill start time: %v", err)
+	err = d.FillTicketEntry(te)
+	if err != nil {
+		return fmt.Errorf("captureEntry: %v", err)
 	}
 
-	inputs := page.Locator("[data-eii='000001GH'] input[type='text']") // Duration
-
-	if err := inputs.First().Fill(te.DurationHoursStr); err != nil {
-		return fmt.Errorf("captureEntry: could not fill hours: %v", err)
+	err = d.SaveTicketEntry()
+	if err != nil {
+		return fmt.Errorf("captureEntry: %v", err)
 	}
 
-	if err := inputs.Nth(1).Fill(te.DurationMinutesStr); err != nil {
-		return fmt.Errorf("captureEntry: could not fill minutes: %v", err)
-	}
+	te.Submitted = true
+	slog.Info("Saved entry", "ticket", te.Id, "date", te.DateStr, "start", te.StartTimeStr)
 
-	summaryNotes := page.Locator("[data-eii='000001GK']  > div.Content2 > div.InputWrapper2 > div.ContentEditable2.Small") // Summary Notes
-	if err := summaryNotes.Fill(te.Summary); err != nil {
-		return fmt.Errorf("captureEntry: could not fill summary: %v", err)
-	}
+	return nil
+}
 
-	page.WaitForTimeout(1000) // Forced wait to allow the page to catch up
+// updateEntry overwrites the existing entry of the conversation with the duration and summary of the entry.
+func updateEntry(d common.Driver, te *at.TimeEntry, conv common.Conversation) error {
+	te.SetStarted()
+	defer te.SetFinished()
 
-	saveButton := page.Locator("[data-eii='010000xo']") // Save button
+	slog.Debug("Update time entry", "ticket", te.Id, "date", te.DateStr, "start", te.StartTimeStr, "duration", te.Duration)
 
+	err := d.EditTicketEntry(conv)
 	if err != nil {
-		return fmt.Errorf("captureEntry: could not wait for dialog to close: %v", err)
+		return fmt.Errorf("updateEntry: %v", err)
 	}
 
-	err = saveButton.Click()
+	err = d.FillTicketEntry(te)
 	if err != nil {
-		return fmt.Errorf("captureEntry: could not click save button: %v", err)
-	} else {
-		log.Println("clicked save button")
+		return fmt.Errorf("updateEntry: %v", err)
 	}
 
-	err = timeEntryDialog.WaitFor(playwright.LocatorWaitForOptions{
-		State: playwright.WaitForSelectorStateDetached,
-	})
-
+	err = d.SaveTicketEntry()
 	if err != nil {
-		return fmt.Errorf("captureEntry: could not wait for dialog to close: %v", err)
+		return fmt.Errorf("updateEntry: %v", err)
 	}
 
 	te.Submitted = true
+	slog.Info("Updated entry", "ticket", te.Id, "date", te.DateStr, "start", te.StartTimeStr, "duration", te.Existing.Duration, "to", te.Duration)
 
 	return nil
 }
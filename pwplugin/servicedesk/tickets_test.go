@@ -0,0 +1,332 @@
+package servicedesk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+const displayName = "Jo Bloggs"
+
+var friday = time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)
+
+func newEntries(d *common.FakeDriver, entries ...at.RequestEntry) at.TimeEntries {
+	return at.ToTimeEntries(entries, d.DateFormat)
+}
+
+func TestCapture(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Existing", Author: displayName},
+		{Id: 101, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Someone else", Author: "Other"},
+	}
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 0.5, Summary: "Same day"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday.AddDate(0, 0, 3), StartTime: "09:00", Duration: 1.25, Summary: "Monday"},
+		at.RequestEntry{Id: 101, IsTicket: true, Date: friday, StartTime: "13:00", Duration: 2, Summary: "Other ticket"},
+	)
+
+	err := Capture(d, displayName, entries, d.DateFormat, at.DuplicateSkip, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !entries[0].Exists || entries[0].Submitted {
+		t.Errorf("expected the entry on the same day to be skipped, got: %v", entries[0].Status())
+	}
+	for _, e := range entries[1:] {
+		if !e.Submitted || e.Exists || e.Error != nil {
+			t.Errorf("expected entry %v on %v to be saved, got: %v", e.Id, e.DateStr, e.Error)
+		}
+	}
+
+	saved := d.Saved()
+	if len(saved) != 4 {
+		t.Fatalf("expected 4 entries but got: %+v", saved)
+	}
+	if saved[1].StartTime != "09:00" || saved[1].Duration != 1.25 || saved[1].Notes != "Monday" {
+		t.Errorf("unexpected entry saved: %+v", saved[1])
+	}
+}
+
+func TestCaptureOptionalFields(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	billable := false
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 1, Summary: "Billed",
+			EndTime: "11:45", Role: "Engineer", WorkType: "Remote", InternalNotes: "Reboot needed", NonBillable: &billable, TicketStatus: "Complete"},
+		at.RequestEntry{Id: 101, IsTicket: true, Date: friday, StartTime: "13:00", Duration: 1, Summary: "Defaults"},
+	)
+
+	err := Capture(d, displayName, entries, d.DateFormat, at.DuplicateSkip, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved := d.Saved()
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 entries but got: %+v", saved)
+	}
+
+	e := saved[0]
+	if e.EndTime != "11:45" || e.Role != "Engineer" || e.WorkType != "Remote" || e.InternalNotes != "Reboot needed" || e.TicketStatus != "Complete" {
+		t.Errorf("expected the optional fields to be filled in, got: %+v", e)
+	}
+	if e.NonBillable == nil || *e.NonBillable || e.ShowOnInvoice != nil {
+		t.Errorf("expected only the non-billable flag to be set, got %v, %v", e.NonBillable, e.ShowOnInvoice)
+	}
+
+	if e := saved[1]; e.Role != "" || e.EndTime != "" || e.NonBillable != nil || e.TicketStatus != "" {
+		t.Errorf("expected the fields that aren't set to keep their default, got: %+v", e)
+	}
+}
+
+func TestCaptureDuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		policy at.DuplicatePolicy
+		marker bool
+		saved  bool
+	}{
+		{at.DuplicateSkip, false, false},
+		{at.DuplicateWarn, false, true},
+		{at.DuplicateWarn, true, false},
+		{at.DuplicateForce, true, true},
+	}
+
+	for _, tt := range tests {
+		d := common.NewFakeDriver(displayName)
+		entries := newEntries(d, at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 0.5, Summary: "Work"})
+
+		notes := "Other work"
+		if tt.marker {
+			entries.EmbedMarkers()
+			notes = entries[0].Notes()
+		}
+		d.Entries = []common.FakeEntry{{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 0.5, Notes: notes, Author: displayName}}
+
+		Capture(d, displayName, entries, d.DateFormat, tt.policy, nil, nil)
+
+		if entries[0].Submitted != tt.saved {
+			t.Errorf("%v with marker %v: expected saved to be %v", tt.policy, tt.marker, tt.saved)
+		}
+	}
+}
+
+func TestCaptureUpdate(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Stand-up", Author: displayName},
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 2, Notes: "Fixing", Author: displayName},
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "13:00", Duration: 1, Notes: "Review", Author: displayName},
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "15:00", Duration: 1, Notes: "Theirs", Author: "Other"},
+	}
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Summary: "Stand-up"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 1.5, Summary: "Fixed it"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "13:00", Duration: 0.5, Summary: "Review"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "15:00", Duration: 1, Summary: "Mine"},
+	)
+
+	// Only the review is declined
+	var asked []string
+	confirm := func(te *at.TimeEntry) bool {
+		asked = append(asked, te.StartTimeStr)
+		return te.StartTimeStr != "13:00"
+	}
+
+	var events []at.EventType
+	obs := at.ObserverFunc(func(e at.Event) { events = append(events, e.Type) })
+
+	Capture(d, displayName, entries, d.DateFormat, at.DuplicateUpdate, confirm, obs)
+
+	if len(asked) != 2 || asked[0] != "10:30" || asked[1] != "13:00" {
+		t.Errorf("expected to confirm the changed entries, got %v", asked)
+	}
+	if !entries[0].Exists || entries[0].Submitted {
+		t.Errorf("expected the identical entry to be skipped, got %v", entries[0].Status())
+	}
+	if !entries[1].Updated() || entries[1].Existing.Duration != 2 || entries[1].Existing.Summary != "Fixing" {
+		t.Errorf("expected the changed entry to be updated, got %+v", entries[1])
+	}
+	if !entries[2].Exists || entries[2].Submitted {
+		t.Errorf("expected the declined entry to be skipped, got %v", entries[2].Status())
+	}
+	if !entries[3].Submitted || entries[3].Updates() {
+		t.Errorf("expected the entry to be saved next to the entry of someone else, got %+v", entries[3])
+	}
+
+	saved := d.Saved()
+	if len(saved) != 5 || saved[1].Duration != 1.5 || saved[1].Notes != "Fixed it" || saved[2].Duration != 1 {
+		t.Errorf("expected only the confirmed entry to be overwritten, got %+v", saved)
+	}
+	if called := strings.Count(strings.Join(d.Calls, ","), "EditTicketEntry"); called != 1 {
+		t.Errorf("expected a single entry to be edited, calls: %v", d.Calls)
+	}
+
+	// Updates come first
+	expected := []at.EventType{at.EventTicketOpened, at.EventEntryUpdated, at.EventEntrySkippedExisting, at.EventEntrySkippedExisting, at.EventEntrySaved}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestCaptureNewThenUpdate(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Stand-up", Author: displayName},
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 2, Notes: "Fixing", Author: displayName},
+	}
+
+	// Saving the new entry first would move the conversation of the update down
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "14:00", Duration: 1, Summary: "New"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 0.5, Summary: "Stand-up"},
+	)
+
+	Capture(d, displayName, entries, d.DateFormat, at.DuplicateUpdate, nil, nil)
+
+	if !entries[0].Submitted || !entries[1].Updated() || entries[1].Error != nil {
+		t.Fatalf("expected the new entry to be saved and the other updated, got %v, %v", entries[0].Status(), entries[1].Error)
+	}
+
+	saved := d.Saved()
+	if len(saved) != 3 || saved[0].StartTime != "08:00" || saved[0].Duration != 0.5 ||
+		saved[1].Notes != "Fixing" || saved[1].Duration != 2 || saved[2].Notes != "New" {
+		t.Errorf("expected only the stand-up to be updated, got %+v", saved)
+	}
+}
+
+func TestEditTicketEntryMoved(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Stand-up", Author: displayName},
+	}
+
+	d.OpenTicket(100)
+	convs, _ := d.Conversations()
+
+	// Another entry is saved after the conversations were read
+	d.NewTicketEntry()
+	d.FillTicketEntry(newEntries(d, at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "14:00", Duration: 1, Summary: "New"})[0])
+	d.SaveTicketEntry()
+
+	err := d.EditTicketEntry(convs[0])
+	if err == nil || !strings.Contains(err.Error(), "expected conversation") {
+		t.Errorf("expected editing a moved conversation to fail, got %v", err)
+	}
+}
+
+func TestCaptureErrors(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Fail = map[string]error{"SaveTicketEntry": errors.New("save button not found")}
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 0.5, Summary: "Work"},
+		at.RequestEntry{Id: 100, IsTicket: false, Date: friday, StartTime: "11:30", Duration: 0.5, Summary: "Task"},
+	)
+
+	Capture(d, displayName, entries, d.DateFormat, at.DuplicateSkip, nil, nil)
+
+	for _, e := range entries {
+		if e.Submitted || e.Error == nil {
+			t.Errorf("expected entry at %v to fail", e.StartTimeStr)
+		}
+	}
+	if len(d.Saved()) != 0 {
+		t.Errorf("expected nothing to be saved, got: %+v", d.Saved())
+	}
+}
+
+// cancelOnSave cancels the context once the first entry is saved.
+type cancelOnSave struct {
+	common.Driver
+	cancel context.CancelFunc
+}
+
+func (c cancelOnSave) SaveTicketEntry() error {
+	err := c.Driver.SaveTicketEntry()
+	c.cancel()
+	return err
+}
+
+func TestCaptureCancelled(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Summary: "First"},
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "09:00", Duration: 1, Summary: "Second"},
+		at.RequestEntry{Id: 101, IsTicket: true, Date: friday, StartTime: "10:00", Duration: 1, Summary: "Third"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := Capture(common.WithContext(ctx, cancelOnSave{d, cancel}), displayName, entries, d.DateFormat, at.DuplicateForce, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !entries[0].Submitted {
+		t.Errorf("expected the first entry to be saved, got: %v", entries[0].Error)
+	}
+	for _, e := range entries[1:] {
+		if e.Submitted || e.Error == nil || !strings.Contains(e.Error.Error(), context.Canceled.Error()) {
+			t.Errorf("expected %v to be cancelled, got: %v", e.Summary, e.Error)
+		}
+	}
+
+	if len(d.Saved()) != 1 {
+		t.Errorf("expected only one entry to be saved, got: %+v", d.Saved())
+	}
+}
+
+// failOpen fails to open one ticket.
+type failOpen struct {
+	common.Driver
+	id int
+}
+
+func (f failOpen) OpenTicket(id int) error {
+	if id == f.id {
+		return errors.New("not found")
+	}
+	return f.Driver.OpenTicket(id)
+}
+
+func TestCaptureEvents(t *testing.T) {
+	d := common.NewFakeDriver(displayName)
+	d.Entries = []common.FakeEntry{
+		{Id: 100, IsTicket: true, Date: friday, StartTime: "08:00", Duration: 1, Notes: "Existing", Author: displayName},
+	}
+
+	entries := newEntries(d,
+		at.RequestEntry{Id: 100, IsTicket: true, Date: friday, StartTime: "10:30", Duration: 0.5, Summary: "Same day"},
+		at.RequestEntry{Id: 101, IsTicket: true, Date: friday, StartTime: "13:00", Duration: 2, Summary: "Other ticket"},
+		at.RequestEntry{Id: 102, IsTicket: true, Date: friday, StartTime: "15:00", Duration: 1, Summary: "Missing ticket"},
+	)
+
+	var events []string
+	obs := at.ObserverFunc(func(e at.Event) {
+		events = append(events, fmt.Sprintf("%v %v", e.Type, e.Id))
+	})
+
+	Capture(failOpen{d, 102}, displayName, entries, d.DateFormat, at.DuplicateSkip, nil, obs)
+
+	expected := []string{
+		"ticket-opened 100", "entry-skipped-existing 100",
+		"ticket-opened 101", "entry-saved 101",
+		"entry-failed 102",
+	}
+	if strings.Join(events, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
@@ -0,0 +1,147 @@
+package pwplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/playwright-community/playwright-go"
+)
+
+// storedSession is the on-disk representation of an authenticated browser session.
+type storedSession struct {
+	Username string                   `json:"username"`
+	BaseURL  string                   `json:"baseUrl"`
+	SavedAt  time.Time                `json:"savedAt"`
+	State    *playwright.StorageState `json:"storageState"`
+}
+
+// SessionPath returns the location of the stored browser session for the given username.
+// Sessions are kept per user under the user's configuration directory.
+func SessionPath(username string) (string, error) {
+	dataDir, err := at.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "sessions", sessionFileName(username)), nil
+}
+
+// ClearSession removes the stored browser session for the given username, if any.
+func ClearSession(username string) error {
+	path, err := SessionPath(username)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove session file: %v", err)
+	}
+
+	return nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// sessionFileName derives a safe file name from the username.
+func sessionFileName(username string) string {
+	name := unsafeFileChars.ReplaceAllString(strings.ToLower(username), "_")
+	if name == "" {
+		name = "default"
+	}
+
+	return name + ".json"
+}
+
+// loadSession reads a stored session, it returns nil when no session has been stored yet.
+func loadSession(path string) (*storedSession, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read session file: %v", err)
+	}
+
+	var s storedSession
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal session file: %v", err)
+	}
+
+	if s.State == nil || s.BaseURL == "" {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// saveSession writes the storage state (cookies and local storage) of the browser context
+// to path, readable only by the current user.
+func saveSession(path, username string, ctx playwright.BrowserContext) error {
+	state, err := ctx.StorageState()
+	if err != nil {
+		return fmt.Errorf("could not get storage state: %v", err)
+	}
+
+	data, err := json.Marshal(storedSession{
+		Username: username,
+		BaseURL:  at.BaseURL,
+		SavedAt:  time.Now(),
+		State:    state,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal session: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create session directory: %v", err)
+	}
+
+	// Write to a temporary file first so that a partially written session is never reused
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write session file: %v", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("could not replace session file: %v", err)
+	}
+
+	return nil
+}
+
+// resumeSession checks if a stored session is still valid by navigating to the landing page,
+// an expired session is redirected to the authentication page instead.
+func resumeSession(page playwright.Page, baseURL string) bool {
+	slog.Info("Reusing stored session, checking if it is still valid")
+
+	_, err := page.Goto(fmt.Sprintf(at.URI_LANDING, baseURL))
+	if err != nil {
+		slog.Warn("could not goto landing page", "error", err)
+		return false
+	}
+
+	urlRegEx := regexp.MustCompile(".*" + at.URI_LANDING_SUFFIX)
+	err = page.WaitForURL(urlRegEx, playwright.PageWaitForURLOptions{
+		Timeout: playwright.Float(15 * 1000),
+	})
+	if err != nil {
+		slog.Info("Stored session has expired, a full login is required")
+		return false
+	}
+
+	slog.Debug("Stored session is valid")
+	return true
+}
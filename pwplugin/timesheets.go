@@ -0,0 +1,59 @@
+package pwplugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+	"github.com/philipf/gt-at/pwplugin/timesheets"
+)
+
+// Timesheets returns the status of the timesheets of the weeks.
+func (atp *autoTaskPlaywright) Timesheets(ctx context.Context, weeks []time.Time, opts at.CaptureOptions) ([]at.Timesheet, error) {
+	var result []at.Timesheet
+	err := withDriver(ctx, opts, func(d common.Driver) error {
+		result = timesheets.Status(d, weeks)
+		return nil
+	})
+
+	return result, err
+}
+
+// SubmitTimesheet submits the timesheet of the week for approval.
+func (atp *autoTaskPlaywright) SubmitTimesheet(ctx context.Context, week time.Time, opts at.CaptureOptions) error {
+	return withDriver(ctx, opts, func(d common.Driver) error {
+		return timesheets.Submit(d, week)
+	})
+}
+
+// RecallTimesheet recalls the submitted timesheet of the week.
+func (atp *autoTaskPlaywright) RecallTimesheet(ctx context.Context, week time.Time, opts at.CaptureOptions) error {
+	return withDriver(ctx, opts, func(d common.Driver) error {
+		return timesheets.Recall(d, week)
+	})
+}
+
+// withDriver logs in to AutoTask and calls f with a driver for the browser page, which is closed
+// afterwards.
+func withDriver(ctx context.Context, opts at.CaptureOptions, f func(d common.Driver) error) error {
+	sel, err := common.LoadSelectors(opts.SelectorsFile)
+	if err != nil {
+		return err
+	}
+
+	s, err := openBrowser(ctx, opts, sel)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	err = f(common.WithContext(ctx, common.NewPlaywrightDriver(s.page, sel)))
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	s.save()
+
+	return err
+}
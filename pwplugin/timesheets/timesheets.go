@@ -0,0 +1,194 @@
+package timesheets
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+// Status opens the timesheet of each week and returns its status. When the status can't be read
+// the timesheet is unknown, with the error.
+func Status(d common.Driver, weeks []time.Time) []at.Timesheet {
+	return status(d, weeks, false)
+}
+
+// errSkipped is the error of the timesheets that weren't opened after another one failed.
+var errSkipped = errors.New("skipped, a previous timesheet could not be checked")
+
+// status returns the status of the timesheet of each week, if stop is set the timesheets after the
+// first one that fails aren't opened.
+func status(d common.Driver, weeks []time.Time, stop bool) []at.Timesheet {
+	var result []at.Timesheet
+	failed := false
+
+	for _, week := range weeks {
+		sheet := at.Timesheet{Week: at.SundayOfTheWeek(week), Status: at.TimesheetUnknown}
+		if failed {
+			sheet.Err = errSkipped
+			result = append(result, sheet)
+			continue
+		}
+
+		err := d.OpenTimesheet(sheet.Week)
+		if err == nil {
+			sheet.Status, err = d.TimesheetStatus()
+		}
+		if err != nil {
+			sheet.Status, sheet.Err = at.TimesheetUnknown, err
+			failed = stop
+		}
+
+		result = append(result, sheet)
+	}
+
+	return result
+}
+
+// Preflight checks the timesheets of the weeks of the entries before capturing them. AutoTask
+// doesn't accept time in a submitted or approved week, so its entries are marked with an error
+// instead of aborting the whole import. The entries that can be captured are returned, including
+// those of weeks whose status is unknown. Once a timesheet can't be checked, the following weeks
+// are skipped, as they would most likely fail the same way.
+func Preflight(d common.Driver, entries at.TimeEntries, obs at.Observer) (at.TimeEntries, []at.Timesheet) {
+	sheets := status(d, entries.Weeks(), true)
+
+	locked := map[time.Time]at.TimesheetStatus{}
+	for i := range sheets {
+		sheet := &sheets[i]
+		if sheet.Err != nil {
+			slog.Warn("could not check the timesheet, capturing its entries anyway", "week", sheet.Week.Format(time.DateOnly), "error", sheet.Err)
+		} else {
+			slog.Info("Timesheet", "week", sheet.Week.Format(time.DateOnly), "status", sheet.Status)
+		}
+
+		if sheet.Status.Locked() {
+			locked[sheet.Week] = sheet.Status
+		}
+
+		at.Notify(obs, at.Event{Type: at.EventTimesheetChecked, Timesheet: sheet})
+	}
+
+	var open at.TimeEntries
+	for _, te := range entries {
+		week := at.SundayOfTheWeek(te.Date)
+		if status, ok := locked[week]; ok {
+			te.SetError(fmt.Errorf("the timesheet of the week of %v is %v, recall it to capture the entry", week.Format(time.DateOnly), status))
+			at.NotifyEntry(obs, te)
+			continue
+		}
+
+		open = append(open, te)
+	}
+
+	return open, sheets
+}
+
+// Checked reports whether the status of any of the timesheets could be read.
+func Checked(sheets []at.Timesheet) bool {
+	for _, sheet := range sheets {
+		if sheet.Err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Submit submits the timesheet of the week for approval, a timesheet that is already submitted
+// is left as is.
+func Submit(d common.Driver, week time.Time) error {
+	status, err := open(d, week)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case at.TimesheetSubmitted:
+		slog.Info("Timesheet already submitted", "week", week.Format(time.DateOnly))
+		return nil
+	case at.TimesheetApproved:
+		return fmt.Errorf("submit: the timesheet is already approved")
+	}
+
+	err = d.SubmitTimesheet()
+	if err != nil {
+		return fmt.Errorf("submit: %v", err)
+	}
+
+	slog.Info("Submitted timesheet", "week", week.Format(time.DateOnly))
+
+	return nil
+}
+
+// Recall recalls the submitted timesheet of the week, an open timesheet is left as is. An approved
+// timesheet can't be recalled.
+func Recall(d common.Driver, week time.Time) error {
+	status, err := open(d, week)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case at.TimesheetOpen:
+		slog.Info("Timesheet is not submitted", "week", week.Format(time.DateOnly))
+		return nil
+	case at.TimesheetApproved:
+		return fmt.Errorf("recall: the timesheet is approved, ask your approver to reject it")
+	}
+
+	err = d.RecallTimesheet()
+	if err != nil {
+		return fmt.Errorf("recall: %v", err)
+	}
+
+	slog.Info("Recalled timesheet", "week", week.Format(time.DateOnly))
+
+	return nil
+}
+
+// open opens the timesheet of the week and returns its status.
+func open(d common.Driver, week time.Time) (at.TimesheetStatus, error) {
+	err := d.OpenTimesheet(at.SundayOfTheWeek(week))
+	if err != nil {
+		return at.TimesheetUnknown, fmt.Errorf("could not open the timesheet: %v", err)
+	}
+
+	status, err := d.TimesheetStatus()
+	if err != nil {
+		return at.TimesheetUnknown, fmt.Errorf("could not get the timesheet status: %v", err)
+	}
+
+	return status, nil
+}
+
+// SubmitCaptured submits the timesheets of the weeks in which every entry was saved or already
+// existed. Weeks with entries that failed or weren't processed are left open, so they can be
+// fixed and imported again.
+func SubmitCaptured(d common.Driver, entries at.TimeEntries, obs at.Observer) {
+	for _, week := range entries.Weeks() {
+		complete := true
+		for _, te := range entries {
+			if status := te.Status(); at.SundayOfTheWeek(te.Date).Equal(week) && status != at.StatusSaved && status != at.StatusExists {
+				complete = false
+			}
+		}
+
+		if !complete {
+			slog.Warn("Not submitting the timesheet, not every entry of the week was captured", "week", week.Format(time.DateOnly))
+			continue
+		}
+
+		sheet := at.Timesheet{Week: week, Status: at.TimesheetSubmitted}
+		err := Submit(d, week)
+		if err != nil {
+			slog.Error("could not submit the timesheet", "week", week.Format(time.DateOnly), "error", err)
+			sheet.Status, sheet.Err = at.TimesheetUnknown, err
+		}
+
+		at.Notify(obs, at.Event{Type: at.EventTimesheetSubmitted, Timesheet: &sheet, Err: err})
+	}
+}
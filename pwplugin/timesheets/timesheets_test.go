@@ -0,0 +1,146 @@
+package timesheets
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipf/gt-at/at"
+	"github.com/philipf/gt-at/pwplugin/common"
+)
+
+var (
+	week1 = time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC)
+	week2 = week1.AddDate(0, 0, 7)
+	week3 = week2.AddDate(0, 0, 7)
+)
+
+func newEntries() at.TimeEntries {
+	return at.TimeEntries{
+		at.NewEntry(100, true, week1.AddDate(0, 0, 1), "08:00", 1, "Week 1", "", "2006/01/02"),
+		at.NewEntry(100, true, week2.AddDate(0, 0, 2), "08:00", 1, "Week 2", "", "2006/01/02"),
+		at.NewEntry(200, false, week3.AddDate(0, 0, 3), "", 1, "Week 3", "", "2006/01/02"),
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	d := common.NewFakeDriver("Jo Bloggs")
+	d.Timesheets = map[time.Time]at.TimesheetStatus{week2: at.TimesheetSubmitted, week3: at.TimesheetApproved}
+
+	var events []at.Event
+	obs := at.ObserverFunc(func(e at.Event) { events = append(events, e) })
+
+	entries := newEntries()
+	open, sheets := Preflight(d, entries, obs)
+
+	if len(open) != 1 || open[0] != entries[0] {
+		t.Errorf("expected only the entry of the open week to be captured, got %v", open)
+	}
+
+	expected := []at.TimesheetStatus{at.TimesheetOpen, at.TimesheetSubmitted, at.TimesheetApproved}
+	if len(sheets) != len(expected) {
+		t.Fatalf("expected %d timesheets, got %+v", len(expected), sheets)
+	}
+	for i, s := range sheets {
+		if s.Status != expected[i] || s.Err != nil {
+			t.Errorf("expected the timesheet of %v to be %v, got %v: %v", s.Week, expected[i], s.Status, s.Err)
+		}
+	}
+
+	if entries[1].Error == nil || !strings.Contains(entries[1].Error.Error(), "2023-09-17 is submitted") {
+		t.Errorf("expected the entry of the submitted week to fail, got %v", entries[1].Error)
+	}
+	if entries[2].Error == nil || entries[0].Error != nil {
+		t.Errorf("expected only the entries of locked weeks to fail, got %v, %v", entries[0].Error, entries[2].Error)
+	}
+
+	// A checked event per week and a failed event per locked entry
+	if len(events) != 5 || events[0].Type != at.EventTimesheetChecked || events[3].Type != at.EventEntryFailed {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestPreflightUnknown(t *testing.T) {
+	d := common.NewFakeDriver("Jo Bloggs")
+	d.Fail = map[string]error{"OpenTimesheet": errors.New("timeout")}
+
+	entries := newEntries()
+	open, sheets := Preflight(d, entries, nil)
+
+	if len(open) != len(entries) {
+		t.Errorf("expected every entry to be captured when the status is unknown, got %v", open)
+	}
+	if sheets[0].Status != at.TimesheetUnknown || sheets[0].Err == nil {
+		t.Errorf("expected an unknown status with the error, got %+v", sheets[0])
+	}
+
+	// The weeks after the first failure aren't opened
+	if len(d.Calls) != 1 || sheets[1].Err != errSkipped || sheets[2].Err != errSkipped {
+		t.Errorf("expected only the first timesheet to be opened, got calls %v and %+v", d.Calls, sheets)
+	}
+	if Checked(sheets) {
+		t.Error("expected none of the timesheets to be checked")
+	}
+
+	// Status checks every week
+	sheets = Status(d, []time.Time{week1, week2})
+	if len(sheets) != 2 || sheets[1].Err == errSkipped {
+		t.Errorf("expected every timesheet to be opened, got %+v", sheets)
+	}
+}
+
+func TestSubmitAndRecall(t *testing.T) {
+	d := common.NewFakeDriver("Jo Bloggs")
+	d.Timesheets = map[time.Time]at.TimesheetStatus{week3: at.TimesheetApproved}
+
+	// Any day of the week can be given
+	err := Submit(d, week1.AddDate(0, 0, 3))
+	if err != nil || d.Timesheets[week1] != at.TimesheetSubmitted {
+		t.Fatalf("expected the timesheet to be submitted, got %v: %v", d.Timesheets[week1], err)
+	}
+
+	err = Submit(d, week1)
+	if err != nil {
+		t.Errorf("expected submitting a submitted timesheet to succeed, got %v", err)
+	}
+
+	err = Recall(d, week1)
+	if err != nil || d.Timesheets[week1] != at.TimesheetOpen {
+		t.Errorf("expected the timesheet to be recalled, got %v: %v", d.Timesheets[week1], err)
+	}
+
+	err = Recall(d, week2)
+	if err != nil {
+		t.Errorf("expected recalling an open timesheet to succeed, got %v", err)
+	}
+
+	if Submit(d, week3) == nil || Recall(d, week3) == nil {
+		t.Error("expected an approved timesheet to be neither submitted nor recalled")
+	}
+}
+
+func TestSubmitCaptured(t *testing.T) {
+	d := common.NewFakeDriver("Jo Bloggs")
+
+	entries := newEntries()
+	entries[0].Submitted = true
+	entries[1].SetError(errors.New("boom"))
+	entries[2].Exists = true
+
+	var submitted []time.Time
+	obs := at.ObserverFunc(func(e at.Event) {
+		if e.Type == at.EventTimesheetSubmitted && e.Err == nil {
+			submitted = append(submitted, e.Timesheet.Week)
+		}
+	})
+
+	SubmitCaptured(d, entries, obs)
+
+	if len(submitted) != 2 || !submitted[0].Equal(week1) || !submitted[1].Equal(week3) {
+		t.Errorf("expected the weeks without failures to be submitted, got %v", submitted)
+	}
+	if d.Timesheets[week2] == at.TimesheetSubmitted {
+		t.Error("expected the week with a failed entry to stay open")
+	}
+}